@@ -0,0 +1,66 @@
+// Command godog-kubernetes-steps generates the catalog of all steps provided
+// by godog-kubernetes, as Markdown documentation, as JSON or as a Cucumber
+// step-definition file used by the IDE plugins for the autocompletion.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cucumber/godog"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+// catalogScenario is a ScenarioContext ignoring all hooks and steps; only
+// the step catalog of the FeatureContext is used.
+type catalogScenario struct{}
+
+func (catalogScenario) BeforeScenario(func(*godog.Scenario))       {}
+func (catalogScenario) AfterScenario(func(*godog.Scenario, error)) {}
+func (catalogScenario) BeforeStep(func(*godog.Step))               {}
+func (catalogScenario) AfterStep(func(*godog.Step, error))         {}
+func (catalogScenario) Step(interface{}, interface{})              {}
+
+var writers = map[string]func(io.Writer, []kubernetes_ctx.StepDefinition) error{
+	"markdown": kubernetes_ctx.WriteStepsMarkdown,
+	"json":     kubernetes_ctx.WriteStepsJSON,
+	"cucumber": kubernetes_ctx.WriteStepsCucumber,
+}
+
+func main() {
+	format := flag.String("format", "markdown", "output format (markdown, json or cucumber)")
+	output := flag.String("output", "", "output file (default to the standard output)")
+	flag.Parse()
+
+	if err := run(*format, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "godog-kubernetes-steps: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(format, output string) error {
+	write, exists := writers[format]
+	if !exists {
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+
+	ctx, err := kubernetes_ctx.NewFeatureContext(catalogScenario{}, kubernetes_ctx.WithFakeRuntimeClient())
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return write(os.Stdout, ctx.Steps())
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file, ctx.Steps())
+}
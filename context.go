@@ -5,14 +5,20 @@ import (
 	"fmt"
 
 	"github.com/cucumber/godog"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 const (
 	RxDNSChar          = `[a-z0-9\-.]`
-	RxGroupVersionKind = `[\w/]+`
+	RxGroupVersionKind = `[\w/.\-]+`
 	RxNamespacedName   = RxDNSChar + `+(?:/` + RxDNSChar + `+)?`
 	RxFieldPath        = `[^=:]+?`
 )
@@ -24,8 +30,64 @@ type (
 		ctx    context.Context
 		scheme Scheme
 		client client.Client
+		mapper meta.RESTMapper
+		// server is the client without the client-side interceptors (like
+		// the RBAC enforcement), used by the emulated controllers
+		server client.Client
+		clock  clock.Clock
 
-		gc func(*FeatureContext, *unstructured.Unstructured) error
+		gc        func(*FeatureContext, *unstructured.Unstructured) error
+		admission []admissionPlugin
+		emulators []emulator
+		kubelet   *kubelet
+
+		faultInjection bool
+		faults         []*Fault
+		calls          *callRecorder
+
+		// fakeScheme is the scheme of the fake client, if used; its store can
+		// be replaced in order to restore a checkpoint
+		fakeScheme *runtime.Scheme
+		// defaulter runs the defaulting functions of the scheme given
+		// through the options, which are not kept by copyScheme
+		defaulter   runtime.ObjectDefaulter
+		fakeStore   *fakeStore
+		checkpoints map[string][]runtime.Object
+
+		// watches dispatches the changes to the emulated watches, when the
+		// feature context uses the fake client
+		watches       *watchHub
+		watchConfig   *rest.Config
+		openedWatches []*watchRecorder
+
+		// objectRecorder records all objects read through the client
+		objectRecorder *objectRecorder
+		initialObjects []runtime.Object
+		serverMetadata bool
+		finalizers     bool
+		defaulting     bool
+		// leaks detects the objects left behind by the scenario
+		leaks *leakDetector
+
+		emulating      bool
+		emulationQueue []emulationEvent
+		crds           []*apiextensions.CustomResourceDefinition
+
+		kindAliases      map[string]string
+		capturedVersions map[string]*unstructured.Unstructured
+		// steps is the catalog of all registered steps
+		steps      []StepDefinition
+		stepGroups []StepGroup
+		stepPrefix string
+
+		user                *userInfo
+		rbacEnforced        bool
+		taggedUser          string
+		impersonation       *rest.Config
+		impersonatedClients map[string]client.Client
+
+		// err is the first error raised by an option
+		err error
 	}
 
 	// FeatureContextOption is some configuration that modifies options for
@@ -45,54 +107,11 @@ type (
 // with all step injected. If you want to choose which steps must be enabled,
 // use NewEmptyFeatureContext instead.
 func NewFeatureContext(s ScenarioContext, opts ...FeatureContextOption) (*FeatureContext, error) {
-	ctx, err := NewEmptyFeatureContext(s, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	CreateSingleResource(ctx, s)
-	CreateSingleResourceWith(ctx, s)
-	CreateSingleResourceFrom(ctx, s)
-	CreateMultiResources(ctx, s)
-
-	ResourceExists(ctx, s)
-	ResourceNotExists(ctx, s)
-	ResourceIsSimilarTo(ctx, s)
-	ResourceIsNotSimilarTo(ctx, s)
-	ResourceIsEqualTo(ctx, s)
-	ResourceIsNotEqualTo(ctx, s)
-	ResourceHasField(ctx, s)
-	ResourceDoesntHaveField(ctx, s)
-	ResourceHasFieldEqual(ctx, s)
-	ResourceHasFieldNotEqual(ctx, s)
-	ResourceHasLabel(ctx, s)
-	ResourceDoesntHaveLabel(ctx, s)
-	ResourceHasLabelEqual(ctx, s)
-	ResourceHasLabelNotEqual(ctx, s)
-	ResourceHasAnnotation(ctx, s)
-	ResourceDoesntHaveAnnotation(ctx, s)
-	ResourceHasAnnotationEqual(ctx, s)
-	ResourceHasAnnotationNotEqual(ctx, s)
-
-	CountResources(ctx, s)
-	CountNamespacedResources(ctx, s)
-
-	PatchResourceWith(ctx, s)
-	LabelizeResource(ctx, s)
-	RemoveResourceLabel(ctx, s)
-	UpdateResourceLabel(ctx, s)
-	AnnotateResource(ctx, s)
-	RemoveResourceAnnotation(ctx, s)
-	UpdateResourceAnnotation(ctx, s)
-
-	RemoveResource(ctx, s)
-	RemoveMultiResource(ctx, s)
-
-	return ctx, nil
+	return NewEmptyFeatureContext(s, append([]FeatureContextOption{WithSteps(AllStepGroups()...)}, opts...)...)
 }
 
 // NewEmptyFeatureContext returns a new instance of the Kubernetes feature context,
-// without any step injected.
+// without any step injected, except the groups selected with WithSteps.
 func NewEmptyFeatureContext(s ScenarioContext, opts ...FeatureContextOption) (*FeatureContext, error) {
 	// preflight checks
 	dummy := &FeatureContext{}
@@ -100,15 +119,60 @@ func NewEmptyFeatureContext(s ScenarioContext, opts ...FeatureContextOption) (*F
 		opt.ApplyToFeatureContext(dummy)
 	}
 	switch {
+	case dummy.err != nil:
+		return nil, dummy.err
 	case dummy.client == nil:
 		return nil, fmt.Errorf("kubernetes client must be instanciated")
 	}
+	if err := dummy.setup(); err != nil {
+		return nil, err
+	}
 
-	ctx := &FeatureContext{ctx: context.TODO()}
-	s.BeforeScenario(func(*godog.Scenario) {
+	ctx := &FeatureContext{ctx: context.TODO(), stepGroups: dummy.stepGroups, stepPrefix: dummy.stepPrefix}
+	if err := ctx.registerStepGroups(s); err != nil {
+		return nil, err
+	}
+
+	s.BeforeScenario(func(sc *godog.Scenario) {
+		// NOTE: steps are registered once, not for each scenario
+		*ctx = FeatureContext{ctx: context.TODO(), steps: ctx.steps}
 		for _, opt := range opts {
 			opt.ApplyToFeatureContext(ctx)
 		}
+		if err := ctx.setup(); err != nil {
+			// NOTE: godog hooks cannot fail the scenario, so the error is
+			//       returned by all client calls instead
+			ctx.client = &unavailableClient{err: fmt.Errorf("unable to set up the feature context: %w", err)}
+			ctx.server = ctx.client
+		}
+		ctx.taggedUser = taggedUser(sc)
+
+		if ctx.leaks != nil {
+			ctx.takeInventory()
+		}
+	})
+	s.AfterScenario(func(*godog.Scenario, error) { ctx.stopWatches() })
+	s.AfterScenario(func(*godog.Scenario, error) {
+		if ctx.objectRecorder == nil {
+			return
+		}
+		if err := ctx.objectRecorder.flush(); err != nil {
+			// NOTE: godog hooks cannot fail the scenario
+			panic(err)
+		}
+	})
+	s.AfterScenario(func(sc *godog.Scenario, _ error) {
+		if ctx.leaks != nil {
+			ctx.detectLeaks(sc)
+		}
+	})
+	s.BeforeStep(func(*godog.Step) {
+		// NOTE: the tagged user is used only by the steps, allowing
+		//       BeforeScenario hooks to prepare the cluster
+		if ctx.taggedUser != "" {
+			ctx.ActAs(ctx.taggedUser)
+			ctx.taggedUser = ""
+		}
 	})
 
 	return ctx, nil
@@ -120,18 +184,176 @@ func (ctx FeatureContext) Client() client.Client { return ctx.client }
 // Scheme returns the Kubernetes scheme used by the FeatureContext.
 func (ctx FeatureContext) Scheme() Scheme { return ctx.scheme }
 
+// RESTMapper returns the RESTMapper used by the FeatureContext to resolve
+// kinds and resources.
+func (ctx FeatureContext) RESTMapper() meta.RESTMapper { return ctx.mapper }
+
 // GoContext returns the golang context used by the FeatureContext.
 func (ctx FeatureContext) GoContext() context.Context { return ctx.ctx }
 
+// Clock returns the clock used by the FeatureContext for all timestamps it
+// sets. Reconcilers tested with the FeatureContext should use it, in order
+// to follow the time steps.
+func (ctx FeatureContext) Clock() clock.Clock { return ctx.clock }
+
 // GarbageCollector returns the garbage collector implementation used
 // by the FeatureContext.
 func (ctx FeatureContext) GarbageCollector() func(*FeatureContext, *unstructured.Unstructured) error {
 	return ctx.gc
 }
 
+// setup finalizes the feature context once all options have been applied.
+func (ctx *FeatureContext) setup() error {
+	if rscheme, isRuntimeScheme := ctx.scheme.(*runtime.Scheme); isRuntimeScheme {
+		ctx.defaulter = rscheme
+	}
+	if len(ctx.crds) > 0 {
+		if ctx.fakeScheme != nil && ctx.scheme == Scheme(ctx.fakeScheme) {
+			// NOTE: the scheme of the fake client can be shared (like the
+			//       client-go one), so custom resources are registered
+			//       inside a copy used by a new fake client
+			ctx.fakeScheme = copyScheme(ctx.fakeScheme)
+			ctx.scheme = ctx.fakeScheme
+			ctx.client = fake.NewFakeClientWithScheme(ctx.fakeScheme)
+		}
+		if err := registerCustomResources(ctx.scheme, ctx.crds); err != nil {
+			return err
+		}
+	}
+	if ctx.mapper == nil {
+		ctx.mapper = newSchemeRESTMapper(ctx.scheme, ctx.crds)
+	}
+	if ctx.clock == nil {
+		ctx.clock = clock.RealClock{}
+	}
+
+	if ctx.impersonation != nil {
+		ctx.client = &impersonatingClient{Client: ctx.client, ctx: ctx}
+	}
+	if ctx.fakeScheme != nil {
+		ctx.fakeStore = &fakeStore{Client: ctx.client}
+		ctx.client = ctx.fakeStore
+	}
+	if len(ctx.initialObjects) > 0 && ctx.fakeScheme == nil {
+		// NOTE: seeding a real cluster would fail on the next scenarios
+		//       because the objects already exist
+		return fmt.Errorf("initial objects can only be seeded on the fake client")
+	}
+	if err := ctx.seedInitialObjects(); err != nil {
+		return err
+	}
+	if ctx.finalizers {
+		if ctx.fakeScheme == nil {
+			return fmt.Errorf("finalizers can only be emulated on the fake client")
+		}
+		ctx.client = &finalizerClient{Client: ctx.client, ctx: ctx}
+	}
+	if ctx.serverMetadata {
+		if ctx.fakeScheme == nil {
+			return fmt.Errorf("server-side metadata can only be emulated on the fake client")
+		}
+		ctx.client = &metadataClient{Client: ctx.client, ctx: ctx}
+	}
+	if ctx.defaulting {
+		ctx.client = &defaultingClient{Client: ctx.client, ctx: ctx}
+	}
+	if ctx.objectRecorder != nil {
+		ctx.objectRecorder.reset()
+		ctx.client = &objectRecordingClient{Client: ctx.client, ctx: ctx}
+	}
+	if ctx.fakeScheme != nil {
+		ctx.watches = &watchHub{}
+		ctx.client = &watchClient{Client: ctx.client, ctx: ctx}
+	}
+	ctx.client = ctx.interceptClient(ctx.client)
+	ctx.server = ctx.client
+	ctx.wrapServerClient()
+	return nil
+}
+
+// wrapServerClient wraps the server client with the client-side behaviours:
+// the RBAC enforcement (once a user is set through ActAs), the injected
+// faults and the calls recording.
+func (ctx *FeatureContext) wrapServerClient() {
+	ctx.client = ctx.server
+	if ctx.rbacEnforced {
+		// NOTE: requests denied by the RBAC rules are also recorded and
+		//       can be faulted, like on a real API server
+		ctx.client = &rbacClient{Client: ctx.client, ctx: ctx}
+	}
+	if ctx.faultInjection {
+		// NOTE: faults are not injected on the emulated components
+		ctx.client = &faultClient{Client: ctx.client, ctx: ctx}
+	}
+	if ctx.calls != nil {
+		// NOTE: calls rejected by an injected fault are recorded too
+		ctx.client = &recordingClient{Client: ctx.client, ctx: ctx}
+	}
+}
+
+// interceptClient wraps the given client with all behaviours enabled
+// through the options.
+func (ctx *FeatureContext) interceptClient(c client.Client) client.Client {
+	if len(ctx.admission) > 0 {
+		c = &admissionClient{Client: c, ctx: ctx}
+	}
+	if len(ctx.emulators) > 0 {
+		c = &emulationClient{Client: c, ctx: ctx}
+	}
+	return c
+}
+
+// serverClient returns the client without the client-side interceptors,
+// acting like the API server itself.
+func (ctx *FeatureContext) serverClient() client.Client { return ctx.server }
+
+// now returns the current time of the feature context clock.
+func (ctx *FeatureContext) now() metav1.Time { return metav1.NewTime(ctx.clock.Now()) }
+
 func (ctx *FeatureContext) callGC(obj *unstructured.Unstructured) error {
 	if ctx.gc == nil {
 		return nil
 	}
+
+	// NOTE: the garbage collector is not restricted by the current user
+	user := ctx.user
+	ctx.user = nil
+	defer func() { ctx.user = user }()
+
 	return ctx.gc(ctx, obj)
 }
+
+// unavailableClient is used instead of the feature context client when it
+// cannot be set up at the beginning of a scenario, in order to fail all
+// steps using it.
+type unavailableClient struct{ err error }
+
+func (c *unavailableClient) Get(context.Context, client.ObjectKey, runtime.Object) error {
+	return c.err
+}
+func (c *unavailableClient) List(context.Context, runtime.Object, ...client.ListOption) error {
+	return c.err
+}
+func (c *unavailableClient) Create(context.Context, runtime.Object, ...client.CreateOption) error {
+	return c.err
+}
+func (c *unavailableClient) Delete(context.Context, runtime.Object, ...client.DeleteOption) error {
+	return c.err
+}
+func (c *unavailableClient) Update(context.Context, runtime.Object, ...client.UpdateOption) error {
+	return c.err
+}
+func (c *unavailableClient) Patch(context.Context, runtime.Object, client.Patch, ...client.PatchOption) error {
+	return c.err
+}
+func (c *unavailableClient) DeleteAllOf(context.Context, runtime.Object, ...client.DeleteAllOfOption) error {
+	return c.err
+}
+func (c *unavailableClient) Status() client.StatusWriter { return c }
+
+// setError keeps the first error raised by an option.
+func (ctx *FeatureContext) setError(err error) {
+	if ctx.err == nil {
+		ctx.err = err
+	}
+}
@@ -2,12 +2,22 @@ package kubernetes_ctx
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
 )
 
 // FeatureContextOptionFnc wraps a function to implement
@@ -26,16 +36,20 @@ func WithClient(scheme Scheme, client client.Client) FeatureContextOptionFnc {
 	return func(ctx *FeatureContext) {
 		ctx.scheme = scheme
 		ctx.client = client
+		ctx.fakeScheme = nil
 	}
 }
 
 // WithFakeClient instantiate a new Kubernetes client
 // with the given scheme. It automatically inject the
 // NaiveGC as garbage collector if any is provided.
+// Watches are emulated from the changes written through
+// the feature context.
 func WithFakeClient(scheme *runtime.Scheme) FeatureContextOptionFnc {
 	return func(ctx *FeatureContext) {
 		ctx.scheme = scheme
 		ctx.client = fake.NewFakeClientWithScheme(scheme)
+		ctx.fakeScheme = scheme
 		if ctx.gc == nil {
 			ctx.gc = NaiveGC
 		}
@@ -49,12 +63,146 @@ func WithFakeRuntimeClient() FeatureContextOptionFnc {
 	return WithFakeClient(scheme.Scheme)
 }
 
+// WithFakeClientFromRecording instantiate a new Kubernetes client with the
+// default scheme, like WithFakeRuntimeClient, seeded at the beginning of
+// each scenario with the objects recorded by WithClientRecording. The
+// recorded kinds must be known by the feature context.
+func WithFakeClientFromRecording(path string) FeatureContextOptionFnc {
+	var (
+		once sync.Once
+		objs []*unstructured.Unstructured
+		err  error
+	)
+
+	return func(ctx *FeatureContext) {
+		once.Do(func() { objs, err = helpers.ReadManifests(path) })
+		if err != nil {
+			ctx.setError(err)
+			return
+		}
+		WithFakeRuntimeClient()(ctx)
+		for _, obj := range objs {
+			ctx.initialObjects = append(ctx.initialObjects, obj)
+		}
+	}
+}
+
+// WithInitialObjects creates the given objects inside the feature context
+// client at the beginning of each scenario, without calling any
+// interceptor (like the admission or the emulated controllers). Like with
+// FeatureContext.Create, objects without UID or creationTimestamp get
+// generated ones.
+// It can only be used with a fake client (like WithFakeClient), because the
+// objects of a real cluster would already exist in the next scenarios.
+func WithInitialObjects(objs ...runtime.Object) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		ctx.initialObjects = append(ctx.initialObjects, objs...)
+	}
+}
+
+// WithFixtureDir creates all objects defined in the YAML or JSON files
+// available in the given directory (walked recursively), including the
+// items of `kind: List` documents, at the beginning of each scenario
+// (see WithInitialObjects). Like WithInitialObjects, it can only be used
+// with a fake client.
+func WithFixtureDir(path string) FeatureContextOptionFnc {
+	var (
+		once sync.Once
+		objs []*unstructured.Unstructured
+		err  error
+	)
+
+	return func(ctx *FeatureContext) {
+		once.Do(func() { objs, err = helpers.ReadManifests(path) })
+		if err != nil {
+			ctx.setError(err)
+			return
+		}
+		for _, obj := range objs {
+			ctx.initialObjects = append(ctx.initialObjects, obj)
+		}
+	}
+}
+
+// WithClientRecording records, during all scenarios, the first version of
+// each object read through the feature context client (like the one given
+// to WithClient) inside the given file. Objects created by the scenarios
+// are not recorded. The file is written at the end of each scenario, and
+// can then be used by WithFakeClientFromRecording in order to run the same
+// features offline.
+func WithClientRecording(path string) FeatureContextOptionFnc {
+	recorder := newObjectRecorder(path)
+	return func(ctx *FeatureContext) { ctx.objectRecorder = recorder }
+}
+
 // WithContext inject a context to the Kubernetes feature context, used
 // during the client's call.
 func WithContext(goctx context.Context) FeatureContextOptionFnc {
 	return func(ctx *FeatureContext) { ctx.ctx = goctx }
 }
 
+// WithRESTMapper inject the given RESTMapper inside the Kubernetes feature
+// context, used to resolve kinds and resources. By default, a RESTMapper is
+// built from the scheme and the loaded CustomResourceDefinitions.
+func WithRESTMapper(mapper meta.RESTMapper) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.mapper = mapper }
+}
+
+// WithDiscoveryRESTMapper inject a RESTMapper based on the discovery API of
+// the Kubernetes cluster reached with the given configuration. Kinds
+// served by the cluster but not registered inside the scheme are managed
+// through Unstructured objects.
+func WithDiscoveryRESTMapper(cfg *rest.Config) FeatureContextOptionFnc {
+	var (
+		once   sync.Once
+		mapper meta.RESTMapper
+		err    error
+	)
+
+	return func(ctx *FeatureContext) {
+		once.Do(func() { mapper, err = apiutil.NewDynamicRESTMapper(cfg) })
+		if err != nil {
+			ctx.setError(err)
+			return
+		}
+		ctx.mapper = mapper
+	}
+}
+
+// WithImpersonation uses the impersonation of the given configuration (which
+// must target the same cluster than the feature context client) when the
+// feature context acts as a specific user, instead of evaluating the RBAC
+// rules locally. Permission assertions use SubjectAccessReviews.
+// The user is impersonated by the client at the bottom of the feature context
+// client, so the requests still go through all other behaviours (like the
+// call recording or the injected faults).
+func WithImpersonation(cfg *rest.Config) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.impersonation = cfg }
+}
+
+// WithWatchConfig sends the watches opened by the feature context (see
+// FeatureContext.Watch) to the Kubernetes cluster reached with the given
+// configuration, which must target the same cluster than the feature
+// context client. It is not required with the fake client, which emulates
+// the watches.
+func WithWatchConfig(cfg *rest.Config) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.watchConfig = cfg }
+}
+
+// WithKindAliases adds aliases usable instead of the GroupVersionKind
+// inside the steps, like `deploy` for `deployments.apps`. Aliases must
+// target a resource or a kind name, optionally followed by its group.
+func WithKindAliases(aliases map[string]string) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		if ctx.kindAliases == nil {
+			ctx.kindAliases = map[string]string{}
+		}
+		for alias, resource := range aliases {
+			ctx.kindAliases[strings.ToLower(alias)] = resource
+		}
+	}
+}
+
 // WithCustomGarbageCollector inject the given GarbageCollector
 // to the feature context. This garbage collector is used to
 // Delete children objects when a parent is removed. It is
@@ -62,3 +210,219 @@ func WithContext(goctx context.Context) FeatureContextOptionFnc {
 func WithCustomGarbageCollector(gc func(*FeatureContext, *unstructured.Unstructured) error) FeatureContextOptionFnc {
 	return func(ctx *FeatureContext) { ctx.gc = gc }
 }
+
+// WithKubeletSimulator enables a simulated kubelet, moving all created pods
+// through the Pending, Running and Ready phases like a real kubelet does:
+// pods get an IP and their container statuses are filled. Running and Ready
+// are written by separate status updates, so the Running but not ready
+// state can be observed through a watch. Containers can fail, depending on
+// their image (see KubeletOptions).
+func WithKubeletSimulator(opts KubeletOptions) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		ctx.kubelet = &kubelet{opts: opts}
+		ctx.emulators = append(ctx.emulators, ctx.kubelet)
+	}
+}
+
+// WithWorkloadControllers enables lightweight emulations of the Deployment,
+// ReplicaSet, StatefulSet and Job controllers, run after each write done
+// through the feature context: Deployments create ReplicaSets (labelled with
+// their pod-template-hash), which create Pods, and Jobs complete once their
+// pods succeed. All created objects are owned by their controller, allowing
+// the garbage collector to remove them.
+// Combined with WithKubeletSimulator, the workloads status (like
+// readyReplicas or availableReplicas) follows the pods status.
+func WithWorkloadControllers() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		ctx.emulators = append(ctx.emulators, workloadControllers()...)
+	}
+}
+
+// WithEndpointsController enables a lightweight emulation of the Endpoints
+// and EndpointSlice controllers, run after each write done through the
+// feature context: each Service with a selector gets an Endpoints and
+// EndpointSlices listing its selected pods having an IP (ready or not).
+// Combined with WithKubeletSimulator, pods are listed as soon as they are
+// created.
+// EndpointSlices are written as discovery.k8s.io/v1beta1, because
+// discovery.k8s.io/v1 doesn't exist in the supported Kubernetes API (1.18).
+func WithEndpointsController() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		ctx.emulators = append(ctx.emulators, &endpointsController{})
+	}
+}
+
+// WithClock configures the clock used by the feature context for all
+// timestamps it sets (like the creationTimestamp, the deletionTimestamp (see
+// WithFinalizers) or the conditions transition times). With a fake clock
+// (see clock.NewFakeClock), the time is driven by the steps
+// `Time advances by <Duration>` and `Time is '<Time>'`.
+// The clock is not given to the code under test: reconcilers and garbage
+// collectors must use FeatureContext.Clock to follow the time steps. The
+// watch steps always wait for the expected events in real time.
+func WithClock(c clock.Clock) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		ctx.clock = c
+	}
+}
+
+// WithCronJobController enables a lightweight emulation of the CronJob
+// controller: Jobs are created each time the feature context clock passes a
+// CronJob schedule, following its suspend, startingDeadlineSeconds,
+// concurrencyPolicy and history limits. Combined with WithClock and a fake
+// clock, the schedules are driven by the time steps.
+// Only batch/v1beta1 CronJobs are handled, because batch/v1 CronJobs don't
+// exist in the supported Kubernetes API (1.18).
+func WithCronJobController() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		ctx.emulators = append(ctx.emulators, &cronJobController{})
+	}
+}
+
+// WithFaultInjection enables the injection of faults (errors or latencies)
+// on the requests sent through the feature context client, driven by
+// FeatureContext.InjectFault or the `Kubernetes fails the next ...` steps.
+// Requests sent by the emulated components and the reads issued by the
+// feature context itself (like the ones of the assertion steps) are never
+// affected.
+func WithFaultInjection() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.faultInjection = true }
+}
+
+// WithCallRecording enables the recording of all requests sent through the
+// feature context client (verb, kind, name, patch body and timestamp),
+// available through FeatureContext.Calls or the `Kubernetes client issued
+// ...` steps. Requests sent by the emulated components and the reads issued
+// by the feature context itself (like the ones of the assertion steps or the
+// one done by FeatureContext.Patch before patching) are never recorded,
+// unlike the reads done through FeatureContext.Get, List and ListAll.
+func WithCallRecording() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.calls = &callRecorder{} }
+}
+
+// WithServerMetadata emulates, on the fake client, the metadata fields
+// managed by the API server for all writes done through the feature context
+// (including the ones of the emulated controllers): the name is generated
+// from generateName, the uid and the creationTimestamp are set on creation
+// and can't be changed, and the generation starts at 1 and is incremented
+// each time anything other than the metadata or the status changes.
+func WithServerMetadata() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.serverMetadata = true }
+}
+
+// WithFinalizers emulates, on the fake client, the deletion of the objects
+// with finalizers like the API server: they are only marked with a
+// deletionTimestamp, taken from the feature context clock, and removed (then
+// garbage collected) once their last finalizer is removed by an update or a
+// patch.
+func WithFinalizers() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.finalizers = true }
+}
+
+// WithDefaulting fills the fields defaulted by the API server on all
+// objects written through the feature context client (created, updated or
+// patched, including by the emulated components), like the strategy of a
+// Deployment or the imagePullPolicy of its containers. The defaulting
+// functions registered inside the scheme (like the ones of the custom
+// resources) are run first, followed by the ones of the most used built-in
+// kinds, which are not registered by the client-go scheme. These built-in
+// defaults are a partial copy of the Kubernetes 1.18 ones.
+func WithDefaulting() FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) { ctx.defaulting = true }
+}
+
+// WithLeakDetection takes an inventory of the inspected objects (see
+// LeakDetectionOptions) at the beginning of each scenario and compares it
+// with the objects existing once the scenario is done. Objects left behind
+// are reported with the name of the scenario which leaked them.
+// godog runs the AfterScenario hooks in their registration order, so the
+// cleanup hooks must be registered before the feature context is created in
+// order to run before the detection.
+func WithLeakDetection(opts LeakDetectionOptions) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		if len(opts.Namespaces) == 0 && len(opts.Kinds) == 0 {
+			ctx.setError(fmt.Errorf("leak detection requires at least one namespace or kind to inspect"))
+			return
+		}
+		ctx.leaks = &leakDetector{opts: opts}
+	}
+}
+
+// WithCustomResourceDefinitions loads the CustomResourceDefinitions available
+// in the given files or directories and registers all their kinds inside the
+// feature context scheme, which must be a *runtime.Scheme. Kinds without
+// registered Go types are managed through Unstructured objects, allowing
+// to use third-party custom resources without their Go types.
+// With a fake client (like WithFakeRuntimeClient), kinds are registered inside
+// a copy of its scheme, which is never modified. Otherwise, they are registered
+// inside the given scheme, which can't be the global client-go one.
+func WithCustomResourceDefinitions(paths ...string) FeatureContextOptionFnc {
+	var (
+		once sync.Once
+		crds []*apiextensions.CustomResourceDefinition
+		err  error
+	)
+
+	return func(ctx *FeatureContext) {
+		once.Do(func() { crds, err = helpers.LoadCustomResourceDefinitions(paths...) })
+		if err != nil {
+			ctx.setError(err)
+			return
+		}
+		ctx.crds = append(ctx.crds, crds...)
+	}
+}
+
+// WithCustomResourceValidation loads the CustomResourceDefinitions available
+// in the given files or directories and validates all custom resources written
+// through the feature context against their OpenAPI v3 schema and their
+// x-kubernetes-validations rules. Like the API server does, schema defaults
+// are applied and unknown fields are pruned before the validation.
+// Transition rules (using oldSelf) are refused under array items, because
+// the items are not correlated with the ones of the old object.
+func WithCustomResourceValidation(paths ...string) FeatureContextOptionFnc {
+	var (
+		once    sync.Once
+		schemas customResourceSchemas
+		err     error
+	)
+
+	return func(ctx *FeatureContext) {
+		once.Do(func() { schemas, err = newCustomResourceSchemas(paths...) })
+		if err != nil {
+			ctx.setError(err)
+			return
+		}
+		ctx.admission = append(ctx.admission, schemas)
+	}
+}
+
+// WithSteps registers all steps of the given groups, like StepsCreate or
+// StepsAssert, when the feature context is created. Unlike
+// NewFeatureContext, only the selected capabilities are available.
+func WithSteps(groups ...StepGroup) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		for _, group := range groups {
+			if _, exists := stepGroupIndex(group); !exists {
+				ctx.setError(fmt.Errorf("unknown step group '%s'", group))
+				return
+			}
+			if !ctx.hasStepGroup(group) {
+				ctx.stepGroups = append(ctx.stepGroups, group)
+			}
+		}
+	}
+}
+
+// WithStepPrefix replaces the `Kubernetes` word starting the provided steps
+// by the given prefix, like `the cluster`. Steps that do not start with
+// `Kubernetes`, like the time steps, are not affected.
+func WithStepPrefix(prefix string) FeatureContextOptionFnc {
+	return func(ctx *FeatureContext) {
+		if strings.TrimSpace(prefix) == "" {
+			ctx.setError(fmt.Errorf("step prefix must not be empty"))
+			return
+		}
+		ctx.stepPrefix = strings.TrimSpace(prefix)
+	}
+}
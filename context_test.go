@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/cucumber/godog/colors"
@@ -14,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
@@ -34,7 +36,7 @@ func TestNewFeatureContext(t *testing.T) {
 	assert.NotNil(t, ctx)
 
 	assert.Len(t, scenarioCtx.beforeScenarioList, 1)
-	assert.Len(t, scenarioCtx.stepList, 33) // NOTE: Do not forget to update this value
+	assert.Len(t, scenarioCtx.stepList, 62) // NOTE: Do not forget to update this value
 }
 
 func TestNewEmptyFeatureContext(t *testing.T) {
@@ -152,7 +154,19 @@ func TestMain(m *testing.M) {
 	// - 3 Namespaces (default, kube-public & kube-system)
 	// - 2 Services (default/default & default/Kubernetes)
 	scenarioInitializer := func(scenarioContext *godog.ScenarioContext) {
-		ctx, _ := kubernetes_ctx.NewFeatureContext(scenarioContext, kubernetes_ctx.WithFakeRuntimeClient())
+		ctx, _ := kubernetes_ctx.NewFeatureContext(
+			scenarioContext,
+			kubernetes_ctx.WithFakeClient(featureScheme()),
+			kubernetes_ctx.WithCustomResourceDefinitions("features/resources/crds"),
+			kubernetes_ctx.WithCustomResourceValidation("features/resources/crds"),
+			kubernetes_ctx.WithWorkloadControllers(),
+			kubernetes_ctx.WithEndpointsController(),
+			kubernetes_ctx.WithCronJobController(),
+			kubernetes_ctx.WithClock(clock.NewFakeClock(time.Now())),
+			kubernetes_ctx.WithFinalizers(),
+			kubernetes_ctx.WithFaultInjection(),
+			kubernetes_ctx.WithCallRecording(),
+		)
 		scenarioContext.BeforeScenario(func(sc *godog.Scenario) {
 			// create default namespace
 			_ = ctx.Create(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, types.NamespacedName{Name: "default"}, &unstructured.Unstructured{})
@@ -0,0 +1,65 @@
+package kubernetes_ctx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// UnknownKindError is returned when a kind is not known by the feature
+// context RESTMapper (built from the scheme and the loaded
+// CustomResourceDefinitions, or from the API server discovery).
+type UnknownKindError struct {
+	// GroupVersionKind is the unknown kind, if it was completely defined.
+	GroupVersionKind schema.GroupVersionKind
+	// Name is the unknown kind, resource or alias name, if it was given
+	// without its version.
+	Name string
+	Err  error
+}
+
+func (err *UnknownKindError) Error() string {
+	name := err.Name
+	if name == "" {
+		apiVersion, kind := err.GroupVersionKind.ToAPIVersionAndKind()
+		name = apiVersion + "/" + kind
+	}
+	return fmt.Sprintf(
+		"unknown kind %s: it must be registered in the scheme, defined by a CustomResourceDefinition or served by the API server",
+		name,
+	)
+}
+
+func (err *UnknownKindError) Unwrap() error { return err.Err }
+
+// IsUnknownKind returns true if the given error is, or wraps, an
+// UnknownKindError.
+func IsUnknownKind(err error) bool {
+	var unknownKindErr *UnknownKindError
+	return errors.As(err, &unknownKindErr)
+}
+
+// AmbiguousKindError is returned when a kind, resource or alias name given
+// without its group matches kinds of several groups.
+type AmbiguousKindError struct {
+	Name       string
+	Candidates []schema.GroupVersionKind
+}
+
+func (err *AmbiguousKindError) Error() string {
+	candidates := make([]string, 0, len(err.Candidates))
+	for _, candidate := range err.Candidates {
+		apiVersion, kind := candidate.ToAPIVersionAndKind()
+		candidates = append(candidates, apiVersion+"/"+kind)
+	}
+	return fmt.Sprintf("ambiguous kind %s: it matches %s", err.Name, strings.Join(candidates, ", "))
+}
+
+// IsAmbiguousKind returns true if the given error is, or wraps, an
+// AmbiguousKindError.
+func IsAmbiguousKind(err error) bool {
+	var ambiguousKindErr *AmbiguousKindError
+	return errors.As(err, &ambiguousKindErr)
+}
@@ -0,0 +1,39 @@
+Feature: Record the Kubernetes client calls
+  In order to validate the idempotency of controllers
+  As feature context
+  I need to be able to assert on the requests sent through the Kubernetes client
+
+  Scenario: should record the client calls
+    Given Kubernetes client forgets the issued calls
+    When Kubernetes creates a new v1/Service 'default/svc'
+    And Kubernetes labelizes v1/Service 'default/svc' with 'key=value'
+    Then Kubernetes client issued exactly these calls
+      | Verb   | ApiGroupVersion | Kind    | Namespace | Name |
+      | create | v1              | Service | default   | svc  |
+      | patch  | v1              | Service | default   | svc  |
+    And Kubernetes client issued 1 create on v1/Service 'default/svc'
+    And Kubernetes client issued 0 updates on v1/Service 'default/svc'
+    And Kubernetes client issued 0 update on v1/Service
+
+  Scenario: should count the client calls on a kind
+    Given Kubernetes client forgets the issued calls
+    When Kubernetes creates a new v1/Service 'default/first'
+    And Kubernetes creates a new v1/Service 'kube-system/second'
+    And Kubernetes has 3 v1/Service in namespace 'default'
+    Then Kubernetes client issued 2 creates on v1/Service
+    And Kubernetes client issued exactly these calls
+      | create | v1 | Service | default     | first  |
+      | create | v1 | Service | kube-system | second |
+
+  Scenario: should record the calls rejected by an injected fault
+    Given Kubernetes fails the next 1 create of v1/Service 'default/svc' with 'Conflict'
+    And Kubernetes client forgets the issued calls
+    When Kubernetes fails to create v1/Service 'default/svc' due to Conflict
+    Then Kubernetes client issued 1 create on v1/Service 'default/svc'
+
+  Scenario: should not record the reads of the assertion steps
+    Given Kubernetes client forgets the issued calls
+    When Kubernetes has v1/Service 'default/kubernetes'
+    And Kubernetes has 2 v1/Service in namespace 'default'
+    Then Kubernetes client issued exactly these calls
+      | Verb | ApiGroupVersion | Kind | Namespace | Name |
@@ -0,0 +1,35 @@
+Feature: Save and restore checkpoints of Kubernetes resources
+  In order to try several alternatives from the same state
+  As feature context
+  I need to be able to save and restore the state of Kubernetes
+
+  Scenario: should restore a saved checkpoint
+    Given Kubernetes saves a checkpoint 'initial'
+    When Kubernetes creates a new v1/Service 'default/svc'
+    And Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=updated'
+    And Kubernetes removes v1/Service 'default/default'
+    Then Kubernetes has changed since checkpoint 'initial'
+    When Kubernetes restores checkpoint 'initial'
+    Then Kubernetes hasn't changed since checkpoint 'initial'
+    And Kubernetes doesn't have v1/Service 'default/svc'
+    And Kubernetes has v1/Service 'default/default'
+    And Kubernetes resource v1/Service 'default/kubernetes' has label 'key=value'
+
+  Scenario: should try several alternatives from the same checkpoint
+    Given Kubernetes creates a new v1/Namespace 'alternatives'
+    And Kubernetes saves a checkpoint 'background'
+    When Kubernetes creates a new v1/Service 'alternatives/first'
+    And Kubernetes saves a checkpoint 'first'
+    And Kubernetes restores checkpoint 'background'
+    And Kubernetes creates a new v1/Service 'alternatives/second'
+    Then Kubernetes has v1/Service 'alternatives/second'
+    And Kubernetes doesn't have v1/Service 'alternatives/first'
+    When Kubernetes restores checkpoint 'first'
+    Then Kubernetes has v1/Service 'alternatives/first'
+    And Kubernetes doesn't have v1/Service 'alternatives/second'
+
+  Scenario: should keep updating restored resources
+    Given Kubernetes saves a checkpoint 'initial'
+    When Kubernetes restores checkpoint 'initial'
+    And Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=updated'
+    Then Kubernetes resource v1/Service 'default/kubernetes' has label 'key=updated'
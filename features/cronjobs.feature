@@ -0,0 +1,48 @@
+Feature: Emulate the CronJob controller
+  In order to test CronJob schedules
+  As feature context
+  I need to be able to start Jobs when the time passes a schedule
+
+  Background:
+    Given Time is '2024-01-01T00:00:00Z'
+    And Kubernetes must have batch/v1beta1/CronJob 'default/backup' with
+      """
+      spec:
+        schedule: "*/5 * * * *"
+        jobTemplate:
+          spec:
+            template:
+              spec:
+                restartPolicy: Never
+                containers:
+                  - name: backup
+                    image: busybox
+      """
+
+  Scenario: should create a Job at each schedule
+    When Time advances by 4m
+    Then Kubernetes has 0 batch/v1/Job in namespace 'default'
+    When Time advances by 1m
+    Then Kubernetes has 1 batch/v1/Job in namespace 'default'
+    And Kubernetes has batch/v1/Job 'default/backup-28401125'
+    And Kubernetes resource batch/v1beta1/CronJob 'default/backup' has 'status.lastScheduleTime=2024-01-01T00:05:00Z'
+
+  Scenario: should not create concurrent Jobs when forbidden
+    Given Kubernetes patches batch/v1beta1/CronJob 'default/backup' with
+      """
+      spec:
+        concurrencyPolicy: Forbid
+      """
+    When Time advances by 10m
+    And Time advances by 5m
+    Then Kubernetes has 1 batch/v1/Job in namespace 'default'
+    And Kubernetes has batch/v1/Job 'default/backup-28401130'
+
+  Scenario: should not create Jobs when suspended
+    Given Kubernetes patches batch/v1beta1/CronJob 'default/backup' with
+      """
+      spec:
+        suspend: true
+      """
+    When Time advances by 10m
+    Then Kubernetes has 0 batch/v1/Job in namespace 'default'
@@ -0,0 +1,20 @@
+Feature: Manage custom resources
+  In order to test custom resources features
+  As feature context
+  I need to be able to manage custom resources defined only by their CustomResourceDefinition
+
+  Scenario: should manage a custom resource without Go type
+    Given Kubernetes creates a new example.com/v1/Widget 'default/widget' with
+      """
+      spec:
+        image: nginx
+      """
+    Then Kubernetes has example.com/v1/Widget 'default/widget'
+    When Kubernetes patches example.com/v1/Widget 'default/widget' with
+      """
+      spec:
+        replicas: 3
+      """
+    Then Kubernetes resource example.com/v1/Widget 'default/widget' has 'spec.replicas=3'
+    When Kubernetes removes example.com/v1/Widget 'default/widget'
+    Then Kubernetes doesn't have example.com/v1/Widget 'default/widget'
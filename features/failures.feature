@@ -0,0 +1,52 @@
+Feature: Expect failures of Kubernetes operations
+  In order to test rejected operations
+  As feature context
+  I need to be able to validate that an operation fails with a specific reason
+
+  Scenario: should fail to create an existing resource
+    Then Kubernetes fails to create v1/Namespace 'default' due to AlreadyExists
+    And Kubernetes fails to create v1/Service 'default/kubernetes' due to AlreadyExists matching 'services "kubernetes" already exists'
+
+  Scenario: should fail to create an invalid resource
+    When Kubernetes fails to create example.com/v1/Widget 'default/widget' due to Invalid matching 'replicas' with
+      """
+      spec:
+        image: nginx
+        replicas: 11
+      """
+    Then Kubernetes doesn't have example.com/v1/Widget 'default/widget'
+
+  Scenario: should fail to patch a resource
+    Given Kubernetes creates a new example.com/v1/Widget 'default/widget' with
+      """
+      spec:
+        image: nginx
+      """
+    Then Kubernetes fails to patch example.com/v1/Widget 'default/widget' due to Invalid matching 'image is immutable' with
+      """
+      spec:
+        image: httpd
+      """
+    And Kubernetes fails to patch v1/Service 'default/unknown' due to NotFound with
+      """
+      metadata:
+        labels:
+          key: value
+      """
+
+  Scenario: should fail to remove a resource
+    Given Kubernetes must have rbac.authorization.k8s.io/v1/Role 'default/pod-reader' from features/resources/rbac/pod-reader.yaml
+    And Kubernetes must have rbac.authorization.k8s.io/v1/RoleBinding 'default/pod-reader' with
+      """
+      roleRef:
+        apiGroup: rbac.authorization.k8s.io
+        kind: Role
+        name: pod-reader
+      subjects:
+        - kind: User
+          name: alice
+      """
+    And Kubernetes must have v1/Pod 'default/pod'
+    Then Kubernetes fails to remove v1/Service 'default/unknown' due to NotFound
+    When Kubernetes acts as user 'alice'
+    Then Kubernetes fails to remove v1/Pod 'default/pod' due to Forbidden matching 'cannot delete'
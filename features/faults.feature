@@ -0,0 +1,55 @@
+Feature: Inject faults on Kubernetes requests
+  In order to test the error handling of controllers
+  As feature context
+  I need to be able to make the Kubernetes API misbehave on demand
+
+  Scenario: should fail the next requests with the given reason
+    Given Kubernetes fails the next 2 patches of v1/Service 'default/kubernetes' with 'Conflict'
+    Then Kubernetes fails to patch v1/Service 'default/kubernetes' due to Conflict matching 'fault injected' with
+      """
+      metadata:
+        labels:
+          key: first
+      """
+    And Kubernetes labelizes v1/Service 'default/default' with 'key=second'
+    And Kubernetes fails to patch v1/Service 'default/kubernetes' due to Conflict with
+      """
+      metadata:
+        labels:
+          key: third
+      """
+    When Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=fourth'
+    Then Kubernetes resource v1/Service 'default/kubernetes' has label 'key=fourth'
+
+  Scenario: should fail the requests matching the namespace
+    Given Kubernetes fails the next 1 create of v1/Service in namespace 'kube-system' with 'Forbidden'
+    Then Kubernetes fails to create v1/Service 'kube-system/svc' due to Forbidden
+    When Kubernetes creates a new v1/Service 'default/svc'
+    And Kubernetes creates a new v1/Service 'kube-system/svc'
+    Then Kubernetes has 1 v1/Service in namespace 'kube-system'
+
+  Scenario: should delay the next requests
+    Given Time is '2024-01-01T00:00:00Z'
+    And Kubernetes fails the next 1 patch of v1/Service 'default/kubernetes' with latency 5m
+    When Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=delayed'
+    And Kubernetes creates a new v1/ConfigMap 'default/config'
+    Then Kubernetes resource v1/ConfigMap 'default/config' has 'metadata.creationTimestamp=2024-01-01T00:05:00Z'
+
+  Scenario: should not affect the reads of the steps
+    Given Kubernetes fails the next 1 get of v1/Service 'default/kubernetes' with 'NotFound'
+    When Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=read'
+    Then Kubernetes has v1/Service 'default/kubernetes'
+    And Kubernetes resource v1/Service 'default/kubernetes' has label 'key=read'
+
+  Scenario: should not delay the reads of the steps
+    Given Time is '2024-01-01T00:00:00Z'
+    And Kubernetes fails the next 1 get of v1/Service 'default/kubernetes' with latency 5m
+    When Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=read'
+    And Kubernetes creates a new v1/ConfigMap 'default/config'
+    Then Kubernetes has v1/Service 'default/kubernetes'
+    And Kubernetes resource v1/ConfigMap 'default/config' has 'metadata.creationTimestamp=2024-01-01T00:00:00Z'
+
+  Scenario: should stop failing requests
+    Given Kubernetes fails the next 10 get of v1/Service with 'NotFound'
+    When Kubernetes no longer fails requests
+    Then Kubernetes has v1/Service 'default/kubernetes'
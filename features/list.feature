@@ -13,3 +13,16 @@ Feature: List resources
     Given Kubernetes has v1/Service 'default/default'
     And Kubernetes has v1/Service 'default/kubernetes'
     And Kubernetes has 2 v1/Service in namespace 'default'
+
+  Scenario: should list only the expected resources in a namespace
+    Given Kubernetes creates a new v1/Namespace 'only-resources'
+    And Kubernetes creates the following resources
+      | ApiGroupVersion | Kind           | Namespace      | Name   |
+      | v1              | ConfigMap      | only-resources | config |
+      | v1              | Secret         | only-resources | secret |
+      | v1              | ServiceAccount | only-resources | robot  |
+    Then Kubernetes has only the following resources in namespace 'only-resources'
+      | ApiGroupVersion | Kind           | Namespace      | Name   |
+      | v1              | ConfigMap      |                | config |
+      | v1              | Secret         | only-resources | secret |
+      | v1              | ServiceAccount | only-resources | robot  |
@@ -0,0 +1,26 @@
+Feature: Simulate the pods lifecycle
+  In order to test pods lifecycle features
+  As feature context
+  I need to be able to drive the pods status like a kubelet does
+
+  Background:
+    Given Kubernetes must have v1/Pod 'default/pod' with
+      """
+      spec:
+        containers:
+          - name: app
+            image: nginx
+      """
+
+  Scenario: should make a pod ready
+    When Kubernetes pod 'default/pod' becomes ready
+    Then Kubernetes resource v1/Pod 'default/pod' has 'status.phase=Running'
+    And Kubernetes resource v1/Pod 'default/pod' has 'status.podIP'
+    And Kubernetes resource v1/Pod 'default/pod' has 'status.containerStatuses[0].ready=true'
+
+  Scenario: should restart a failed container
+    Given Kubernetes pod 'default/pod' becomes ready
+    When Kubernetes pod 'default/pod' container 'app' terminates with exit code 1
+    Then Kubernetes resource v1/Pod 'default/pod' has 'status.phase=Running'
+    And Kubernetes resource v1/Pod 'default/pod' has 'status.containerStatuses[0].restartCount=1'
+    And Kubernetes resource v1/Pod 'default/pod' has 'status.containerStatuses[0].state.waiting.reason=CrashLoopBackOff'
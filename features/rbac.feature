@@ -0,0 +1,43 @@
+Feature: Enforce RBAC rules
+  In order to test RBAC features
+  As feature context
+  I need to be able to act as a specific user and validate its permissions
+
+  Background:
+    Given Kubernetes must have rbac.authorization.k8s.io/v1/Role 'default/pod-reader' from features/resources/rbac/pod-reader.yaml
+    And Kubernetes must have rbac.authorization.k8s.io/v1/RoleBinding 'default/pod-reader' with
+      """
+      roleRef:
+        apiGroup: rbac.authorization.k8s.io
+        kind: Role
+        name: pod-reader
+      subjects:
+        - kind: User
+          name: alice
+        - kind: ServiceAccount
+          name: reader
+          namespace: default
+      """
+    And Kubernetes must have v1/Pod 'default/pod'
+
+  Scenario: should validate user permissions
+    Then User 'alice' can get v1/Pod in namespace 'default'
+    And User 'alice' can list pods in namespace 'default'
+    And User 'alice' cannot delete v1/Pod in namespace 'default'
+    And User 'alice' cannot get v1/Pod in namespace 'kube-system'
+    And User 'alice' cannot list v1/Pod
+    And User 'system:serviceaccount:default:reader' can get po in namespace 'default'
+    And User 'bob' cannot get v1/Pod in namespace 'default'
+
+  Scenario: should act as a user
+    When Kubernetes acts as user 'alice'
+    Then Kubernetes has v1/Pod 'default/pod'
+    And Kubernetes has 1 v1/Pod in namespace 'default'
+    When Kubernetes acts as user 'bob' in groups 'system:masters'
+    Then Kubernetes removes v1/Pod 'default/pod'
+
+  Scenario: should act as a service account
+    When Kubernetes acts as service account 'default/reader'
+    Then Kubernetes has v1/Pod 'default/pod'
+    When Kubernetes acts as cluster administrator
+    Then Kubernetes removes v1/Pod 'default/pod'
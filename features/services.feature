@@ -0,0 +1,58 @@
+Feature: Emulate the service endpoints
+  In order to test that services select the right pods
+  As feature context
+  I need to be able to compute the endpoints of services like Kubernetes does
+
+  Background:
+    Given Kubernetes must have v1/Service 'default/web' with
+      """
+      spec:
+        selector:
+          app: web
+        ports:
+          - port: 80
+            targetPort: 8080
+      """
+    And Kubernetes must have v1/Pod 'default/web-1' with
+      """
+      metadata:
+        labels:
+          app: web
+      spec:
+        containers:
+          - name: app
+            image: nginx
+      """
+    And Kubernetes must have v1/Pod 'default/web-2' with
+      """
+      metadata:
+        labels:
+          app: web
+      spec:
+        containers:
+          - name: app
+            image: nginx
+      """
+    And Kubernetes must have v1/Pod 'default/other' with
+      """
+      metadata:
+        labels:
+          app: other
+      spec:
+        containers:
+          - name: app
+            image: nginx
+      """
+
+  Scenario: should list only the ready pods selected by a service
+    When Kubernetes pod 'default/web-1' becomes ready
+    And Kubernetes pod 'default/other' becomes ready
+    Then Kubernetes service 'default/web' has endpoints for pods 'web-1'
+    And Kubernetes resource v1/Endpoints 'default/web' has 'subsets[0].ports[0].port=8080'
+
+  Scenario: should update the endpoints when pods are removed
+    Given Kubernetes pod 'default/web-1' becomes ready
+    And Kubernetes pod 'default/web-2' becomes ready
+    When Kubernetes removes v1/Pod 'default/web-2'
+    Then Kubernetes service 'default/web' has endpoints for pods 'web-1'
+    And Kubernetes has 1 discovery.k8s.io/v1beta1/EndpointSlice in namespace 'default'
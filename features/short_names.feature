@@ -0,0 +1,26 @@
+Feature: Use short kind names
+  In order to write shorter steps
+  As feature context
+  I need to be able to use kinds, resources and aliases instead of complete GroupVersionKinds
+
+  Scenario: should manage resources with short kind names
+    Given Kubernetes must have Namespace 'kube-lease'
+    When Kubernetes creates a new svc 'kube-lease/svc'
+    And Kubernetes creates a new deployments.apps 'kube-lease/deploy' with
+      """
+      spec:
+        selector:
+          matchLabels:
+            app: demo
+      """
+    And Kubernetes creates a new Widget.example.com 'kube-lease/widget' with
+      """
+      spec:
+        image: nginx
+      """
+    Then Kubernetes has ns 'kube-lease'
+    And Kubernetes has v1/Service 'kube-lease/svc'
+    And Kubernetes has apps/v1/Deployment 'kube-lease/deploy'
+    And Kubernetes has deploy 'kube-lease/deploy'
+    And Kubernetes has wg 'kube-lease/widget'
+    And Kubernetes has 1 Service in namespace 'kube-lease'
@@ -0,0 +1,33 @@
+Feature: Control the time
+  In order to test time-dependent features
+  As feature context
+  I need to be able to drive the time used by Kubernetes
+
+  Background:
+    Given Time is '2024-01-01T00:00:00Z'
+
+  Scenario: should timestamp created resources with the current time
+    When Kubernetes creates a new v1/ConfigMap 'default/config'
+    Then Kubernetes resource v1/ConfigMap 'default/config' has 'metadata.creationTimestamp=2024-01-01T00:00:00Z'
+
+  Scenario: should advance the time
+    When Time advances by 5m
+    And Kubernetes creates a new v1/ConfigMap 'default/config'
+    Then Kubernetes resource v1/ConfigMap 'default/config' has 'metadata.creationTimestamp=2024-01-01T00:05:00Z'
+
+  Scenario: should mark resources with finalizers as being deleted
+    Given Kubernetes must have v1/ConfigMap 'default/config' with
+      """
+      metadata:
+        finalizers:
+          - example.com/protection
+      """
+    When Time advances by 1h
+    And Kubernetes removes v1/ConfigMap 'default/config'
+    Then Kubernetes resource v1/ConfigMap 'default/config' has 'metadata.deletionTimestamp=2024-01-01T01:00:00Z'
+    When Kubernetes patches v1/ConfigMap 'default/config' with
+      """
+      metadata:
+        finalizers: null
+      """
+    Then Kubernetes doesn't have v1/ConfigMap 'default/config'
@@ -0,0 +1,32 @@
+Feature: Validate custom resources
+  In order to test custom resource validation features
+  As feature context
+  I need to be able to validate custom resources with their x-kubernetes-validations rules
+
+  Scenario: should reject resource creation violating a rule
+    When Kubernetes refuses to create example.com/v1/Widget 'default/widget' due to rule 'replicas must be lower than or equal to 10' with
+      """
+      spec:
+        image: nginx
+        replicas: 11
+      """
+    Then Kubernetes doesn't have example.com/v1/Widget 'default/widget'
+
+  Scenario: should reject resource patch violating a transition rule
+    Given Kubernetes creates a new example.com/v1/Widget 'default/widget' with
+      """
+      spec:
+        image: nginx
+      """
+    When Kubernetes patches example.com/v1/Widget 'default/widget' with
+      """
+      spec:
+        replicas: 3
+      """
+    Then Kubernetes refuses to patch example.com/v1/Widget 'default/widget' due to rule 'image is immutable' with
+      """
+      spec:
+        image: httpd
+      """
+    And Kubernetes resource example.com/v1/Widget 'default/widget' has 'spec.image=nginx'
+    And Kubernetes resource example.com/v1/Widget 'default/widget' has 'spec.replicas=3'
@@ -0,0 +1,22 @@
+Feature: Manage resource versions
+  In order to test the optimistic concurrency
+  As feature context
+  I need to be able to write stale versions and compare resource versions
+
+  Scenario: should reject the update of a stale version
+    Given Kubernetes captures the version of v1/Service 'default/kubernetes'
+    When Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=new-value'
+    Then Kubernetes fails to update v1/Service 'default/kubernetes' from stale version with
+      """
+      metadata:
+        labels:
+          stale: "true"
+      """
+    And Kubernetes resource v1/Service 'default/kubernetes' doesn't have label 'stale'
+    And Kubernetes resource v1/Service 'default/kubernetes' resourceVersion has changed
+    And Kubernetes resource v1/Service 'default/kubernetes' generation hasn't changed
+
+  Scenario: should compare the resource versions
+    Given Kubernetes captures the version of v1/Service 'default/kubernetes'
+    Then Kubernetes resource v1/Service 'default/kubernetes' resourceVersion hasn't changed
+    And Kubernetes resource v1/Service 'default/kubernetes' generation hasn't changed
@@ -0,0 +1,48 @@
+Feature: Watch Kubernetes resources
+  In order to validate the order of the changes made by controllers
+  As feature context
+  I need to be able to watch Kubernetes resources
+
+  Scenario: should see the changes in their order
+    Given Kubernetes watches v1/Service in namespace 'default'
+    When Kubernetes creates a new v1/Service 'default/svc'
+    And Kubernetes labelizes v1/Service 'default/svc' with 'key=value'
+    And Kubernetes creates a new v1/Service 'kube-system/svc'
+    And Kubernetes removes v1/Service 'default/svc'
+    Then Kubernetes watch saw these events
+      | Type     | NamespacedName |
+      | ADDED    | default/svc    |
+      | MODIFIED | default/svc    |
+      | DELETED  | default/svc    |
+
+  Scenario: should see the changes on several watches
+    Given Kubernetes watches v1/Namespace
+    And Kubernetes watches v1/Service
+    When Kubernetes creates a new v1/Namespace 'watched'
+    And Kubernetes creates a new v1/Service 'watched/svc'
+    Then Kubernetes watch on v1/Namespace saw these events
+      | ADDED | watched |
+    And Kubernetes watch on v1/Service saw these events
+      | ADDED | watched/svc |
+
+  Scenario: should see the changes made by the emulated controllers
+    Given Kubernetes watches apps/v1/ReplicaSet in namespace 'default'
+    When Kubernetes creates a new apps/v1/Deployment 'default/nginx' with
+      """
+      spec:
+        replicas: 1
+        selector:
+          matchLabels:
+            app: nginx
+        template:
+          metadata:
+            labels:
+              app: nginx
+          spec:
+            containers:
+            - name: nginx
+              image: nginx
+      """
+    Then Kubernetes watch saw these events
+      | ADDED    | default/nginx-65b8cb5c7b |
+      | MODIFIED | default/nginx-65b8cb5c7b |
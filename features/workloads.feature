@@ -0,0 +1,80 @@
+Feature: Emulate the workload controllers
+  In order to test features depending on workloads
+  As feature context
+  I need to be able to create Deployments, StatefulSets and Jobs like the Kubernetes controllers do
+
+  Scenario: should create the pods of a Deployment through a ReplicaSet
+    When Kubernetes creates a new apps/v1/Deployment 'default/nginx' with
+      """
+      spec:
+        replicas: 2
+        selector:
+          matchLabels:
+            app: nginx
+        template:
+          metadata:
+            labels:
+              app: nginx
+          spec:
+            containers:
+              - name: app
+                image: nginx
+      """
+    Then Kubernetes has 1 apps/v1/ReplicaSet in namespace 'default'
+    And Kubernetes has 2 v1/Pod in namespace 'default'
+    And Kubernetes resource apps/v1/Deployment 'default/nginx' has 'status.replicas=2'
+
+  Scenario: should remove the pods of a removed Deployment
+    Given Kubernetes must have apps/v1/Deployment 'default/nginx' with
+      """
+      spec:
+        selector:
+          matchLabels:
+            app: nginx
+        template:
+          metadata:
+            labels:
+              app: nginx
+          spec:
+            containers:
+              - name: app
+                image: nginx
+      """
+    When Kubernetes removes apps/v1/Deployment 'default/nginx'
+    Then Kubernetes has 0 apps/v1/ReplicaSet in namespace 'default'
+    And Kubernetes has 0 v1/Pod in namespace 'default'
+
+  Scenario: should create the ordered pods of a StatefulSet
+    When Kubernetes creates a new apps/v1/StatefulSet 'default/db' with
+      """
+      spec:
+        replicas: 2
+        serviceName: db
+        selector:
+          matchLabels:
+            app: db
+        template:
+          metadata:
+            labels:
+              app: db
+          spec:
+            containers:
+              - name: app
+                image: postgres
+      """
+    Then Kubernetes has v1/Pod 'default/db-0'
+    And Kubernetes has v1/Pod 'default/db-1'
+
+  Scenario: should create the pod of a Job
+    When Kubernetes creates a new batch/v1/Job 'default/migrate' with
+      """
+      spec:
+        template:
+          spec:
+            restartPolicy: Never
+            containers:
+              - name: app
+                image: migrate
+      """
+    Then Kubernetes has 1 v1/Pod in namespace 'default'
+    And Kubernetes resource batch/v1/Job 'default/migrate' has 'status.active=1'
@@ -0,0 +1,140 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// CountClientCalls implements the GoDoc step
+// - `Kubernetes client issued <N> <Verb> on <ApiGroupVersionKind>`
+// - `Kubernetes client issued <N> <Verb> on <ApiGroupVersionKind> '<NamespacedName>'`
+// It validates the number of requests sent through the feature context
+// client with the given verb (including on the subresources) on the given
+// kind or resource. The call recording must be enabled with WithCallRecording.
+func CountClientCalls(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsCalls,
+			Expr:  `^Kubernetes client issued (\d+) ` + RxVerb + ` on (` + RxGroupVersionKind + `)(?: '(` + RxNamespacedName + `)')?$`,
+			Templates: []string{
+				"Kubernetes client issued <N> <Verb> on <ApiGroupVersionKind>",
+				"Kubernetes client issued <N> <Verb> on <ApiGroupVersionKind> '<NamespacedName>'",
+			},
+			Description: "It validates the number of requests sent through the feature context client with the given verb (including on the subresources) on the given kind or resource. The call recording must be enabled with WithCallRecording.",
+			Examples: []string{
+				"Kubernetes client issued 0 update on apps/v1/Deployment 'default/nginx'",
+				"Kubernetes client issued 2 creates on v1/Service",
+			},
+		},
+		func(n int, verb, groupVersionKindStr, name string) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(name)
+
+			calls, err := ctx.Calls()
+			if err != nil {
+				return err
+			}
+
+			count := 0
+			for _, call := range calls {
+				if call.matches(verb, groupVersionKind, namespacedName) {
+					count++
+				}
+			}
+
+			if count != n {
+				target := groupVersionKindStr
+				if name != "" {
+					target = fmt.Sprintf("%s '%s'", groupVersionKindStr, name)
+				}
+				return fmt.Errorf("client issued %d %s on %s, not %d", count, verb, target, n)
+			}
+			return nil
+		},
+	)
+}
+
+// ClientIssuedCalls implements the GoDoc step
+// - `Kubernetes client issued exactly these calls <CallTable>`
+// It validates the fact that the requests sent through the feature context
+// client are exactly the given ones, in the same order. Requests on a
+// subresource (like status) are given through the optional Subresource
+// column. The call recording must be enabled with WithCallRecording.
+func ClientIssuedCalls(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCalls,
+			Expr:        `^Kubernetes client issued exactly these calls$`,
+			Templates:   []string{"Kubernetes client issued exactly these calls <CallTable>"},
+			Description: "It validates the fact that the requests sent through the feature context client are exactly the given ones, in the same order. Requests on a subresource (like status) are given through the optional Subresource column. The call recording must be enabled with WithCallRecording.",
+			Examples:    []string{"Kubernetes client issued exactly these calls"},
+		},
+		func(table helpers.CallTable) error {
+			expected, err := helpers.UnmarshalCallTable(table)
+			if err != nil {
+				return err
+			}
+
+			calls, err := ctx.Calls()
+			if err != nil {
+				return err
+			}
+
+			issued := make([]string, len(calls))
+			for i, call := range calls {
+				issued[i] = call.String()
+			}
+
+			if len(calls) != len(expected) {
+				return fmt.Errorf("client issued %d calls, not %d: [%s]", len(calls), len(expected), strings.Join(issued, ", "))
+			}
+			for i, row := range expected {
+				groupVersionKind, err := ctx.ResolveGroupVersionKind(row.GroupVersion + "/" + row.Kind)
+				if err != nil {
+					return err
+				}
+
+				expectedCall := APICall{
+					Verb:             strings.ToLower(row.Verb),
+					GroupVersionKind: groupVersionKind,
+					Namespace:        row.Namespace,
+					Name:             row.Name,
+					Subresource:      row.Subresource,
+				}
+				if call := calls[i]; call.Verb != expectedCall.Verb || call.GroupVersionKind != expectedCall.GroupVersionKind ||
+					call.Namespace != expectedCall.Namespace || call.Name != expectedCall.Name || call.Subresource != expectedCall.Subresource {
+					return fmt.Errorf("call #%d is '%s', not '%s': [%s]", i+1, call, expectedCall, strings.Join(issued, ", "))
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// ResetClientCalls implements the GoDoc step
+// - `Kubernetes client forgets the issued calls`
+// It forgets all requests sent through the feature context client until
+// now, in order to validate only the following ones.
+func ResetClientCalls(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCalls,
+			Expr:        `^Kubernetes client forgets the issued calls$`,
+			Templates:   []string{"Kubernetes client forgets the issued calls"},
+			Description: "It forgets all requests sent through the feature context client until now, in order to validate only the following ones.",
+			Examples:    []string{"Kubernetes client forgets the issued calls"},
+		},
+		func() error {
+			if _, err := ctx.Calls(); err != nil {
+				return err
+			}
+			ctx.ResetCalls()
+			return nil
+		},
+	)
+}
@@ -0,0 +1,78 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SaveCheckpoint implements the GoDoc step
+// - `Kubernetes saves a checkpoint '<Name>'`
+// It keeps a deep copy of all resources stored by the fake client, which
+// can be restored later in the scenario.
+func SaveCheckpoint(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCheckpoints,
+			Expr:        `^Kubernetes saves a checkpoint '([^']+)'$`,
+			Templates:   []string{"Kubernetes saves a checkpoint '<Name>'"},
+			Description: "It keeps a deep copy of all resources stored by the fake client, which can be restored later in the scenario.",
+			Examples:    []string{"Kubernetes saves a checkpoint 'before-upgrade'"},
+		},
+		func(name string) error {
+			return ctx.SaveCheckpoint(name)
+		},
+	)
+}
+
+// RestoreCheckpoint implements the GoDoc step
+// - `Kubernetes restores checkpoint '<Name>'`
+// It replaces all resources stored by the fake client by the ones kept by
+// the given checkpoint.
+func RestoreCheckpoint(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCheckpoints,
+			Expr:        `^Kubernetes restores checkpoint '([^']+)'$`,
+			Templates:   []string{"Kubernetes restores checkpoint '<Name>'"},
+			Description: "It replaces all resources stored by the fake client by the ones kept by the given checkpoint.",
+			Examples:    []string{"Kubernetes restores checkpoint 'before-upgrade'"},
+		},
+		func(name string) error {
+			return ctx.RestoreCheckpoint(name)
+		},
+	)
+}
+
+// ChangedSinceCheckpoint implements the GoDoc step
+// - `Kubernetes has changed since checkpoint '<Name>'`
+// - `Kubernetes hasn't changed since checkpoint '<Name>'`
+// It compares all resources stored by the fake client with the ones kept by
+// the given checkpoint and reports the added, removed or modified ones.
+func ChangedSinceCheckpoint(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsCheckpoints,
+			Expr:  `^Kubernetes (has|hasn't) changed since checkpoint '([^']+)'$`,
+			Templates: []string{
+				"Kubernetes has changed since checkpoint '<Name>'",
+				"Kubernetes hasn't changed since checkpoint '<Name>'",
+			},
+			Description: "It compares all resources stored by the fake client with the ones kept by the given checkpoint and reports the added, removed or modified ones.",
+			Examples:    []string{"Kubernetes hasn't changed since checkpoint 'before-upgrade'"},
+		},
+		func(has, name string) error {
+			diffs, err := ctx.DiffCheckpoint(name)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case has == "has" && len(diffs) == 0:
+				return fmt.Errorf("Kubernetes hasn't changed since checkpoint '%s'", name)
+			case has == "hasn't" && len(diffs) > 0:
+				return fmt.Errorf("Kubernetes has changed since checkpoint '%s':\n%s", name, strings.Join(diffs, "\n"))
+			}
+			return nil
+		},
+	)
+}
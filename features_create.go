@@ -14,10 +14,16 @@ import (
 // - `Kubernetes creates a new <ApiGroupVersionKind> '<NamespacedName>'`
 // It creates a new resource, without any specific fields.
 func CreateSingleResource(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes (?:must have|creates a new) (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCreate,
+			Expr:        `^Kubernetes (?:must have|creates a new) (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes must have <ApiGroupVersionKind> '<NamespacedName>'", "Kubernetes creates a new <ApiGroupVersionKind> '<NamespacedName>'"},
+			Description: "It creates a new resource, without any specific fields.",
+			Examples:    []string{"Kubernetes must have v1/Namespace 'kube-lease'", "Kubernetes creates a new v1/Service 'kube-lease/svc'"},
+		},
 		func(groupVersionKindStr, resourceName string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -33,10 +39,16 @@ func CreateSingleResource(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes creates a new <ApiGroupVersionKind> '<NamespacedName>' with <YAML>`
 // It creates a new resource, with the given definition.
 func CreateSingleResourceWith(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes (?:must have|creates a new) (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' with$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCreate,
+			Expr:        `^Kubernetes (?:must have|creates a new) (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' with$`,
+			Templates:   []string{"Kubernetes must have <ApiGroupVersionKind> '<NamespacedName>' with <YAML>", "Kubernetes creates a new <ApiGroupVersionKind> '<NamespacedName>' with <YAML>"},
+			Description: "It creates a new resource, with the given definition.",
+			Examples:    []string{"Kubernetes must have v1/Service 'kube-lease/svc' with", "Kubernetes creates a new v1/Service 'kube-lease/svc-lb' with"},
+		},
 		func(groupVersionKindStr, resourceName string, yamlObj helpers.YamlDocString) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -55,12 +67,18 @@ func CreateSingleResourceWith(ctx *FeatureContext, s ScenarioContext) {
 // CreateSingleResourceFrom implements the GoDoc step
 // - `Kubernetes must have <ApiGroupVersionKind> '<NamespacedName>' from <filename>`
 // - `Kubernetes creates a new <ApiGroupVersionKind> '<NamespacedName>' from <filename>`
-// It creates a new resource, with then definition available in the given filename.
+// It creates a new resource, with the definition available in the given filename.
 func CreateSingleResourceFrom(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes (?:must have|creates a new) (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' from (.+)$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCreate,
+			Expr:        `^Kubernetes (?:must have|creates a new) (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' from (.+)$`,
+			Templates:   []string{"Kubernetes must have <ApiGroupVersionKind> '<NamespacedName>' from <filename>", "Kubernetes creates a new <ApiGroupVersionKind> '<NamespacedName>' from <filename>"},
+			Description: "It creates a new resource, with the definition available in the given filename.",
+			Examples:    []string{"Kubernetes must have v1/Service 'kube-lease/svc' from features/resources/kube-lease/svc.yaml", "Kubernetes creates a new v1/Service 'kube-lease/svc-lb' from features/resources/kube-lease/svc-lb.yaml"},
+		},
 		func(groupVersionKindStr, resourceName, fileName string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -87,8 +105,14 @@ func CreateSingleResourceFrom(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes creates the following resources <RESOURCES_TABLE>`
 // It creates several resources in a row, without any specific fields (useful for Namespaces).
 func CreateMultiResources(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes (?:must have|creates) the following resources$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsCreate,
+			Expr:        `^Kubernetes (?:must have|creates) the following resources$`,
+			Templates:   []string{"Kubernetes must have the following resources <RESOURCES_TABLE>", "Kubernetes creates the following resources <RESOURCES_TABLE>"},
+			Description: "It creates several resources in a row, without any specific fields (useful for Namespaces).",
+			Examples:    []string{"Kubernetes must have the following resources", "Kubernetes creates the following resources"},
+		},
 		func(table helpers.ResourceTable) error {
 			resources, err := helpers.UnmarshalResourceTable(table)
 			if err != nil {
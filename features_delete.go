@@ -6,10 +6,16 @@ import "github.com/xunleii/godog-kubernetes/helpers"
 // - `Kubernetes removes <ApiGroupVersionKind> '<NamespacedName>'`
 // It removes the specified resource.
 func RemoveResource(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes removes (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsDelete,
+			Expr:        `^Kubernetes removes (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes removes <ApiGroupVersionKind> '<NamespacedName>'"},
+			Description: "It removes the specified resource.",
+			Examples:    []string{"Kubernetes removes example.com/v1/Widget 'default/widget'", "Kubernetes removes v1/Service 'default/default'"},
+		},
 		func(groupVersionKindStr, name string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -23,10 +29,16 @@ func RemoveResource(ctx *FeatureContext, s ScenarioContext) {
 
 // RemoveMultiResource implements the GoDoc step
 // - `Kubernetes removes the following resources <RESOURCES_TABLE>`
-// It creates several resources in a row.
+// It removes several resources in a row.
 func RemoveMultiResource(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes removes the following resources$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsDelete,
+			Expr:        `^Kubernetes removes the following resources$`,
+			Templates:   []string{"Kubernetes removes the following resources <RESOURCES_TABLE>"},
+			Description: "It removes several resources in a row.",
+			Examples:    []string{"Kubernetes removes the following resources"},
+		},
 		func(table helpers.ResourceTable) error {
 			resources, err := helpers.UnmarshalResourceTable(table)
 			if err != nil {
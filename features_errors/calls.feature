@@ -0,0 +1,21 @@
+Feature: Record the Kubernetes client calls
+  In order to validate the idempotency of controllers
+  As feature context
+  I need to be able to assert on the requests sent through the Kubernetes client
+
+  Scenario: should failed due to an unexpected number of calls
+    Given Kubernetes client forgets the issued calls
+    When Kubernetes creates a new v1/Service 'default/svc'
+    Then Kubernetes client issued 0 create on v1/Service 'default/svc'
+
+  Scenario: should failed due to unexpected calls
+    Given Kubernetes client forgets the issued calls
+    When Kubernetes creates a new v1/Service 'default/svc'
+    Then Kubernetes client issued exactly these calls
+      | Verb   | ApiGroupVersion | Kind    | Namespace | Name |
+      | update | v1              | Service | default   | svc  |
+
+  Scenario: should failed due to missing calls
+    Given Kubernetes client forgets the issued calls
+    Then Kubernetes client issued exactly these calls
+      | create | v1 | Service | default | svc |
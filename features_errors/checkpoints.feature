@@ -0,0 +1,18 @@
+Feature: Save and restore checkpoints of Kubernetes resources
+  In order to try several alternatives from the same state
+  As feature context
+  I need to be able to save and restore the state of Kubernetes
+
+  Scenario: should failed due to an unknown checkpoint
+    Given Kubernetes restores checkpoint 'unknown'
+
+  Scenario: should failed due to the changes since the checkpoint
+    Given Kubernetes saves a checkpoint 'initial'
+    When Kubernetes creates a new v1/Service 'default/svc'
+    And Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=updated'
+    And Kubernetes removes v1/Service 'default/default'
+    Then Kubernetes hasn't changed since checkpoint 'initial'
+
+  Scenario: should failed due to the lack of changes since the checkpoint
+    Given Kubernetes saves a checkpoint 'initial'
+    Then Kubernetes has changed since checkpoint 'initial'
@@ -0,0 +1,19 @@
+Feature: Expect failures of Kubernetes operations
+  In order to test rejected operations
+  As feature context
+  I need to be able to validate that an operation fails with a specific reason
+
+  Scenario: should not validate a successful creation
+    Then Kubernetes fails to create v1/Namespace 'kube-lease' due to AlreadyExists
+
+  Scenario: should not validate a failure with another reason
+    Then Kubernetes fails to create v1/Namespace 'default' due to Invalid
+
+  Scenario: should not validate a failure with another message
+    Then Kubernetes fails to create v1/Namespace 'default' due to AlreadyExists matching 'forbidden'
+
+  Scenario: should not validate an unknown reason
+    Then Kubernetes fails to remove v1/Service 'default/unknown' due to Unknown
+
+  Scenario: should not validate an invalid message expression
+    Then Kubernetes fails to remove v1/Service 'default/unknown' due to NotFound matching '('
@@ -0,0 +1,17 @@
+Feature: Inject faults on Kubernetes requests
+  In order to test the error handling of controllers
+  As feature context
+  I need to be able to make the Kubernetes API misbehave on demand
+
+  Scenario: should failed due to the injected fault
+    Given Kubernetes fails the next 1 patch of v1/Service 'default/kubernetes' with 'NotFound'
+    Then Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=value'
+
+  Scenario: should failed due to an unknown reason
+    Given Kubernetes fails the next 1 get of v1/Service with 'Unknown'
+
+  Scenario: should failed due to an invalid latency
+    Given Kubernetes fails the next 1 get of v1/Service with latency 5 minutes
+
+  Scenario: should failed due to no request to fail
+    Given Kubernetes fails the next 0 update of v1/Service with 'Conflict'
@@ -33,3 +33,29 @@ Feature: List resources with errors
   Scenario: should failed due to the non-existent on namespaced resource listing
     When Kubernetes has 2 v1/Pod in namespace 'default'
 
+
+  Scenario: should failed due to unexpected resources in the namespace
+    Given Kubernetes creates a new v1/ConfigMap 'default/config'
+    When Kubernetes has only the following resources in namespace 'default'
+      | ApiGroupVersion | Kind    | Namespace | Name       |
+      | v1              | Service | default   | default    |
+      | v1              | Service | default   | kubernetes |
+
+  Scenario: should failed due to missing resources in the namespace
+    When Kubernetes has only the following resources in namespace 'default'
+      | ApiGroupVersion | Kind      | Namespace | Name       |
+      | v1              | Service   | default   | default    |
+      | v1              | Service   | default   | kubernetes |
+      | v1              | ConfigMap | default   | missing    |
+
+  Scenario: should failed due to a resource of another namespace
+    When Kubernetes has only the following resources in namespace 'default'
+      | ApiGroupVersion | Kind    | Namespace   | Name       |
+      | v1              | Service | default     | kubernetes |
+      | v1              | Service | kube-system | kube-dns   |
+
+  Scenario: should failed due to a cluster-scoped resource
+    When Kubernetes has only the following resources in namespace 'default'
+      | ApiGroupVersion | Kind      | Namespace | Name       |
+      | v1              | Service   | default   | kubernetes |
+      | v1              | Namespace |           | default    |
@@ -0,0 +1,17 @@
+Feature: Simulate the pods lifecycle with errors
+  In order to test pods lifecycle features
+  As feature context
+  I need to be able to fail when a pod can't be driven
+
+  Scenario: should failed due to nonexistent pod
+    When Kubernetes pod 'default/pod' becomes ready
+
+  Scenario: should failed due to nonexistent container
+    Given Kubernetes must have v1/Pod 'default/pod' with
+      """
+      spec:
+        containers:
+          - name: app
+            image: nginx
+      """
+    When Kubernetes pod 'default/pod' container 'sidecar' terminates with exit code 1
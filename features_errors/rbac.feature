@@ -0,0 +1,38 @@
+Feature: Enforce RBAC rules
+  In order to test RBAC features
+  As feature context
+  I need to be able to fail when a user is not allowed
+
+  Scenario: should failed due to forbidden request
+    When Kubernetes acts as user 'alice'
+    Then Kubernetes removes v1/Service 'default/default'
+
+  Scenario: should failed due to missing permission
+    Then User 'alice' can get v1/Pod in namespace 'default'
+
+  Scenario: should failed due to unexpected permission
+    Given Kubernetes must have rbac.authorization.k8s.io/v1/ClusterRoleBinding 'root' with
+      """
+      roleRef:
+        apiGroup: rbac.authorization.k8s.io
+        kind: ClusterRole
+        name: cluster-admin
+      subjects:
+        - kind: User
+          name: root
+      """
+    And Kubernetes must have rbac.authorization.k8s.io/v1/ClusterRole 'cluster-admin' with
+      """
+      rules:
+        - apiGroups: ["*"]
+          resources: ["*"]
+          verbs: ["*"]
+      """
+    Then User 'root' cannot get v1/Pod in namespace 'default'
+
+  Scenario: should failed due to non namespaced service account
+    When Kubernetes acts as service account 'reader'
+
+  @as:alice
+  Scenario: should failed due to forbidden request by the tagged user
+    Then Kubernetes has v1/Service 'default/default'
@@ -0,0 +1,18 @@
+Feature: Emulate the service endpoints (errors)
+  In order to test that services select the right pods
+  As feature context
+  I need to be able to compute the endpoints of services like Kubernetes does
+
+  Background:
+    Given Kubernetes must have v1/Service 'default/web' with
+      """
+      spec:
+        selector:
+          app: web
+      """
+
+  Scenario: should failed due to unexpected endpoints
+    Then Kubernetes service 'default/web' has endpoints for pods 'web-1'
+
+  Scenario: should failed due to non-existent service endpoints
+    Then Kubernetes service 'default/unknown' has endpoints for pods ''
@@ -0,0 +1,10 @@
+Feature: Control the time (errors)
+  In order to test time-dependent features
+  As feature context
+  I need to be able to drive the time used by Kubernetes
+
+  Scenario: should failed due to invalid duration
+    When Time advances by 5 minutes
+
+  Scenario: should failed due to invalid time
+    When Time is '2024-01-01 00:00:00'
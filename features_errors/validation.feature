@@ -0,0 +1,33 @@
+Feature: Validate custom resources with errors
+  In order to test custom resource validation features
+  As feature context
+  I need to be able to manage validation errors
+
+  Scenario: should failed due to accepted resource creation
+    When Kubernetes refuses to create example.com/v1/Widget 'default/widget' due to rule 'replicas must be lower than or equal to 10' with
+      """
+      spec:
+        image: nginx
+      """
+
+  Scenario: should failed due to resource creation rejected by another rule
+    When Kubernetes refuses to create example.com/v1/Widget 'default/widget' due to rule 'image is immutable' with
+      """
+      spec:
+        image: nginx
+        replicas: 11
+      """
+
+  Scenario: should failed due to resource creation rejected by the schema
+    When Kubernetes refuses to create example.com/v1/Widget 'default/widget' due to rule 'replicas must be lower than or equal to 10' with
+      """
+      spec:
+        replicas: 1
+      """
+
+  Scenario: should failed due to non-existent resource on resource patch
+    When Kubernetes refuses to patch example.com/v1/Widget 'default/widget' due to rule 'image is immutable' with
+      """
+      spec:
+        image: httpd
+      """
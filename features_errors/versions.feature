@@ -0,0 +1,25 @@
+Feature: Manage resource versions
+  In order to test the optimistic concurrency
+  As feature context
+  I need to be able to write stale versions and compare resource versions
+
+  Scenario: should failed due to a version not captured
+    Then Kubernetes resource v1/Service 'default/kubernetes' resourceVersion hasn't changed
+
+  Scenario: should failed due to an up-to-date version
+    Given Kubernetes captures the version of v1/Service 'default/kubernetes'
+    Then Kubernetes fails to update v1/Service 'default/kubernetes' from stale version with
+      """
+      metadata:
+        labels:
+          stale: "true"
+      """
+
+  Scenario: should failed due to an unchanged resourceVersion
+    Given Kubernetes captures the version of v1/Service 'default/kubernetes'
+    Then Kubernetes resource v1/Service 'default/kubernetes' resourceVersion has changed
+
+  Scenario: should failed due to a changed resourceVersion
+    Given Kubernetes captures the version of v1/Service 'default/kubernetes'
+    When Kubernetes labelizes v1/Service 'default/kubernetes' with 'key=new-value'
+    Then Kubernetes resource v1/Service 'default/kubernetes' resourceVersion hasn't changed
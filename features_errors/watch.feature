@@ -0,0 +1,15 @@
+Feature: Watch Kubernetes resources
+  In order to validate the order of the changes made by controllers
+  As feature context
+  I need to be able to watch Kubernetes resources
+
+  Scenario: should failed due to unexpected events
+    Given Kubernetes watches v1/Service in namespace 'default'
+    When Kubernetes creates a new v1/Service 'default/svc'
+    Then Kubernetes watch saw these events
+      | Type    | NamespacedName |
+      | DELETED | default/svc    |
+
+  Scenario: should failed due to a watch not opened
+    Then Kubernetes watch on v1/Service saw these events
+      | ADDED | default/svc |
@@ -0,0 +1,187 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// RxStatusReason matches the reason of a failure, like AlreadyExists.
+const RxStatusReason = `\w+`
+
+// statusReasons associates the expected failure reasons with the apierrors
+// helper checking them.
+var statusReasons = map[metav1.StatusReason]func(error) bool{
+	metav1.StatusReasonAlreadyExists:    errors.IsAlreadyExists,
+	metav1.StatusReasonBadRequest:       errors.IsBadRequest,
+	metav1.StatusReasonConflict:         errors.IsConflict,
+	metav1.StatusReasonForbidden:        errors.IsForbidden,
+	metav1.StatusReasonGone:             errors.IsGone,
+	metav1.StatusReasonInvalid:          errors.IsInvalid,
+	metav1.StatusReasonMethodNotAllowed: errors.IsMethodNotSupported,
+	metav1.StatusReasonNotFound:         errors.IsNotFound,
+	metav1.StatusReasonUnauthorized:     errors.IsUnauthorized,
+}
+
+// FailToCreateResource implements the GoDoc step
+// - `Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason>`
+// - `Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>'`
+// It validates the fact that the resource creation, without any specific
+// fields, is rejected with the given reason (like AlreadyExists).
+func FailToCreateResource(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsFailures,
+			Expr:  `^Kubernetes fails to create (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' due to (` + RxStatusReason + `)(?: matching '(.*)')?$`,
+			Templates: []string{
+				"Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason>",
+				"Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>'",
+			},
+			Description: "It validates the fact that the resource creation, without any specific fields, is rejected with the given reason (like AlreadyExists).",
+			Examples:    []string{"Kubernetes fails to create v1/Namespace 'default' due to AlreadyExists"},
+		},
+		func(groupVersionKindStr, resourceName, reason, message string) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			err = ctx.Create(groupVersionKind, namespacedName, &unstructured.Unstructured{})
+			return isExpectedFailure(err, "creation", reason, message)
+		},
+	)
+}
+
+// FailToCreateResourceWith implements the GoDoc step
+// - `Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> with <YAML>`
+// - `Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>' with <YAML>`
+// It validates the fact that the resource creation, with the given
+// definition, is rejected with the given reason (like Invalid).
+func FailToCreateResourceWith(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsFailures,
+			Expr:  `^Kubernetes fails to create (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' due to (` + RxStatusReason + `)(?: matching '(.*)')? with$`,
+			Templates: []string{
+				"Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> with <YAML>",
+				"Kubernetes fails to create <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>' with <YAML>",
+			},
+			Description: "It validates the fact that the resource creation, with the given definition, is rejected with the given reason (like Invalid).",
+			Examples:    []string{"Kubernetes fails to create example.com/v1/Widget 'default/widget' due to Invalid matching 'replicas' with"},
+		},
+		func(groupVersionKindStr, resourceName, reason, message string, yamlObj helpers.YamlDocString) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			obj, err := helpers.UnmarshalYamlDocString(yamlObj)
+			if err != nil {
+				return err
+			}
+
+			err = ctx.Create(groupVersionKind, namespacedName, &unstructured.Unstructured{Object: obj})
+			return isExpectedFailure(err, "creation", reason, message)
+		},
+	)
+}
+
+// FailToPatchResource implements the GoDoc step
+// - `Kubernetes fails to patch <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> with <YAML>`
+// - `Kubernetes fails to patch <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>' with <YAML>`
+// It validates the fact that the resource patch is rejected with the given
+// reason (like Invalid or NotFound).
+func FailToPatchResource(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsFailures,
+			Expr:  `^Kubernetes fails to patch (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' due to (` + RxStatusReason + `)(?: matching '(.*)')? with$`,
+			Templates: []string{
+				"Kubernetes fails to patch <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> with <YAML>",
+				"Kubernetes fails to patch <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>' with <YAML>",
+			},
+			Description: "It validates the fact that the resource patch is rejected with the given reason (like Invalid or NotFound).",
+			Examples:    []string{"Kubernetes fails to patch v1/Service 'default/unknown' due to NotFound with"},
+		},
+		func(groupVersionKindStr, resourceName, reason, message string, content helpers.YamlDocString) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			patch, err := helpers.YamlToJson(content.Content)
+			if err != nil {
+				return err
+			}
+
+			err = ctx.Patch(groupVersionKind, namespacedName, types.StrategicMergePatchType, patch)
+			return isExpectedFailure(err, "patch", reason, message)
+		},
+	)
+}
+
+// FailToRemoveResource implements the GoDoc step
+// - `Kubernetes fails to remove <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason>`
+// - `Kubernetes fails to remove <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>'`
+// It validates the fact that the resource removal is rejected with the
+// given reason (like Forbidden or NotFound).
+func FailToRemoveResource(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsFailures,
+			Expr:  `^Kubernetes fails to remove (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' due to (` + RxStatusReason + `)(?: matching '(.*)')?$`,
+			Templates: []string{
+				"Kubernetes fails to remove <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason>",
+				"Kubernetes fails to remove <ApiGroupVersionKind> '<NamespacedName>' due to <StatusReason> matching '<MessageRegex>'",
+			},
+			Description: "It validates the fact that the resource removal is rejected with the given reason (like Forbidden or NotFound).",
+			Examples:    []string{"Kubernetes fails to remove v1/Service 'default/unknown' due to NotFound"},
+		},
+		func(groupVersionKindStr, resourceName, reason, message string) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			_, err = ctx.Delete(groupVersionKind, namespacedName)
+			return isExpectedFailure(err, "removal", reason, message)
+		},
+	)
+}
+
+// isExpectedFailure returns an error if the given error is not an API error
+// with the given reason and a message matching the given expression.
+func isExpectedFailure(err error, operation, reason, message string) error {
+	isReason, known := statusReasons[metav1.StatusReason(reason)]
+	if !known {
+		return fmt.Errorf("unknown failure reason '%s'", reason)
+	}
+
+	var rx *regexp.Regexp
+	if message != "" {
+		var rxErr error
+		if rx, rxErr = regexp.Compile(message); rxErr != nil {
+			return fmt.Errorf("invalid message expression '%s': %w", message, rxErr)
+		}
+	}
+
+	switch {
+	case err == nil:
+		return fmt.Errorf("%s not rejected, but %s was expected", operation, reason)
+	case !isReason(err):
+		return fmt.Errorf("%s rejected, but not due to %s: %w", operation, reason, err)
+	case rx != nil && !rx.MatchString(err.Error()):
+		return fmt.Errorf("%s rejected due to %s, but its message doesn't match '%s': %w", operation, reason, message, err)
+	}
+	return nil
+}
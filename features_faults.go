@@ -0,0 +1,82 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// RxVerb matches the verb of a request, optionally in the plural form.
+const RxVerb = `(get|list|create|update|patch|delete|deletecollection)(?:e?s)?`
+
+// InjectRequestFault implements the GoDoc step
+// - `Kubernetes fails the next <N> <Verb> of <ApiGroupVersionKind> with '<StatusReason>'`
+// - `Kubernetes fails the next <N> <Verb> of <ApiGroupVersionKind> '<NamespacedName>' with '<StatusReason>'`
+// - `Kubernetes fails the next <N> <Verb> of <ApiGroupVersionKind> in namespace '<Namespace>' with latency <Duration>`
+// It injects an error (or a latency) on the next requests matching the given
+// verb, kind, name or namespace; at least 1 request must be failed. The
+// fault injection must be enabled with WithFaultInjection.
+func InjectRequestFault(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsFaults,
+			Expr:  `^Kubernetes fails the next (\d+) ` + RxVerb + ` of (` + RxGroupVersionKind + `)(?: '(` + RxNamespacedName + `)')?(?: in namespace '(` + RxDNSChar + `+)')? with (?:'(` + RxStatusReason + `)'|latency (.+))$`,
+			Templates: []string{
+				"Kubernetes fails the next <N> <Verb> of <ApiGroupVersionKind> with '<StatusReason>'",
+				"Kubernetes fails the next <N> <Verb> of <ApiGroupVersionKind> '<NamespacedName>' with '<StatusReason>'",
+				"Kubernetes fails the next <N> <Verb> of <ApiGroupVersionKind> in namespace '<Namespace>' with latency <Duration>",
+			},
+			Description: "It injects an error (or a latency) on the next requests matching the given verb, kind, name or namespace; at least 1 request must be failed. The fault injection must be enabled with WithFaultInjection.",
+			Examples: []string{
+				"Kubernetes fails the next 2 update of apps/v1/Deployment with 'Conflict'",
+				"Kubernetes fails the next 1 get of v1/Service 'default/kubernetes' with latency 500ms",
+			},
+		},
+		func(n int, verb, groupVersionKindStr, name, namespace, reason, latencyStr string) error {
+			// NOTE: a fault without Times affects all requests
+			if n == 0 {
+				return fmt.Errorf("at least 1 request must be failed")
+			}
+
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+
+			fault := Fault{Verb: verb, GroupVersionKind: groupVersionKind, Namespace: namespace, Times: n, Reason: metav1.StatusReason(reason)}
+			if name != "" {
+				namespacedName, _ := helpers.NamespacedNameFrom(name)
+				fault.Namespace, fault.Name = namespacedName.Namespace, namespacedName.Name
+			}
+			if latencyStr != "" {
+				if fault.Latency, err = time.ParseDuration(latencyStr); err != nil {
+					return err
+				}
+			}
+
+			return ctx.InjectFault(fault)
+		},
+	)
+}
+
+// ClearRequestFaults implements the GoDoc step
+// - `Kubernetes no longer fails requests`
+// It removes all injected faults.
+func ClearRequestFaults(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsFaults,
+			Expr:        `^Kubernetes no longer fails requests$`,
+			Templates:   []string{"Kubernetes no longer fails requests"},
+			Description: "It removes all injected faults.",
+			Examples:    []string{"Kubernetes no longer fails requests"},
+		},
+		func() error {
+			ctx.ClearFaults()
+			return nil
+		},
+	)
+}
@@ -15,16 +15,22 @@ import (
 // - `Kubernetes has <ApiGroupVersionKind> '<NamespacedName>'`
 // It validates the fact that Kubernetes has the specified resource.
 func ResourceExists(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes has (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes has (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes has <ApiGroupVersionKind> '<NamespacedName>'"},
+			Description: "It validates the fact that Kubernetes has the specified resource.",
+			Examples:    []string{"Kubernetes has v1/Namespace 'kube-lease'", "Kubernetes has v1/Service 'kube-lease/svc'"},
+		},
 		func(groupVersionKindStr, name string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
 			namespacedName, _ := helpers.NamespacedNameFrom(name)
 
-			_, err = ctx.Get(groupVersionKind, namespacedName)
+			_, err = ctx.get(groupVersionKind, namespacedName)
 			return err
 		},
 	)
@@ -34,16 +40,22 @@ func ResourceExists(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes doesn't have <ApiGroupVersionKind> '<NamespacedName>'`
 // It validates the fact that Kubernetes doesn't have the specified resource.
 func ResourceNotExists(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes doesn't have (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes doesn't have (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes doesn't have <ApiGroupVersionKind> '<NamespacedName>'"},
+			Description: "It validates the fact that Kubernetes doesn't have the specified resource.",
+			Examples:    []string{"Kubernetes doesn't have example.com/v1/Widget 'default/widget'", "Kubernetes doesn't have v1/Service 'default/default'"},
+		},
 		func(groupVersionKindStr, name string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
 			namespacedName, _ := helpers.NamespacedNameFrom(name)
 
-			_, err = ctx.Get(groupVersionKind, namespacedName)
+			_, err = ctx.get(groupVersionKind, namespacedName)
 			switch {
 			case errors.IsNotFound(err):
 				return nil
@@ -62,8 +74,14 @@ func ResourceNotExists(ctx *FeatureContext, s ScenarioContext) {
 //
 // NOTE: Two resources are similar if all fields except 'medatata' are the same.
 func ResourceIsSimilarTo(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' is similar to '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' is similar to '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' is similar to '<NamespacedName>'"},
+			Description: "It compares two resources in order to determine if they are similar.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-public' is similar to 'kube-system'"},
+		},
 		func(groupVersionKindStr, lname, rname string) (err error) {
 			lobj, err := getWithoutMetadata(ctx, groupVersionKindStr, lname)
 			if err != nil {
@@ -91,8 +109,14 @@ func ResourceIsSimilarTo(ctx *FeatureContext, s ScenarioContext) {
 //
 // NOTE: Two resources are similar if all fields except 'medatata' are the same.
 func ResourceIsNotSimilarTo(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' is not similar to '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' is not similar to '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' is not similar to '<NamespacedName>'"},
+			Description: "It compares two resources in order to determine if they are not similar.",
+			Examples:    []string{"Kubernetes resource v1/Service 'default/default' is not similar to 'default/kubernetes'"},
+		},
 		func(groupVersionKindStr, lname, rname string) (err error) {
 			lobj, err := getWithoutMetadata(ctx, groupVersionKindStr, lname)
 			if err != nil {
@@ -115,14 +139,14 @@ func ResourceIsNotSimilarTo(ctx *FeatureContext, s ScenarioContext) {
 
 // getWithoutMetadata returns resource without metadata field.
 func getWithoutMetadata(ctx *FeatureContext, groupVersionKindStr, name string) (*unstructured.Unstructured, error) {
-	groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+	groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 	if err != nil {
 		return nil, err
 	}
 
 	namespacedName, _ := helpers.NamespacedNameFrom(name)
 
-	obj, err := ctx.Get(groupVersionKind, namespacedName)
+	obj, err := ctx.get(groupVersionKind, namespacedName)
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +162,14 @@ func getWithoutMetadata(ctx *FeatureContext, groupVersionKindStr, name string) (
 // NOTE: Two resources are equal if all fields except unique fields ('metadata.name',
 //       'metadata.namespace', 'metadata.uid' and 'metadata.resourceVersion') are the same.
 func ResourceIsEqualTo(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' is equal to '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' is equal to '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' is equal to '<NamespacedName>'"},
+			Description: "It compares two resources in order to determine if they are equal.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'default' is equal to 'kube-public'"},
+		},
 		func(groupVersionKindStr, lname, rname string) (err error) {
 			lobj, err := getWithoutUniqueFields(ctx, groupVersionKindStr, lname)
 			if err != nil {
@@ -168,8 +198,14 @@ func ResourceIsEqualTo(ctx *FeatureContext, s ScenarioContext) {
 // NOTE: Two resources are equal if all fields except unique fields ('metadata.name',
 //       'metadata.namespace', 'metadata.uid' and 'metadata.resourceVersion') are the same.
 func ResourceIsNotEqualTo(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' is not equal to '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' is not equal to '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' is not equal to '<NamespacedName>'"},
+			Description: "It compares two resources in order to determine if they are not equal.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-public' is not equal to 'kube-system'"},
+		},
 		func(groupVersionKindStr, lname, rname string) (err error) {
 			lobj, err := getWithoutUniqueFields(ctx, groupVersionKindStr, lname)
 			if err != nil {
@@ -193,14 +229,14 @@ func ResourceIsNotEqualTo(ctx *FeatureContext, s ScenarioContext) {
 // getWithoutUniqFields returns resources without unique fields ('metadata.name',
 // 'metadata.namespace', 'metadata.uid' and 'metadata.resourceVersion').
 func getWithoutUniqueFields(ctx *FeatureContext, groupVersionKindStr, name string) (*unstructured.Unstructured, error) {
-	groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+	groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 	if err != nil {
 		return nil, err
 	}
 
 	namespacedName, _ := helpers.NamespacedNameFrom(name)
 
-	obj, err := ctx.Get(groupVersionKind, namespacedName)
+	obj, err := ctx.get(groupVersionKind, namespacedName)
 	if err != nil {
 		return nil, err
 	}
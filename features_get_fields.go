@@ -12,8 +12,14 @@ import (
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has '<FieldPath>'`
 // It validates the fact that the specific resource has the field.
 func ResourceHasField(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' has '(`+RxFieldPath+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' has '(` + RxFieldPath + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has '<FieldPath>'"},
+			Description: "It validates the fact that the specific resource has the field.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' has 'metadata.name'", "Kubernetes resource v1/Pod 'default/pod' has 'status.podIP'"},
+		},
 		func(groupVersionKindStr, name, field string) (err error) {
 			_, exists, err := getResourceField(ctx, groupVersionKindStr, name, field)
 			switch {
@@ -31,8 +37,14 @@ func ResourceHasField(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have '<FieldPath>'`
 // It validates the fact that the specific resource doesn't have the field.
 func ResourceDoesntHaveField(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' doesn't have '(`+RxFieldPath+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' doesn't have '(` + RxFieldPath + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have '<FieldPath>'"},
+			Description: "It validates the fact that the specific resource doesn't have the field.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' doesn't have 'metadata.annotations.oops'"},
+		},
 		func(groupVersionKindStr, name, field string) (err error) {
 			_, exists, err := getResourceField(ctx, groupVersionKindStr, name, field)
 			switch {
@@ -50,8 +62,14 @@ func ResourceDoesntHaveField(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has '<FieldPath>=<FieldValue>'`
 // It validates the fact that the specific resource field has the given value.
 func ResourceHasFieldEqual(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' has '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' has '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has '<FieldPath>=<FieldValue>'"},
+			Description: "It validates the fact that the specific resource field has the given value.",
+			Examples:    []string{"Kubernetes resource v1/Service 'kube-lease/svc-lb' has 'spec.type=LoadBalancer'", "Kubernetes resource batch/v1beta1/CronJob 'default/backup' has 'status.lastScheduleTime=2024-01-01T00:05:00Z'"},
+		},
 		func(groupVersionKindStr, name, field, value string) (err error) {
 			rval, exists, err := getResourceField(ctx, groupVersionKindStr, name, field)
 			switch {
@@ -68,11 +86,17 @@ func ResourceHasFieldEqual(ctx *FeatureContext, s ScenarioContext) {
 }
 
 // ResourceHasFieldNotEqual implements the GoDoc step
-// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has '<FieldPath>!=<FieldValue>'`
+// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have '<FieldPath>=<FieldValue>'`
 // It validates the fact that the specific resource field is different than the given value.
 func ResourceHasFieldNotEqual(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' doesn't have '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' doesn't have '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have '<FieldPath>=<FieldValue>'"},
+			Description: "It validates the fact that the specific resource field is different than the given value.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' doesn't have 'metadata.annotations.key=error'", "Kubernetes resource v1/Service 'default/kubernetes' doesn't have 'spec.type=LoadBalancer'"},
+		},
 		func(groupVersionKindStr, name, field, value string) (err error) {
 			rval, exists, err := getResourceField(ctx, groupVersionKindStr, name, field)
 			switch {
@@ -90,13 +114,13 @@ func ResourceHasFieldNotEqual(ctx *FeatureContext, s ScenarioContext) {
 
 // getResourceField returns the resource field value and if it exists.
 func getResourceField(ctx *FeatureContext, groupVersionKindStr, name, field string) (string, bool, error) {
-	groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+	groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 	if err != nil {
 		return "", false, err
 	}
 	namespacedName, _ := helpers.NamespacedNameFrom(name)
 
-	obj, err := ctx.Get(groupVersionKind, namespacedName)
+	obj, err := ctx.get(groupVersionKind, namespacedName)
 	if err != nil {
 		return "", false, err
 	}
@@ -116,8 +140,14 @@ func getResourceField(ctx *FeatureContext, groupVersionKindStr, name, field stri
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has label '<LabelName>'`
 // It validates the fact that the specific resource has the given label.
 func ResourceHasLabel(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' has label '(`+RxFieldPath+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' has label '(` + RxFieldPath + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has label '<LabelName>'"},
+			Description: "It validates the fact that the specific resource has the given label.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' has label 'key'"},
+		},
 		func(groupVersionKindStr, name, label string) (err error) {
 			_, exists, err := getResourceLabel(ctx, groupVersionKindStr, name, label)
 			switch {
@@ -135,8 +165,14 @@ func ResourceHasLabel(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have label '<LabelName>'`
 // It validates the fact that the specific resource doesn't have the given label.
 func ResourceDoesntHaveLabel(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' doesn't have label '(`+RxFieldPath+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' doesn't have label '(` + RxFieldPath + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have label '<LabelName>'"},
+			Description: "It validates the fact that the specific resource doesn't have the given label.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' doesn't have label 'oops'", "Kubernetes resource v1/Service 'default/default' doesn't have label 'key'"},
+		},
 		func(groupVersionKindStr, name, label string) (err error) {
 			_, exists, err := getResourceLabel(ctx, groupVersionKindStr, name, label)
 			switch {
@@ -154,8 +190,14 @@ func ResourceDoesntHaveLabel(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has label '<LabelName>=<LabelValue>'`
 // It validates the fact that the specific resource label has the given value.
 func ResourceHasLabelEqual(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' has label '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' has label '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has label '<LabelName>=<LabelValue>'"},
+			Description: "It validates the fact that the specific resource label has the given value.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' has label 'key=value'", "Kubernetes resource v1/Service 'default/default' has label 'key=value'"},
+		},
 		func(groupVersionKindStr, name, label, value string) (err error) {
 			rval, exists, err := getResourceLabel(ctx, groupVersionKindStr, name, label)
 			switch {
@@ -172,11 +214,17 @@ func ResourceHasLabelEqual(ctx *FeatureContext, s ScenarioContext) {
 }
 
 // ResourceHasLabelNotEqual implements the GoDoc step
-// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has label '<LabelName>!=<LabelValue>'`
+// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have label '<LabelName>=<LabelValue>'`
 // It validates the fact that the specific resource label doesn't have the given value.
 func ResourceHasLabelNotEqual(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' doesn't have label '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' doesn't have label '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have label '<LabelName>=<LabelValue>'"},
+			Description: "It validates the fact that the specific resource label doesn't have the given value.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' doesn't have label 'oops=error'", "Kubernetes resource v1/Namespace 'kube-system' doesn't have label 'key=error'"},
+		},
 		func(groupVersionKindStr, name, label, value string) (err error) {
 			rval, exists, err := getResourceLabel(ctx, groupVersionKindStr, name, label)
 			switch {
@@ -194,13 +242,13 @@ func ResourceHasLabelNotEqual(ctx *FeatureContext, s ScenarioContext) {
 
 // getResourceLabel returns the resource label value and if it exists.
 func getResourceLabel(ctx *FeatureContext, groupVersionKindStr, name, label string) (string, bool, error) {
-	groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+	groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 	if err != nil {
 		return "", false, err
 	}
 	namespacedName, _ := helpers.NamespacedNameFrom(name)
 
-	obj, err := ctx.Get(groupVersionKind, namespacedName)
+	obj, err := ctx.get(groupVersionKind, namespacedName)
 	if err != nil {
 		return "", false, err
 	}
@@ -218,8 +266,14 @@ func getResourceLabel(ctx *FeatureContext, groupVersionKindStr, name, label stri
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has annotation '<AnnotationName>'`
 // It validates the fact that the specific resource has the given annotation.
 func ResourceHasAnnotation(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' has annotation '(`+RxFieldPath+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' has annotation '(` + RxFieldPath + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has annotation '<AnnotationName>'"},
+			Description: "It validates the fact that the specific resource has the given annotation.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' has annotation 'key'"},
+		},
 		func(groupVersionKindStr, name, annotation string) (err error) {
 			_, exists, err := getResourceAnnotation(ctx, groupVersionKindStr, name, annotation)
 			switch {
@@ -237,8 +291,14 @@ func ResourceHasAnnotation(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have annotation '<AnnotationName>'`
 // It validates the fact that the specific resource doesn't have the given annotation.
 func ResourceDoesntHaveAnnotation(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' doesn't have annotation '(`+RxFieldPath+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' doesn't have annotation '(` + RxFieldPath + `)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have annotation '<AnnotationName>'"},
+			Description: "It validates the fact that the specific resource doesn't have the given annotation.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' doesn't have annotation 'oops'", "Kubernetes resource v1/Service 'default/default' doesn't have annotation 'key'"},
+		},
 		func(groupVersionKindStr, name, annotation string) (err error) {
 			_, exists, err := getResourceAnnotation(ctx, groupVersionKindStr, name, annotation)
 			switch {
@@ -256,8 +316,14 @@ func ResourceDoesntHaveAnnotation(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has annotation '<AnnotationName>=<AnnotationValue>'`
 // It validates the fact that the specific resource annotation has the given value.
 func ResourceHasAnnotationEqual(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' has annotation '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' has annotation '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has annotation '<AnnotationName>=<AnnotationValue>'"},
+			Description: "It validates the fact that the specific resource annotation has the given value.",
+			Examples:    []string{"Kubernetes resource v1/Service 'kube-lease/svc' has annotation 'key=value'", "Kubernetes resource v1/Namespace 'kube-system' has annotation 'key=value'"},
+		},
 		func(groupVersionKindStr, name, annotation, value string) (err error) {
 			rval, exists, err := getResourceAnnotation(ctx, groupVersionKindStr, name, annotation)
 			switch {
@@ -274,11 +340,17 @@ func ResourceHasAnnotationEqual(ctx *FeatureContext, s ScenarioContext) {
 }
 
 // ResourceHasAnnotationNotEqual implements the GoDoc step
-// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' has annotation '<AnnotationName>!=<AnnotationValue>'`
+// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have annotation '<AnnotationName>=<AnnotationValue>'`
 // It validates the fact that the specific resource annotation doesn't have the given value.
 func ResourceHasAnnotationNotEqual(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes resource (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' doesn't have annotation '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' doesn't have annotation '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' doesn't have annotation '<AnnotationName>=<AnnotationValue>'"},
+			Description: "It validates the fact that the specific resource annotation doesn't have the given value.",
+			Examples:    []string{"Kubernetes resource v1/Namespace 'kube-system' doesn't have annotation 'oops=error'", "Kubernetes resource v1/Namespace 'kube-system' doesn't have annotation 'key=error'"},
+		},
 		func(groupVersionKindStr, name, annotation, value string) (err error) {
 			rval, exists, err := getResourceAnnotation(ctx, groupVersionKindStr, name, annotation)
 			switch {
@@ -296,13 +368,13 @@ func ResourceHasAnnotationNotEqual(ctx *FeatureContext, s ScenarioContext) {
 
 // getResourceAnnotation returns the resource annotation value and if it exists.
 func getResourceAnnotation(ctx *FeatureContext, groupVersionKindStr, name, annotation string) (string, bool, error) {
-	groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+	groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 	if err != nil {
 		return "", false, err
 	}
 	namespacedName, _ := helpers.NamespacedNameFrom(name)
 
-	obj, err := ctx.Get(groupVersionKind, namespacedName)
+	obj, err := ctx.get(groupVersionKind, namespacedName)
 	if err != nil {
 		return "", false, err
 	}
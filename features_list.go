@@ -2,10 +2,14 @@ package kubernetes_ctx
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/thoas/go-funk"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/xunleii/godog-kubernetes/helpers"
@@ -15,15 +19,21 @@ import (
 // - `Kubernetes has <NumberResources> <ApiGroupVersionKind>`
 // It compare the current number of a specific resource with the given number.
 func CountResources(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes has (\d+) (`+RxGroupVersionKind+`)$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes has (\d+) (` + RxGroupVersionKind + `)$`,
+			Templates:   []string{"Kubernetes has <NumberResources> <ApiGroupVersionKind>"},
+			Description: "It compare the current number of a specific resource with the given number.",
+			Examples:    []string{"Kubernetes has 3 v1/Namespace"},
+		},
 		func(n int, groupVersionKindStr string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
 
-			objs, err := ctx.List(groupVersionKind)
+			objs, err := ctx.list(groupVersionKind)
 			if err != nil {
 				return err
 			}
@@ -50,15 +60,21 @@ func CountResources(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes has <NumberResources> <ApiGroupVersionKind> in namespace '<Namespace>'`
 // It compare the current number of a specific resource with the given number.
 func CountNamespacedResources(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes has (\d+) (`+RxGroupVersionKind+`) in namespace '(`+RxDNSChar+`+)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes has (\d+) (` + RxGroupVersionKind + `) in namespace '(` + RxDNSChar + `+)'$`,
+			Templates:   []string{"Kubernetes has <NumberResources> <ApiGroupVersionKind> in namespace '<Namespace>'"},
+			Description: "It compare the current number of a specific resource with the given number.",
+			Examples:    []string{"Kubernetes has 0 batch/v1/Job in namespace 'default'", "Kubernetes has 1 batch/v1/Job in namespace 'default'"},
+		},
 		func(n int, groupVersionKindStr, namespace string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
 
-			objs, err := ctx.List(groupVersionKind, &client.ListOptions{Namespace: namespace})
+			objs, err := ctx.list(groupVersionKind, &client.ListOptions{Namespace: namespace})
 			if err != nil {
 				return err
 			}
@@ -77,3 +93,97 @@ func CountNamespacedResources(ctx *FeatureContext, s ScenarioContext) {
 		},
 	)
 }
+
+// OnlyNamespacedResources implements the GoDoc step
+// - `Kubernetes has only the following resources in namespace '<Namespace>' <RESOURCES_TABLE>`
+// It validates the fact that the namespace contains exactly the given
+// resources, looking at all namespaced kinds known by the scheme. Rows
+// without namespace target the given one; rows on another namespace or on a
+// cluster-scoped kind are rejected. Resources served by several API groups
+// (like v1/Event and events.k8s.io/v1beta1/Event) must be given once per
+// group.
+func OnlyNamespacedResources(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsAssert,
+			Expr:        `^Kubernetes has only the following resources in namespace '(` + RxDNSChar + `+)'$`,
+			Templates:   []string{"Kubernetes has only the following resources in namespace '<Namespace>' <RESOURCES_TABLE>"},
+			Description: "It validates the fact that the namespace contains exactly the given resources, looking at all namespaced kinds known by the scheme. Rows without namespace target the given one; rows on another namespace or on a cluster-scoped kind are rejected. Resources served by several API groups (like v1/Event and events.k8s.io/v1beta1/Event) must be given once per group.",
+			Examples:    []string{"Kubernetes has only the following resources in namespace 'default'"},
+		},
+		func(namespace string, table helpers.ResourceTable) error {
+			resources, err := helpers.UnmarshalResourceTable(table)
+			if err != nil {
+				return err
+			}
+
+			// NOTE: resources are identified by their group and kind, in
+			//       order to ignore the version used to list them
+			expected := map[string]string{}
+			for _, resource := range resources {
+				groupVersionKind, err := ctx.ResolveGroupVersionKind(resource.GroupVersion + "/" + resource.Kind)
+				if err != nil {
+					return err
+				}
+				mapping, err := ctx.restMapping(groupVersionKind)
+				if err != nil {
+					return err
+				}
+				if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+					return fmt.Errorf("%s/%s '%s' is cluster-scoped and can't be expected in namespace '%s'",
+						groupVersionKind.GroupVersion(), groupVersionKind.Kind, resource.Name, namespace)
+				}
+				if resource.Namespace == "" {
+					resource.Namespace = namespace
+				}
+				namespacedName := resource.NamespacedName()
+				if resource.Namespace != namespace {
+					return fmt.Errorf("%s is not in namespace '%s'", describeResource(groupVersionKind, namespacedName), namespace)
+				}
+				expected[resourceKey(groupVersionKind.GroupKind(), namespacedName)] = describeResource(groupVersionKind, namespacedName)
+			}
+
+			objs, err := ctx.listAll(client.InNamespace(namespace))
+			if err != nil {
+				return err
+			}
+
+			var unexpected, missing []string
+			found := map[string]bool{}
+			for _, obj := range objs {
+				groupVersionKind := obj.GroupVersionKind()
+				namespacedName := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+				key := resourceKey(groupVersionKind.GroupKind(), namespacedName)
+
+				found[key] = true
+				if _, exists := expected[key]; !exists {
+					unexpected = append(unexpected, describeResource(groupVersionKind, namespacedName))
+				}
+			}
+			for key, description := range expected {
+				if !found[key] {
+					missing = append(missing, description)
+				}
+			}
+
+			if len(unexpected) == 0 && len(missing) == 0 {
+				return nil
+			}
+			sort.Strings(unexpected)
+			sort.Strings(missing)
+			return fmt.Errorf("namespace '%s' doesn't have only the expected resources (unexpected: [%s], missing: [%s])",
+				namespace, strings.Join(unexpected, ", "), strings.Join(missing, ", "))
+		},
+	)
+}
+
+// resourceKey returns the key identifying a resource, whatever its version.
+func resourceKey(groupKind schema.GroupKind, namespacedName types.NamespacedName) string {
+	return fmt.Sprintf("%s '%s'", groupKind, namespacedName)
+}
+
+// describeResource returns a short description of a resource, like
+// `apps/v1/Deployment 'default/nginx'`.
+func describeResource(groupVersionKind schema.GroupVersionKind, namespacedName types.NamespacedName) string {
+	return fmt.Sprintf("%s/%s '%s'", groupVersionKind.GroupVersion(), groupVersionKind.Kind, namespacedName)
+}
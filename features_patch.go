@@ -12,10 +12,16 @@ import (
 // see https://github.com/kubernetes/community/blob/master/contributors/devel/sig-api-machinery/strategic-merge-patch.md
 // for more information).
 func PatchResourceWith(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes patches (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' with$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsPatch,
+			Expr:        `^Kubernetes patches (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' with$`,
+			Templates:   []string{"Kubernetes patches <ApiGroupVersionKind> '<NamespacedName>' with <YAML>"},
+			Description: "It patches a specific resource with the given patch (it use StrategicMergePatchType... see https://github.com/kubernetes/community/blob/master/contributors/devel/sig-api-machinery/strategic-merge-patch.md for more information).",
+			Examples:    []string{"Kubernetes patches batch/v1beta1/CronJob 'default/backup' with", "Kubernetes patches example.com/v1/Widget 'default/widget' with"},
+		},
 		func(groupVersionKindStr, resourceName string, content helpers.YamlDocString) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -12,10 +12,16 @@ import (
 // - `Kubernetes labelizes <ApiGroupVersionKind> '<NamespacedName>' with '<LabelName>=<LabelValue>'`
 // It adds or modifies a specific resource label with the given value.
 func LabelizeResource(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes labelizes (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' with '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes labelizes (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' with '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes labelizes <ApiGroupVersionKind> '<NamespacedName>' with '<LabelName>=<LabelValue>'"},
+			Description: "It adds or modifies a specific resource label with the given value.",
+			Examples:    []string{"Kubernetes labelizes v1/Service 'default/default' with 'key=value'"},
+		},
 		func(groupVersionKindStr, resourceName, labelName, labelValue string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -28,13 +34,19 @@ func LabelizeResource(ctx *FeatureContext, s ScenarioContext) {
 }
 
 // RemoveResourceLabel implements the GoDoc step
-// - `Kubernetes removes label <LabelName> on <ApiGroupVersionKind> '<NamespacedName>'`
+// - `Kubernetes removes label '<LabelName>' on <ApiGroupVersionKind> '<NamespacedName>'`
 // It removes the given label on the specified resource.
 func RemoveResourceLabel(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes removes label '(`+RxFieldPath+`)' on (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes removes label '(` + RxFieldPath + `)' on (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes removes label '<LabelName>' on <ApiGroupVersionKind> '<NamespacedName>'"},
+			Description: "It removes the given label on the specified resource.",
+			Examples:    []string{"Kubernetes removes label 'key' on v1/Service 'default/kubernetes'"},
+		},
 		func(label, groupVersionKindStr, resourceName string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -47,13 +59,19 @@ func RemoveResourceLabel(ctx *FeatureContext, s ScenarioContext) {
 }
 
 // UpdateResourceLabel implements the GoDoc step
-// - `Kubernetes updates label <LabelName> on <ApiGroupVersionKind> '<NamespacedName>' with '<LabelValue>'`
+// - `Kubernetes updates label '<LabelName>' on <ApiGroupVersionKind> '<NamespacedName>' with '<LabelValue>'`
 // It updates the given label on the specified resource with the given value.
 func UpdateResourceLabel(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes updates label '(`+RxFieldPath+`)' on (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' with '(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes updates label '(` + RxFieldPath + `)' on (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' with '(.*)'$`,
+			Templates:   []string{"Kubernetes updates label '<LabelName>' on <ApiGroupVersionKind> '<NamespacedName>' with '<LabelValue>'"},
+			Description: "It updates the given label on the specified resource with the given value.",
+			Examples:    []string{"Kubernetes updates label 'key' on v1/Service 'default/kubernetes' with 'error'"},
+		},
 		func(label, groupVersionKindStr, resourceName, value string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -69,10 +87,16 @@ func UpdateResourceLabel(ctx *FeatureContext, s ScenarioContext) {
 // - `Kubernetes annotates <ApiGroupVersionKind> '<NamespacedName>' with '<AnnotationName>=<AnnotationValue>'`
 // It adds or modifies a specific resource annotation with the given value.
 func AnnotateResource(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes annotates (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' with '(`+RxFieldPath+`)=(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes annotates (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' with '(` + RxFieldPath + `)=(.*)'$`,
+			Templates:   []string{"Kubernetes annotates <ApiGroupVersionKind> '<NamespacedName>' with '<AnnotationName>=<AnnotationValue>'"},
+			Description: "It adds or modifies a specific resource annotation with the given value.",
+			Examples:    []string{"Kubernetes annotates v1/Service 'default/default' with 'key=value'"},
+		},
 		func(groupVersionKindStr, resourceName, annotationName, annotationValue string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -88,13 +112,19 @@ func AnnotateResource(ctx *FeatureContext, s ScenarioContext) {
 }
 
 // RemoveResourceAnnotation implements the GoDoc step
-// - `Kubernetes removes annotation <AnnotationName> on <ApiGroupVersionKind> '<NamespacedName>'`
+// - `Kubernetes removes annotation '<AnnotationName>' on <ApiGroupVersionKind> '<NamespacedName>'`
 // It removes the given annotation on the specified resource.
 func RemoveResourceAnnotation(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes removes annotation '(`+RxFieldPath+`)' on (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes removes annotation '(` + RxFieldPath + `)' on (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes removes annotation '<AnnotationName>' on <ApiGroupVersionKind> '<NamespacedName>'"},
+			Description: "It removes the given annotation on the specified resource.",
+			Examples:    []string{"Kubernetes removes annotation 'key' on v1/Service 'default/kubernetes'"},
+		},
 		func(annotation, groupVersionKindStr, resourceName string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -110,13 +140,19 @@ func RemoveResourceAnnotation(ctx *FeatureContext, s ScenarioContext) {
 }
 
 // UpdateResourceAnnotation implements the GoDoc step
-// - `Kubernetes updates annotation <AnnotationName> on <ApiGroupVersionKind> '<NamespacedName>' with '<AnnotationValue>'`
+// - `Kubernetes updates annotation '<AnnotationName>' on <ApiGroupVersionKind> '<NamespacedName>' with '<AnnotationValue>'`
 // It updates the given annotation on the specified resource with the given value.
 func UpdateResourceAnnotation(ctx *FeatureContext, s ScenarioContext) {
-	s.Step(
-		`^Kubernetes updates annotation '(`+RxFieldPath+`)' on (`+RxGroupVersionKind+`) '(`+RxNamespacedName+`)' with '(.*)'$`,
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsLabels,
+			Expr:        `^Kubernetes updates annotation '(` + RxFieldPath + `)' on (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' with '(.*)'$`,
+			Templates:   []string{"Kubernetes updates annotation '<AnnotationName>' on <ApiGroupVersionKind> '<NamespacedName>' with '<AnnotationValue>'"},
+			Description: "It updates the given annotation on the specified resource with the given value.",
+			Examples:    []string{"Kubernetes updates annotation 'key' on v1/Service 'default/kubernetes' with 'error'"},
+		},
 		func(annotation, groupVersionKindStr, resourceName, value string) error {
-			groupVersionKind, err := helpers.GroupVersionKindFrom(groupVersionKindStr)
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,50 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// PodBecomesReady implements the GoDoc step
+// - `Kubernetes pod '<NamespacedName>' becomes ready`
+// It starts all containers of the given pod and marks it as ready, like
+// the kubelet does.
+func PodBecomesReady(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsPods,
+			Expr:        `^Kubernetes pod '(` + RxNamespacedName + `)' becomes ready$`,
+			Templates:   []string{"Kubernetes pod '<NamespacedName>' becomes ready"},
+			Description: "It starts all containers of the given pod and marks it as ready, like the kubelet does.",
+			Examples:    []string{"Kubernetes pod 'default/pod' becomes ready", "Kubernetes pod 'default/web-1' becomes ready"},
+		},
+		func(name string) error {
+			namespacedName, _ := helpers.NamespacedNameFrom(name)
+			return ctx.MakePodReady(namespacedName)
+		},
+	)
+}
+
+// PodContainerTerminates implements the GoDoc step
+// - `Kubernetes pod '<NamespacedName>' container '<Container>' terminates with exit code <ExitCode>`
+// It terminates the given container, which is restarted following the pod
+// restart policy, like the kubelet does.
+func PodContainerTerminates(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsPods,
+			Expr:        `^Kubernetes pod '(` + RxNamespacedName + `)' container '(` + RxDNSChar + `+)' terminates with exit code (\d+)$`,
+			Templates:   []string{"Kubernetes pod '<NamespacedName>' container '<Container>' terminates with exit code <ExitCode>"},
+			Description: "It terminates the given container, which is restarted following the pod restart policy, like the kubelet does.",
+			Examples:    []string{"Kubernetes pod 'default/pod' container 'app' terminates with exit code 1"},
+		},
+		func(name, container string, exitCode int) error {
+			namespacedName, _ := helpers.NamespacedNameFrom(name)
+			if exitCode > 255 {
+				return fmt.Errorf("invalid exit code %d", exitCode)
+			}
+			return ctx.TerminatePodContainer(namespacedName, container, int32(exitCode))
+		},
+	)
+}
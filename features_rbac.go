@@ -0,0 +1,127 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// ActAsUser implements the GoDoc step
+// - `Kubernetes acts as user '<User>'`
+// - `Kubernetes acts as user '<User>' in groups '<Group1,Group2>'`
+// It sends all following requests as the given user, which must be
+// allowed by the RBAC rules.
+func ActAsUser(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsRBAC,
+			Expr:        `^Kubernetes acts as user '([^']+)'(?: in groups '([^']*)')?$`,
+			Templates:   []string{"Kubernetes acts as user '<User>'", "Kubernetes acts as user '<User>' in groups '<Group1,Group2>'"},
+			Description: "It sends all following requests as the given user, which must be allowed by the RBAC rules.",
+			Examples:    []string{"Kubernetes acts as user 'alice'", "Kubernetes acts as user 'bob' in groups 'system:masters'"},
+		},
+		func(user, groups string) error {
+			ctx.ActAs(user, splitGroups(groups)...)
+			return nil
+		},
+	)
+}
+
+// ActAsServiceAccount implements the GoDoc step
+// - `Kubernetes acts as service account '<NamespacedName>'`
+// It sends all following requests as the given service account, which must
+// be allowed by the RBAC rules.
+func ActAsServiceAccount(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsRBAC,
+			Expr:        `^Kubernetes acts as service account '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes acts as service account '<NamespacedName>'"},
+			Description: "It sends all following requests as the given service account, which must be allowed by the RBAC rules.",
+			Examples:    []string{"Kubernetes acts as service account 'default/reader'"},
+		},
+		func(name string) error {
+			namespacedName, _ := helpers.NamespacedNameFrom(name)
+			if namespacedName.Namespace == "" {
+				return fmt.Errorf("service account '%s' must be namespaced", name)
+			}
+
+			ctx.ActAs(serviceAccountUserName(namespacedName.Namespace, namespacedName.Name))
+			return nil
+		},
+	)
+}
+
+// ActAsClusterAdministrator implements the GoDoc step
+// - `Kubernetes acts as cluster administrator`
+// It stops acting as a specific user; all following requests are no longer
+// restricted by the RBAC rules.
+func ActAsClusterAdministrator(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsRBAC,
+			Expr:        `^Kubernetes acts as cluster administrator$`,
+			Templates:   []string{"Kubernetes acts as cluster administrator"},
+			Description: "It stops acting as a specific user; all following requests are no longer restricted by the RBAC rules.",
+			Examples:    []string{"Kubernetes acts as cluster administrator"},
+		},
+		func() error {
+			ctx.ActAs("")
+			return nil
+		},
+	)
+}
+
+// UserIsAllowed implements the GoDoc step
+// - `User '<User>' can|cannot <Verb> <ApiGroupVersionKind>`
+// - `User '<User>' can|cannot <Verb> <ApiGroupVersionKind> in namespace '<Namespace>'`
+// It validates the fact that the given user is (or isn't) allowed to
+// perform the given verb on a specific resource, cluster wide or inside the
+// given namespace.
+func UserIsAllowed(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsRBAC,
+			Expr:        `^User '([^']+)' (can|cannot) ([a-z]+) (` + RxGroupVersionKind + `)(?: in namespace '(` + RxDNSChar + `+)')?$`,
+			Templates:   []string{"User '<User>' can|cannot <Verb> <ApiGroupVersionKind>", "User '<User>' can|cannot <Verb> <ApiGroupVersionKind> in namespace '<Namespace>'"},
+			Description: "It validates the fact that the given user is (or isn't) allowed to perform the given verb on a specific resource, cluster wide or inside the given namespace.",
+			Examples:    []string{"User 'alice' can get v1/Pod in namespace 'default'", "User 'alice' can list pods in namespace 'default'"},
+		},
+		func(user, can, verb, groupVersionKindStr, namespace string) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+
+			allowed, err := ctx.IsAllowed(user, nil, verb, groupVersionKind, namespace)
+			if err != nil {
+				return err
+			}
+
+			scope := "cluster wide"
+			if namespace != "" {
+				scope = fmt.Sprintf("in namespace '%s'", namespace)
+			}
+
+			switch {
+			case can == "can" && !allowed:
+				return fmt.Errorf("user '%s' cannot %s %s %s", user, verb, groupVersionKindStr, scope)
+			case can == "cannot" && allowed:
+				return fmt.Errorf("user '%s' can %s %s %s", user, verb, groupVersionKindStr, scope)
+			}
+			return nil
+		},
+	)
+}
+
+// splitGroups splits a comma-separated list of groups.
+func splitGroups(groups string) []string {
+	var list []string
+	for _, group := range strings.Split(groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			list = append(list, group)
+		}
+	}
+	return list
+}
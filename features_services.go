@@ -0,0 +1,47 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// ServiceHasEndpoints implements the GoDoc step
+// - `Kubernetes service '<NamespacedName>' has endpoints for pods '<PodNames>'`
+// It compares the ready pods listed in the Endpoints of the given service
+// with the given comma-separated pod names (an empty list means no
+// endpoints).
+func ServiceHasEndpoints(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsServices,
+			Expr:        `^Kubernetes service '(` + RxNamespacedName + `)' has endpoints for pods '([^']*)'$`,
+			Templates:   []string{"Kubernetes service '<NamespacedName>' has endpoints for pods '<PodNames>'"},
+			Description: "It compares the ready pods listed in the Endpoints of the given service with the given comma-separated pod names (an empty list means no endpoints).",
+			Examples:    []string{"Kubernetes service 'default/web' has endpoints for pods 'web-1'"},
+		},
+		func(name, podNames string) error {
+			namespacedName, _ := helpers.NamespacedNameFrom(name)
+
+			var expected []string
+			for _, pod := range strings.Split(podNames, ",") {
+				if pod = strings.TrimSpace(pod); pod != "" {
+					expected = append(expected, pod)
+				}
+			}
+			sort.Strings(expected)
+
+			pods, err := ctx.ServiceEndpoints(namespacedName)
+			if err != nil {
+				return err
+			}
+
+			if strings.Join(pods, ",") != strings.Join(expected, ",") {
+				return fmt.Errorf("service '%s' has endpoints for pods '%s'", namespacedName, strings.Join(pods, ","))
+			}
+			return nil
+		},
+	)
+}
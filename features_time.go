@@ -0,0 +1,51 @@
+package kubernetes_ctx
+
+import "time"
+
+// TimeAdvances implements the GoDoc step
+// - `Time advances by <Duration>`
+// It moves the fake clock of the feature context forward by the given
+// duration (like 30s or 5m), triggering the emulators depending on the time.
+func TimeAdvances(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsTime,
+			Expr:        `^Time advances by (.+)$`,
+			Templates:   []string{"Time advances by <Duration>"},
+			Description: "It moves the fake clock of the feature context forward by the given duration (like 30s or 5m), triggering the emulators depending on the time.",
+			Examples:    []string{"Time advances by 4m", "Time advances by 1m"},
+		},
+		func(durationStr string) error {
+			duration, err := time.ParseDuration(durationStr)
+			if err != nil {
+				return err
+			}
+
+			return ctx.AdvanceTime(duration)
+		},
+	)
+}
+
+// TimeIs implements the GoDoc step
+// - `Time is '<Time>'`
+// It sets the fake clock of the feature context to the given RFC3339 time
+// (like 2024-01-01T00:00:00Z).
+func TimeIs(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsTime,
+			Expr:        `^Time is '([^']+)'$`,
+			Templates:   []string{"Time is '<Time>'"},
+			Description: "It sets the fake clock of the feature context to the given RFC3339 time (like 2024-01-01T00:00:00Z).",
+			Examples:    []string{"Time is '2024-01-01T00:00:00Z'"},
+		},
+		func(timeStr string) error {
+			t, err := time.Parse(time.RFC3339, timeStr)
+			if err != nil {
+				return err
+			}
+
+			return ctx.SetTime(t)
+		},
+	)
+}
@@ -0,0 +1,94 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// CreateResourceViolatingRule implements the GoDoc step
+// - `Kubernetes refuses to create <ApiGroupVersionKind> '<NamespacedName>' due to rule '<RuleMessage>' with <YAML>`
+// It validates the fact that the resource creation is rejected by a
+// x-kubernetes-validations rule, with the given message.
+func CreateResourceViolatingRule(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsValidation,
+			Expr:        `^Kubernetes refuses to create (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' due to rule '(.+)' with$`,
+			Templates:   []string{"Kubernetes refuses to create <ApiGroupVersionKind> '<NamespacedName>' due to rule '<RuleMessage>' with <YAML>"},
+			Description: "It validates the fact that the resource creation is rejected by a x-kubernetes-validations rule, with the given message.",
+			Examples:    []string{"Kubernetes refuses to create example.com/v1/Widget 'default/widget' due to rule 'replicas must be lower than or equal to 10' with"},
+		},
+		func(groupVersionKindStr, resourceName, message string, yamlObj helpers.YamlDocString) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			obj, err := helpers.UnmarshalYamlDocString(yamlObj)
+			if err != nil {
+				return err
+			}
+
+			err = ctx.Create(groupVersionKind, namespacedName, &unstructured.Unstructured{Object: obj})
+			return isRuleViolation(err, message)
+		},
+	)
+}
+
+// PatchResourceViolatingRule implements the GoDoc step
+// - `Kubernetes refuses to patch <ApiGroupVersionKind> '<NamespacedName>' due to rule '<RuleMessage>' with <YAML>`
+// It validates the fact that the resource patch is rejected by a
+// x-kubernetes-validations rule, with the given message.
+func PatchResourceViolatingRule(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsValidation,
+			Expr:        `^Kubernetes refuses to patch (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' due to rule '(.+)' with$`,
+			Templates:   []string{"Kubernetes refuses to patch <ApiGroupVersionKind> '<NamespacedName>' due to rule '<RuleMessage>' with <YAML>"},
+			Description: "It validates the fact that the resource patch is rejected by a x-kubernetes-validations rule, with the given message.",
+			Examples:    []string{"Kubernetes refuses to patch example.com/v1/Widget 'default/widget' due to rule 'image is immutable' with"},
+		},
+		func(groupVersionKindStr, resourceName, message string, content helpers.YamlDocString) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			patch, err := helpers.YamlToJson(content.Content)
+			if err != nil {
+				return err
+			}
+
+			err = ctx.Patch(groupVersionKind, namespacedName, types.StrategicMergePatchType, patch)
+			return isRuleViolation(err, message)
+		},
+	)
+}
+
+// isRuleViolation returns an error if the given error is not an Invalid
+// error raised by a rule with the given message.
+func isRuleViolation(err error, message string) error {
+	switch {
+	case err == nil:
+		return fmt.Errorf("write not rejected by rule '%s'", message)
+	case !errors.IsInvalid(err):
+		return err
+	}
+
+	if status, isStatus := err.(errors.APIStatus); isStatus && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if strings.HasSuffix(cause.Message, ": "+message) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("write not rejected by rule '%s': %w", message, err)
+}
@@ -0,0 +1,142 @@
+package kubernetes_ctx
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// CaptureResourceVersion implements the GoDoc step
+// - `Kubernetes captures the version of <ApiGroupVersionKind> '<NamespacedName>'`
+// It keeps the current version of the specified resource, used by the
+// steps comparing or writing a stale version.
+func CaptureResourceVersion(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsVersions,
+			Expr:        `^Kubernetes captures the version of (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)'$`,
+			Templates:   []string{"Kubernetes captures the version of <ApiGroupVersionKind> '<NamespacedName>'"},
+			Description: "It keeps the current version of the specified resource, used by the steps comparing or writing a stale version.",
+			Examples:    []string{"Kubernetes captures the version of v1/Service 'default/kubernetes'"},
+		},
+		func(groupVersionKindStr, resourceName string) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			return ctx.CaptureVersion(groupVersionKind, namespacedName)
+		},
+	)
+}
+
+// UpdateResourceFromStaleVersion implements the GoDoc step
+// - `Kubernetes fails to update <ApiGroupVersionKind> '<NamespacedName>' from stale version with <YAML>`
+// It updates the captured version of the specified resource, merged with
+// the given definition, and validates the fact that the update is rejected
+// with a Conflict because the resource has been modified since.
+func UpdateResourceFromStaleVersion(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group:       StepsVersions,
+			Expr:        `^Kubernetes fails to update (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' from stale version with$`,
+			Templates:   []string{"Kubernetes fails to update <ApiGroupVersionKind> '<NamespacedName>' from stale version with <YAML>"},
+			Description: "It updates the captured version of the specified resource, merged with the given definition, and validates the fact that the update is rejected with a Conflict because the resource has been modified since.",
+			Examples:    []string{"Kubernetes fails to update v1/Service 'default/kubernetes' from stale version with"},
+		},
+		func(groupVersionKindStr, resourceName string, content helpers.YamlDocString) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(resourceName)
+
+			stale, err := ctx.CapturedVersion(groupVersionKind, namespacedName)
+			if err != nil {
+				return err
+			}
+
+			patch, err := helpers.YamlToJson(content.Content)
+			if err != nil {
+				return err
+			}
+			original, err := json.Marshal(stale.Object)
+			if err != nil {
+				return err
+			}
+			modified, err := jsonpatch.MergePatch(original, patch)
+			if err != nil {
+				return err
+			}
+
+			obj := &unstructured.Unstructured{}
+			if err := json.Unmarshal(modified, &obj.Object); err != nil {
+				return err
+			}
+
+			err = ctx.Update(groupVersionKind, namespacedName, obj)
+			return isExpectedFailure(err, "update", string(metav1.StatusReasonConflict), "")
+		},
+	)
+}
+
+// ResourceVersionChanged implements the GoDoc step
+// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' generation has|hasn't changed`
+// - `Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' resourceVersion has|hasn't changed`
+// It compares the generation or the resourceVersion of the specified
+// resource with its captured version.
+func ResourceVersionChanged(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsVersions,
+			Expr:  `^Kubernetes resource (` + RxGroupVersionKind + `) '(` + RxNamespacedName + `)' (generation|resourceVersion) (has|hasn't) changed$`,
+			Templates: []string{
+				"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' generation has|hasn't changed",
+				"Kubernetes resource <ApiGroupVersionKind> '<NamespacedName>' resourceVersion has|hasn't changed",
+			},
+			Description: "It compares the generation or the resourceVersion of the specified resource with its captured version.",
+			Examples: []string{
+				"Kubernetes resource v1/Service 'default/kubernetes' resourceVersion has changed",
+				"Kubernetes resource v1/Service 'default/kubernetes' generation hasn't changed",
+			},
+		},
+		func(groupVersionKindStr, name, field, has string) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+			namespacedName, _ := helpers.NamespacedNameFrom(name)
+
+			captured, err := ctx.CapturedVersion(groupVersionKind, namespacedName)
+			if err != nil {
+				return err
+			}
+			current, err := ctx.get(groupVersionKind, namespacedName)
+			if err != nil {
+				return err
+			}
+
+			var before, after interface{}
+			switch field {
+			case "generation":
+				before, after = captured.GetGeneration(), current.GetGeneration()
+			default:
+				before, after = captured.GetResourceVersion(), current.GetResourceVersion()
+			}
+
+			switch {
+			case has == "has" && before == after:
+				return fmt.Errorf("%s of %s '%s' has not changed (%v)", field, groupVersionKindStr, name, before)
+			case has == "hasn't" && before != after:
+				return fmt.Errorf("%s of %s '%s' has changed from %v to %v", field, groupVersionKindStr, name, before, after)
+			}
+			return nil
+		},
+	)
+}
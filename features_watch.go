@@ -0,0 +1,144 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// watchTimeout is the maximum time waited by the steps for the expected
+// events, which are received asynchronously.
+const watchTimeout = 2 * time.Second
+
+// OpenWatch implements the GoDoc step
+// - `Kubernetes watches <ApiGroupVersionKind>`
+// - `Kubernetes watches <ApiGroupVersionKind> in namespace '<Namespace>'`
+// It opens a watch on the given kind, recording all events until the end
+// of the scenario.
+func OpenWatch(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsWatch,
+			Expr:  `^Kubernetes watches (` + RxGroupVersionKind + `)(?: in namespace '(` + RxDNSChar + `+)')?$`,
+			Templates: []string{
+				"Kubernetes watches <ApiGroupVersionKind>",
+				"Kubernetes watches <ApiGroupVersionKind> in namespace '<Namespace>'",
+			},
+			Description: "It opens a watch on the given kind, recording all events until the end of the scenario.",
+			Examples:    []string{"Kubernetes watches apps/v1/Deployment in namespace 'default'"},
+		},
+		func(groupVersionKindStr, namespace string) error {
+			groupVersionKind, err := ctx.ResolveGroupVersionKind(groupVersionKindStr)
+			if err != nil {
+				return err
+			}
+
+			return ctx.openWatch(groupVersionKind, namespace)
+		},
+	)
+}
+
+// WatchSawEvents implements the GoDoc step
+// - `Kubernetes watch saw these events <EventTable>`
+// - `Kubernetes watch on <ApiGroupVersionKind> saw these events <EventTable>`
+// - `Kubernetes watch on <ApiGroupVersionKind> in namespace '<Namespace>' saw these events <EventTable>`
+// It validates the fact that the watch (the last opened one, if the kind is
+// not specified) received exactly the given events (ADDED, MODIFIED or
+// DELETED), in the same order.
+func WatchSawEvents(ctx *FeatureContext, s ScenarioContext) {
+	ctx.registerStep(s,
+		StepDefinition{
+			Group: StepsWatch,
+			Expr:  `^Kubernetes watch(?: on (` + RxGroupVersionKind + `)(?: in namespace '(` + RxDNSChar + `+)')?)? saw these events$`,
+			Templates: []string{
+				"Kubernetes watch saw these events <EventTable>",
+				"Kubernetes watch on <ApiGroupVersionKind> saw these events <EventTable>",
+				"Kubernetes watch on <ApiGroupVersionKind> in namespace '<Namespace>' saw these events <EventTable>",
+			},
+			Description: "It validates the fact that the watch (the last opened one, if the kind is not specified) received exactly the given events (ADDED, MODIFIED or DELETED), in the same order.",
+			Examples: []string{
+				"Kubernetes watch saw these events",
+				"Kubernetes watch on apps/v1/Deployment in namespace 'default' saw these events",
+			},
+		},
+		func(groupVersionKindStr, namespace string, table helpers.EventTable) error {
+			var groupVersionKind schema.GroupVersionKind
+			if groupVersionKindStr != "" {
+				var err error
+				if groupVersionKind, err = ctx.ResolveGroupVersionKind(groupVersionKindStr); err != nil {
+					return err
+				}
+			}
+
+			expected, err := helpers.UnmarshalEventTable(table)
+			if err != nil {
+				return err
+			}
+			recorder, err := ctx.openedWatch(groupVersionKind, namespace)
+			if err != nil {
+				return err
+			}
+
+			var events []watch.Event
+			err = wait.PollImmediate(10*time.Millisecond, watchTimeout, func() (bool, error) {
+				events = recorder.recorded()
+				return eventsMatch(events, expected), nil
+			})
+			if err == wait.ErrWaitTimeout {
+				return fmt.Errorf("watch saw [%s], not [%s]", describeEvents(events), describeEventRows(expected))
+			}
+			return err
+		},
+	)
+}
+
+// eventsMatch returns true if the given events are exactly the expected
+// ones.
+func eventsMatch(events []watch.Event, expected []helpers.EventTableRow) bool {
+	if len(events) != len(expected) {
+		return false
+	}
+	for i, event := range events {
+		if !strings.EqualFold(string(event.Type), expected[i].Type) || eventName(event) != expected[i].NamespacedName() {
+			return false
+		}
+	}
+	return true
+}
+
+// eventName returns the name of the object sent with the given event.
+func eventName(event watch.Event) types.NamespacedName {
+	accessor, err := meta.Accessor(event.Object)
+	if err != nil {
+		return types.NamespacedName{}
+	}
+	return types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+}
+
+// describeEvents returns a short description of the given events, like
+// `ADDED default/nginx`.
+func describeEvents(events []watch.Event) string {
+	descriptions := make([]string, len(events))
+	for i, event := range events {
+		descriptions[i] = fmt.Sprintf("%s %s", event.Type, strings.TrimPrefix(eventName(event).String(), "/"))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// describeEventRows returns a short description of the given expected
+// events, like `ADDED default/nginx`.
+func describeEventRows(rows []helpers.EventTableRow) string {
+	descriptions := make([]string, len(rows))
+	for i, row := range rows {
+		descriptions[i] = fmt.Sprintf("%s %s", strings.ToUpper(row.Type), row.Name)
+	}
+	return strings.Join(descriptions, ", ")
+}
@@ -5,8 +5,12 @@ go 1.14
 require (
 	github.com/cucumber/godog v0.10.0
 	github.com/cucumber/messages-go/v10 v10.0.3
-	github.com/google/uuid v1.1.1
+	github.com/evanphx/json-patch v4.5.0+incompatible
+	github.com/go-openapi/validate v0.19.5
+	github.com/google/cel-go v0.7.2
+	github.com/google/uuid v1.1.2
 	github.com/k0kubun/colorstring v0.0.0-20150214042306-9440f1994b88 // indirect
+	github.com/robfig/cron v1.1.0
 	github.com/stretchr/objx v0.2.0
 	github.com/stretchr/testify v1.6.1
 	github.com/thoas/go-funk v0.7.0
@@ -14,6 +18,8 @@ require (
 	github.com/yudai/golcs v0.0.0-20170316035057-ecda9a501e82 // indirect
 	github.com/yudai/pp v2.0.1+incompatible // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
+	k8s.io/api v0.18.2
+	k8s.io/apiextensions-apiserver v0.18.2
 	k8s.io/apimachinery v0.18.2
 	k8s.io/client-go v0.18.2
 	sigs.k8s.io/controller-runtime v0.6.0
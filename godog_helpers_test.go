@@ -47,4 +47,22 @@ func (s *scenarioContextMock) RunScenario() {
 	for _, fn := range s.beforeScenarioList {
 		fn(nil)
 	}
+}
+
+func (s *scenarioContextMock) RunScenarioWith(sc *godog.Scenario) {
+	for _, fn := range s.beforeScenarioList {
+		fn(sc)
+	}
+}
+
+func (s *scenarioContextMock) EndScenarioWith(sc *godog.Scenario, err error) {
+	for _, fn := range s.afterScenarioList {
+		fn(sc, err)
+	}
+}
+
+func (s *scenarioContextMock) RunStep() {
+	for _, fn := range s.beforeStepList {
+		fn(nil)
+	}
 }
\ No newline at end of file
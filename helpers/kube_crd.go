@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/install"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var crdScheme = runtime.NewScheme()
+
+func init() {
+	install.Install(crdScheme)
+}
+
+// LoadCustomResourceDefinitions reads all CustomResourceDefinitions
+// (apiextensions.k8s.io/v1 or v1beta1) available in the given files or
+// directories. Documents that are not CustomResourceDefinitions are ignored.
+// Definitions are returned with their internal representation, defaulted
+// like the API server does.
+func LoadCustomResourceDefinitions(paths ...string) ([]*apiextensions.CustomResourceDefinition, error) {
+	objs, err := ReadManifests(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	var crds []*apiextensions.CustomResourceDefinition
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		if gvk.Group != apiextensions.GroupName || gvk.Kind != "CustomResourceDefinition" {
+			continue
+		}
+
+		versioned, err := crdScheme.New(gvk)
+		if err != nil {
+			return nil, err
+		}
+
+		// NOTE: JSON is used because the unstructured converter doesn't
+		//       convert integers to float (used by schema limits)
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, versioned); err != nil {
+			return nil, fmt.Errorf("invalid CustomResourceDefinition '%s': %w", obj.GetName(), err)
+		}
+		crdScheme.Default(versioned)
+
+		crd := &apiextensions.CustomResourceDefinition{}
+		err = crdScheme.Convert(versioned, crd, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CustomResourceDefinition '%s': %w", obj.GetName(), err)
+		}
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ManifestExtensions lists the file extensions read by ReadManifests when
+// it walks through a directory.
+var ManifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// ReadManifests reads all YAML or JSON documents available in the given files
+// or directories (walked recursively) and returns them as Unstructured objects.
+// Empty documents are ignored and the items of lists (like `kind: List`) are
+// returned instead of the lists themselves.
+func ReadManifests(paths ...string) ([]*unstructured.Unstructured, error) {
+	files, err := manifestFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []*unstructured.Unstructured
+	for _, file := range files {
+		fobjs, err := readManifestFile(file)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, fobjs...)
+	}
+	return objs, nil
+}
+
+// manifestFiles returns the sorted list of all manifest files available
+// in the given paths.
+func manifestFiles(paths ...string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		var dirFiles []string
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case info.IsDir():
+				return nil
+			}
+
+			for _, ext := range ManifestExtensions {
+				if strings.EqualFold(filepath.Ext(file), ext) {
+					dirFiles = append(dirFiles, file)
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// readManifestFile reads all documents available in the given file.
+func readManifestFile(file string) ([]*unstructured.Unstructured, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(fd, 4096)
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		switch {
+		case err == io.EOF:
+			return objs, nil
+		case err != nil:
+			return nil, err
+		}
+
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(raw); err != nil {
+			return nil, err
+		}
+		if !obj.IsList() {
+			objs = append(objs, obj)
+			continue
+		}
+
+		err = obj.EachListItem(func(item runtime.Object) error {
+			objs = append(objs, item.(*unstructured.Unstructured))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// writeManifests writes the given files in a temporary directory and
+// returns its path.
+func writeManifests(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "manifests")
+	require.NoError(t, err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+// manifestNames returns the kind and the name of the given objects.
+func manifestNames(objs []*unstructured.Unstructured) []string {
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetKind()+"/"+obj.GetName())
+	}
+	return names
+}
+
+func TestReadManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		expect   []string
+		err      bool
+	}{
+		{
+			name: "multi-documents",
+			manifest: `
+apiVersion: v1
+kind: Namespace
+metadata: {name: default}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: config, namespace: default}
+`,
+			expect: []string{"Namespace/default", "ConfigMap/config"},
+		},
+		{
+			name: "empty documents",
+			manifest: `
+---
+# only a comment
+---
+apiVersion: v1
+kind: Namespace
+metadata: {name: default}
+---
+---
+`,
+			expect: []string{"Namespace/default"},
+		},
+		{
+			name: "list",
+			manifest: `
+apiVersion: v1
+kind: List
+items:
+- {apiVersion: v1, kind: Namespace, metadata: {name: default}}
+- {apiVersion: v1, kind: Namespace, metadata: {name: kube-system}}
+`,
+			expect: []string{"Namespace/default", "Namespace/kube-system"},
+		},
+		{
+			name:     "empty file",
+			manifest: ``,
+			expect:   nil,
+		},
+		{
+			name: "invalid document",
+			manifest: `
+apiVersion: v1
+kind: Namespace
+metadata: {name: default}
+---
+apiVersion: v1
+kind: Namespace
+metadata: [
+`,
+			err: true,
+		},
+		{
+			name: "document without kind",
+			manifest: `
+apiVersion: v1
+metadata: {name: default}
+`,
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeManifests(t, map[string]string{"manifest.yaml": tt.manifest})
+			defer os.RemoveAll(dir)
+
+			objs, err := ReadManifests(filepath.Join(dir, "manifest.yaml"))
+
+			switch {
+			case tt.err:
+				assert.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.expect, manifestNames(objs))
+			}
+		})
+	}
+}
+
+func TestReadManifests_Directory(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"b.yml":          "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: b}",
+		"a.json":         `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}`,
+		"sub/c.YAML":     "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: c}",
+		"README.md":      `not a manifest`,
+		"sub/ignored.go": `package ignored`,
+	})
+	defer os.RemoveAll(dir)
+
+	objs, err := ReadManifests(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/a", "ConfigMap/b", "ConfigMap/c"}, manifestNames(objs))
+}
+
+func TestReadManifests_NotFound(t *testing.T) {
+	_, err := ReadManifests("not/found")
+	assert.Error(t, err)
+}
@@ -75,3 +75,87 @@ func (row ResourceTableRow) NamespacedName() types.NamespacedName {
 	namespacedName, _ = NamespacedNameFrom(row.Namespace + "/" + row.Name)
 	return namespacedName
 }
+
+type (
+	// CallTable adds unmarshalling method to easily manage API call table.
+	CallTable pickleTable
+	// CallTableRow describes an API call in the CallTable.
+	CallTableRow struct {
+		Verb string
+		ResourceTableRow
+		// Subresource is the targeted subresource, like status; empty for
+		// the resource itself.
+		Subresource string
+	}
+)
+
+// UnmarshalCallTable converts a *messages.PickleStepArgument_PickleTable into a
+// list of CallTableRow, in which we can easily extract the verb, the
+// GroupVersionKind, the NamespacedName and the subresource (optional last
+// column) values.
+func UnmarshalCallTable(table CallTable) (calls []CallTableRow, err error) {
+	for i, row := range table.Rows {
+		if i == 0 && row.Cells[0].GetValue() == "Verb" {
+			// Ignore header line if exists
+			continue
+		}
+
+		if len(row.Cells) != 5 && len(row.Cells) != 6 {
+			return nil, fmt.Errorf("invalid call table: it must contains 5 or 6 columns (Verb, GroupVersion, Kind, Namespace, Name[, Subresource])")
+		}
+
+		var subresource string
+		if len(row.Cells) == 6 {
+			subresource = row.Cells[5].GetValue()
+		}
+		calls = append(calls, CallTableRow{
+			Verb: row.Cells[0].GetValue(),
+			ResourceTableRow: ResourceTableRow{
+				GroupVersion: row.Cells[1].GetValue(),
+				Kind:         row.Cells[2].GetValue(),
+				Namespace:    row.Cells[3].GetValue(),
+				Name:         row.Cells[4].GetValue(),
+			},
+			Subresource: subresource,
+		})
+	}
+	return calls, nil
+}
+
+type (
+	// EventTable adds unmarshalling method to easily manage watch event table.
+	EventTable pickleTable
+	// EventTableRow describes a watch event in the EventTable.
+	EventTableRow struct {
+		Type string
+		Name string
+	}
+)
+
+// UnmarshalEventTable converts a *messages.PickleStepArgument_PickleTable into a
+// list of EventTableRow, in which we can easily extract the event type and
+// the NamespacedName values.
+func UnmarshalEventTable(table EventTable) (events []EventTableRow, err error) {
+	for i, row := range table.Rows {
+		if i == 0 && row.Cells[0].GetValue() == "Type" {
+			// Ignore header line if exists
+			continue
+		}
+
+		if len(row.Cells) != 2 {
+			return nil, fmt.Errorf("invalid event table: it must contains 2 columns (Type, NamespacedName)")
+		}
+
+		events = append(events, EventTableRow{
+			Type: row.Cells[0].GetValue(),
+			Name: row.Cells[1].GetValue(),
+		})
+	}
+	return events, nil
+}
+
+// NamespacedName returns the NamespacedName of the current event.
+func (row EventTableRow) NamespacedName() types.NamespacedName {
+	namespacedName, _ := NamespacedNameFrom(row.Name)
+	return namespacedName
+}
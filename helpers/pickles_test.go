@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// pickleTableOf returns a PickleTable with the given rows.
+func pickleTableOf(rows ...[]string) *messages.PickleStepArgument_PickleTable {
+	table := &messages.PickleStepArgument_PickleTable{}
+	for _, row := range rows {
+		tableRow := &messages.PickleStepArgument_PickleTable_PickleTableRow{}
+		for _, cell := range row {
+			tableRow.Cells = append(tableRow.Cells, &messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{Value: cell})
+		}
+		table.Rows = append(table.Rows, tableRow)
+	}
+	return table
+}
+
+func TestUnmarshalCallTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		table  *messages.PickleStepArgument_PickleTable
+		expect []CallTableRow
+		err    string
+	}{
+		{
+			name: "with header",
+			table: pickleTableOf(
+				[]string{"Verb", "ApiGroupVersion", "Kind", "Namespace", "Name"},
+				[]string{"create", "v1", "Namespace", "", "default"},
+			),
+			expect: []CallTableRow{
+				{Verb: "create", ResourceTableRow: ResourceTableRow{GroupVersion: "v1", Kind: "Namespace", Name: "default"}},
+			},
+		},
+		{
+			name: "with subresource",
+			table: pickleTableOf(
+				[]string{"update", "apps/v1", "Deployment", "default", "nginx", "status"},
+				[]string{"get", "apps/v1", "Deployment", "default", "nginx"},
+			),
+			expect: []CallTableRow{
+				{Verb: "update", ResourceTableRow: ResourceTableRow{GroupVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "nginx"}, Subresource: "status"},
+				{Verb: "get", ResourceTableRow: ResourceTableRow{GroupVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "nginx"}},
+			},
+		},
+		{
+			name:  "invalid columns",
+			table: pickleTableOf([]string{"get", "v1", "Namespace", "default"}),
+			err:   "invalid call table: it must contains 5 or 6 columns (Verb, GroupVersion, Kind, Namespace, Name[, Subresource])",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, err := UnmarshalCallTable(tt.table)
+
+			switch {
+			case tt.err != "":
+				assert.EqualError(t, err, tt.err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, calls)
+			}
+		})
+	}
+}
+
+func TestCallTableRow_Resource(t *testing.T) {
+	calls, err := UnmarshalCallTable(pickleTableOf([]string{"get", "apps/v1", "Deployment", "default", "nginx"}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, calls[0].GroupVersionKind())
+	assert.Equal(t, types.NamespacedName{Namespace: "default", Name: "nginx"}, calls[0].NamespacedName())
+}
+
+func TestUnmarshalEventTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		table  *messages.PickleStepArgument_PickleTable
+		expect []EventTableRow
+		err    string
+	}{
+		{
+			name: "with header",
+			table: pickleTableOf(
+				[]string{"Type", "NamespacedName"},
+				[]string{"ADDED", "default/nginx"},
+				[]string{"DELETED", "default"},
+			),
+			expect: []EventTableRow{
+				{Type: "ADDED", Name: "default/nginx"},
+				{Type: "DELETED", Name: "default"},
+			},
+		},
+		{
+			name:   "without header",
+			table:  pickleTableOf([]string{"MODIFIED", "default/nginx"}),
+			expect: []EventTableRow{{Type: "MODIFIED", Name: "default/nginx"}},
+		},
+		{
+			name:  "invalid columns",
+			table: pickleTableOf([]string{"ADDED", "default", "nginx"}),
+			err:   "invalid event table: it must contains 2 columns (Type, NamespacedName)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := UnmarshalEventTable(tt.table)
+
+			switch {
+			case tt.err != "":
+				assert.EqualError(t, err, tt.err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, events)
+			}
+		})
+	}
+}
+
+func TestEventTableRow_NamespacedName(t *testing.T) {
+	assert.Equal(t, types.NamespacedName{Namespace: "default", Name: "nginx"}, EventTableRow{Name: "default/nginx"}.NamespacedName())
+	assert.Equal(t, types.NamespacedName{Name: "default"}, EventTableRow{Name: "default"}.NamespacedName())
+}
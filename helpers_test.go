@@ -2,21 +2,28 @@ package kubernetes_ctx_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
 	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
 )
 
 // initFakeScenario generates a godoc ScenarioContext and a FeatureContext
-// with a fake client.
-func initFakeScenario(t *testing.T) *kubernetes_ctx.FeatureContext {
+// with a fake client, configured with the given options.
+func initFakeScenario(t *testing.T, opts ...kubernetes_ctx.FeatureContextOption) *kubernetes_ctx.FeatureContext {
 	scenarioContextMock := MockScenarioContext()
-	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(scenarioContextMock, kubernetes_ctx.WithFakeRuntimeClient())
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		append([]kubernetes_ctx.FeatureContextOption{kubernetes_ctx.WithFakeRuntimeClient()}, opts...)...,
+	)
 	require.NoError(t, err)
 	scenarioContextMock.RunScenario()
 	return ctx
@@ -24,8 +31,8 @@ func initFakeScenario(t *testing.T) *kubernetes_ctx.FeatureContext {
 
 // initFakeScenarioWithNamespaces generates a godoc ScenarioContext,
 // a FeatureContext with a fake client and with default Kubernetes namespaces.
-func initFakeScenarioWithNamespaces(t *testing.T) *kubernetes_ctx.FeatureContext {
-	ctx := initFakeScenario(t)
+func initFakeScenarioWithNamespaces(t *testing.T, opts ...kubernetes_ctx.FeatureContextOption) *kubernetes_ctx.FeatureContext {
+	ctx := initFakeScenario(t, opts...)
 
 	for _, namespace := range []string{"kube-system", "kube-public", "default"} {
 		err := ctx.Create(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, types.NamespacedName{Name: namespace}, &unstructured.Unstructured{})
@@ -34,6 +41,11 @@ func initFakeScenarioWithNamespaces(t *testing.T) *kubernetes_ctx.FeatureContext
 	return ctx
 }
 
+// newFakeClock returns a fake clock set to 2024-01-01T00:00:00Z.
+func newFakeClock() *clock.FakeClock {
+	return clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
 // yamlToUnstructured returns an unmarshalled unstructured.Unstructured based
 // on the given YAML.
 func yamlToUnstructured(t *testing.T, rawYaml string) *unstructured.Unstructured {
@@ -41,3 +53,11 @@ func yamlToUnstructured(t *testing.T, rawYaml string) *unstructured.Unstructured
 	require.NoError(t, yaml.Unmarshal([]byte(rawYaml), &obj.Object))
 	return obj
 }
+
+// featureScheme returns the scheme used by the GoDog test suites, with all
+// Kubernetes types.
+func featureScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	return scheme
+}
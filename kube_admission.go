@@ -0,0 +1,285 @@
+package kubernetes_ctx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// admissionOperation is the kind of write operation being admitted.
+type admissionOperation string
+
+const (
+	admissionCreate admissionOperation = "CREATE"
+	admissionUpdate admissionOperation = "UPDATE"
+)
+
+// admissionPlugin mutates or validates objects before they are written,
+// like an API server admission plugin does.
+type admissionPlugin interface {
+	// Handles returns true if the plugin must be called for the given kind.
+	Handles(gvk schema.GroupVersionKind) bool
+	// Admit mutates or validates the given object. The old object is only
+	// given on update.
+	Admit(ctx *FeatureContext, op admissionOperation, obj, old *unstructured.Unstructured) error
+}
+
+// admissionClient wraps a client.Client in order to run all admission
+// plugins registered on the FeatureContext before each write. Patches are
+// applied locally, admitted and then written with an Update.
+type admissionClient struct {
+	client.Client
+	ctx *FeatureContext
+}
+
+func (c *admissionClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.admit(goctx, admissionCreate, obj); err != nil {
+		return err
+	}
+	return c.Client.Create(goctx, obj, opts...)
+}
+
+func (c *admissionClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.admit(goctx, admissionUpdate, obj); err != nil {
+		return err
+	}
+	return c.Client.Update(goctx, obj, opts...)
+}
+
+func (c *admissionClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	handled, err := c.handles(obj)
+	switch {
+	case err != nil:
+		return err
+	case !handled:
+		return c.Client.Patch(goctx, obj, patch, opts...)
+	}
+
+	if err := c.ctx.patchLocally(goctx, c.Client, obj, patch); err != nil {
+		return err
+	}
+	if err := c.admit(goctx, admissionUpdate, obj); err != nil {
+		return err
+	}
+	return c.Client.Update(goctx, obj, patchToUpdateOptions(opts))
+}
+
+func (c *admissionClient) Status() client.StatusWriter {
+	return &admissionStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// handles returns true if at least one admission plugin handles the
+// given object.
+func (c *admissionClient) handles(obj runtime.Object) (bool, error) {
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return false, err
+	}
+
+	for _, plugin := range c.ctx.admission {
+		if plugin.Handles(gvk) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// admit runs all admission plugins handling the given object and stores
+// the admitted result inside it.
+func (c *admissionClient) admit(goctx context.Context, op admissionOperation, obj runtime.Object) error {
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return err
+	}
+
+	var plugins []admissionPlugin
+	for _, plugin := range c.ctx.admission {
+		if plugin.Handles(gvk) {
+			plugins = append(plugins, plugin)
+		}
+	}
+	if len(plugins) == 0 {
+		return nil
+	}
+
+	content, err := toUnstructuredContent(obj)
+	if err != nil {
+		return err
+	}
+	uobj := &unstructured.Unstructured{Object: content}
+	uobj.SetGroupVersionKind(gvk)
+
+	var old *unstructured.Unstructured
+	if op == admissionUpdate {
+		old = &unstructured.Unstructured{}
+		old.SetGroupVersionKind(gvk)
+
+		err := c.Client.Get(goctx, client.ObjectKey{Namespace: uobj.GetNamespace(), Name: uobj.GetName()}, old)
+		switch {
+		case errors.IsNotFound(err):
+			// the underlying client will return the right error
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+
+	for _, plugin := range plugins {
+		if err := plugin.Admit(c.ctx, op, uobj, old); err != nil {
+			return err
+		}
+	}
+	return fromUnstructuredContent(uobj.Object, obj)
+}
+
+// admissionStatusWriter runs all admission plugins before writing
+// the status subresource.
+type admissionStatusWriter struct {
+	client.StatusWriter
+	client *admissionClient
+}
+
+func (w *admissionStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.client.admit(goctx, admissionUpdate, obj); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(goctx, obj, opts...)
+}
+
+func (w *admissionStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	handled, err := w.client.handles(obj)
+	switch {
+	case err != nil:
+		return err
+	case !handled:
+		return w.StatusWriter.Patch(goctx, obj, patch, opts...)
+	}
+
+	if err := w.client.ctx.patchLocally(goctx, w.client.Client, obj, patch); err != nil {
+		return err
+	}
+	if err := w.client.admit(goctx, admissionUpdate, obj); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(goctx, obj, patchToUpdateOptions(opts))
+}
+
+// patchToUpdateOptions converts patch options to the equivalent update options.
+func patchToUpdateOptions(opts []client.PatchOption) *client.UpdateOptions {
+	patchOpts := (&client.PatchOptions{}).ApplyOptions(opts)
+	return &client.UpdateOptions{DryRun: patchOpts.DryRun, FieldManager: patchOpts.FieldManager}
+}
+
+// gvkForObject returns the GroupVersionKind of the given object, using its
+// TypeMeta if filled or the scheme otherwise.
+func gvkForObject(scheme Scheme, obj runtime.Object) (schema.GroupVersionKind, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if !gvk.Empty() {
+		return gvk, nil
+	}
+
+	gvks, _, err := scheme.ObjectKinds(obj)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gvks[0], nil
+}
+
+// toUnstructuredContent returns a copy of the unstructured content of the
+// given object.
+func toUnstructuredContent(obj runtime.Object) (map[string]interface{}, error) {
+	if uobj, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		return runtime.DeepCopyJSON(uobj.UnstructuredContent()), nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+// fromUnstructuredContent replaces the content of the given object by the
+// unstructured content.
+func fromUnstructuredContent(content map[string]interface{}, obj runtime.Object) error {
+	if uobj, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		uobj.SetUnstructuredContent(content)
+		return nil
+	}
+
+	// reset the object to avoid keeping removed fields
+	value := reflect.ValueOf(obj).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(content, obj)
+}
+
+// patchLocally applies the given patch on the current version of the object,
+// fetched with the given client, and stores the result inside obj.
+func (ctx *FeatureContext) patchLocally(goctx context.Context, c client.Client, obj runtime.Object, patch client.Patch) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	current := obj.DeepCopyObject()
+	err = c.Get(goctx, client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}, current)
+	if err != nil {
+		return err
+	}
+
+	gvk, err := gvkForObject(ctx.scheme, current)
+	if err != nil {
+		return err
+	}
+
+	original, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	patched, err := applyPatch(ctx.scheme, gvk, patch.Type(), original, data)
+	if err != nil {
+		return err
+	}
+
+	content := map[string]interface{}{}
+	if err := json.Unmarshal(patched, &content); err != nil {
+		return err
+	}
+	return fromUnstructuredContent(content, obj)
+}
+
+// applyPatch applies the patch on the original JSON document, like the API
+// server does. Strategic merge patches are only applied with their strategy
+// on kinds with a registered Go type; merge patches are used otherwise.
+func applyPatch(scheme Scheme, gvk schema.GroupVersionKind, pt types.PatchType, original, patch []byte) ([]byte, error) {
+	switch pt {
+	case types.JSONPatchType:
+		jpatch, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+		return jpatch.Apply(original)
+	case types.MergePatchType:
+		return jsonpatch.MergePatch(original, patch)
+	case types.StrategicMergePatchType:
+		kobj, err := scheme.New(gvk)
+		if _, isUnstructured := kobj.(runtime.Unstructured); err != nil || isUnstructured {
+			return jsonpatch.MergePatch(original, patch)
+		}
+		return strategicpatch.StrategicMergePatch(original, patch, kobj)
+	default:
+		return nil, errors.NewBadRequest(fmt.Sprintf("patch type %s is not supported", pt))
+	}
+}
@@ -0,0 +1,242 @@
+package kubernetes_ctx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type (
+	// APICall describes a request sent through the feature context client
+	// (see WithCallRecording).
+	APICall struct {
+		// Verb is the request verb, like get, list, create, update, patch,
+		// delete or deletecollection.
+		Verb             string
+		GroupVersionKind schema.GroupVersionKind
+		Namespace        string
+		Name             string
+		// Subresource is the targeted subresource, like status; empty for
+		// the resource itself.
+		Subresource string
+		// Patch is the body of the patch requests.
+		Patch []byte
+		// Timestamp is the time, on the feature context clock, when the
+		// request has been sent.
+		Timestamp time.Time
+		// Err is the error returned by the request, if any.
+		Err error
+	}
+
+	// callRecorder keeps all calls sent through the feature context client.
+	// NOTE: reconcilers can send requests concurrently
+	callRecorder struct {
+		sync.Mutex
+		calls []APICall
+	}
+
+	// recordingClient wraps a client.Client in order to record all requests
+	// sent through it.
+	recordingClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// recordingStatusWriter wraps a client.StatusWriter in order to record
+	// all requests sent on the status subresource.
+	recordingStatusWriter struct {
+		client.StatusWriter
+		client *recordingClient
+	}
+
+	// internalReadKey is the context key marking the reads issued by the
+	// feature context itself, which are not recorded.
+	internalReadKey struct{}
+)
+
+// Calls returns all requests sent through the feature context client since
+// the beginning of the scenario (or the last call of ResetCalls), in their
+// sending order. The call recording must be enabled with WithCallRecording.
+func (ctx *FeatureContext) Calls() ([]APICall, error) {
+	if ctx.calls == nil {
+		return nil, fmt.Errorf("calls can only be fetched when the call recording is enabled (see WithCallRecording)")
+	}
+
+	ctx.calls.Lock()
+	defer ctx.calls.Unlock()
+	return append([]APICall(nil), ctx.calls.calls...), nil
+}
+
+// ResetCalls forgets all recorded requests.
+func (ctx *FeatureContext) ResetCalls() {
+	if ctx.calls == nil {
+		return
+	}
+
+	ctx.calls.Lock()
+	defer ctx.calls.Unlock()
+	ctx.calls.calls = nil
+}
+
+// internalContext returns the context of the reads issued by the feature
+// context itself (like the ones of the assertion steps), in order to keep
+// them out of the recorded calls.
+func (ctx *FeatureContext) internalContext() context.Context {
+	return context.WithValue(ctx.ctx, internalReadKey{}, true)
+}
+
+// isInternalRead returns true if the request has been issued by the feature
+// context itself.
+func isInternalRead(goctx context.Context) bool {
+	internal, _ := goctx.Value(internalReadKey{}).(bool)
+	return internal
+}
+
+// String returns a short description of the call, like
+// `update apps/v1/Deployment 'default/nginx'`.
+func (call APICall) String() string {
+	verb := call.Verb
+	if call.Subresource != "" {
+		verb += " " + call.Subresource + " of"
+	}
+
+	groupVersionKind := call.GroupVersionKind.GroupVersion().String() + "/" + call.GroupVersionKind.Kind
+	switch {
+	case call.Name != "":
+		return fmt.Sprintf("%s %s '%s'", verb, groupVersionKind, types.NamespacedName{Namespace: call.Namespace, Name: call.Name})
+	case call.Namespace != "":
+		return fmt.Sprintf("%s %s in namespace '%s'", verb, groupVersionKind, call.Namespace)
+	}
+	return fmt.Sprintf("%s %s", verb, groupVersionKind)
+}
+
+// matches returns true if the call has been sent with the given verb on the
+// given kind (or one of its subresources) and, if not empty, on the given
+// resource.
+func (call APICall) matches(verb string, gvk schema.GroupVersionKind, namespacedName types.NamespacedName) bool {
+	return call.Verb == strings.ToLower(verb) &&
+		call.GroupVersionKind == gvk &&
+		(namespacedName.Name == "" || (call.Namespace == namespacedName.Namespace && call.Name == namespacedName.Name))
+}
+
+// record keeps the given request once it has been sent.
+func (c *recordingClient) record(verb, subresource string, obj runtime.Object, namespace, name string, patch []byte, err error) {
+	gvk, gvkErr := gvkForObject(c.ctx.scheme, obj)
+	if gvkErr != nil {
+		// NOTE: unknown kinds are recorded without kind
+		gvk = schema.GroupVersionKind{}
+	}
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+
+	c.ctx.calls.Lock()
+	defer c.ctx.calls.Unlock()
+	c.ctx.calls.calls = append(c.ctx.calls.calls, APICall{
+		Verb:             verb,
+		GroupVersionKind: gvk,
+		Namespace:        namespace,
+		Name:             name,
+		Subresource:      subresource,
+		Patch:            patch,
+		Timestamp:        c.ctx.clock.Now(),
+		Err:              err,
+	})
+}
+
+// recordOnObject keeps the given request sent on an existing object.
+func (c *recordingClient) recordOnObject(verb, subresource string, obj runtime.Object, patch []byte, err error) {
+	var namespace, name string
+	if accessor, accessorErr := meta.Accessor(obj); accessorErr == nil {
+		namespace, name = accessor.GetNamespace(), accessor.GetName()
+	}
+	c.record(verb, subresource, obj, namespace, name, patch, err)
+}
+
+// patchData returns the body of the given patch, computed before sending
+// it because the object is then updated with the server response.
+func patchData(obj runtime.Object, patch client.Patch) []byte {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func (c *recordingClient) Get(goctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	err := c.Client.Get(goctx, key, obj)
+	if !isInternalRead(goctx) {
+		c.record("get", "", obj, key.Namespace, key.Name, nil, err)
+	}
+	return err
+}
+
+func (c *recordingClient) List(goctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	err := c.Client.List(goctx, list, opts...)
+	if !isInternalRead(goctx) {
+		c.record("list", "", list, listOpts.Namespace, "", nil, err)
+	}
+	return err
+}
+
+func (c *recordingClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	err := c.Client.Create(goctx, obj, opts...)
+	c.recordOnObject("create", "", obj, nil, err)
+	return err
+}
+
+func (c *recordingClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	err := c.Client.Update(goctx, obj, opts...)
+	c.recordOnObject("update", "", obj, nil, err)
+	return err
+}
+
+func (c *recordingClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	data := patchData(obj, patch)
+	err := c.Client.Patch(goctx, obj, patch, opts...)
+	c.recordOnObject("patch", "", obj, data, err)
+	return err
+}
+
+func (c *recordingClient) Delete(goctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	err := c.Client.Delete(goctx, obj, opts...)
+	c.recordOnObject("delete", "", obj, nil, err)
+	return err
+}
+
+func (c *recordingClient) DeleteAllOf(goctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	deleteOpts := client.DeleteAllOfOptions{}
+	deleteOpts.ApplyOptions(opts)
+
+	err := c.Client.DeleteAllOf(goctx, obj, opts...)
+	c.record("deletecollection", "", obj, deleteOpts.Namespace, "", nil, err)
+	return err
+}
+
+func (c *recordingClient) Status() client.StatusWriter {
+	return &recordingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+func (w *recordingStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	err := w.StatusWriter.Update(goctx, obj, opts...)
+	w.client.recordOnObject("update", "status", obj, nil, err)
+	return err
+}
+
+func (w *recordingStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	data := patchData(obj, patch)
+	err := w.StatusWriter.Patch(goctx, obj, patch, opts...)
+	w.client.recordOnObject("patch", "status", obj, data, err)
+	return err
+}
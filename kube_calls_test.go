@@ -0,0 +1,149 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// initFakeScenarioWithCalls generates a godoc ScenarioContext and a
+// FeatureContext with a fake client, a fake clock and the call recording.
+func initFakeScenarioWithCalls(t *testing.T, opts ...kubernetes_ctx.FeatureContextOption) *kubernetes_ctx.FeatureContext {
+	return initFakeScenario(t, append([]kubernetes_ctx.FeatureContextOption{
+		kubernetes_ctx.WithClock(newFakeClock()),
+		kubernetes_ctx.WithCallRecording(),
+	}, opts...)...)
+}
+
+func TestFeatureContext_Calls(t *testing.T) {
+	ctx := initFakeScenarioWithCalls(t)
+	now := ctx.Clock().Now()
+
+	require.NoError(t, ctx.Create(namespaceGVK, namespaceDefault, &unstructured.Unstructured{}))
+	require.NoError(t, ctx.Patch(namespaceGVK, namespaceDefault, types.MergePatchType, []byte(`{"metadata":{"labels":{"key":"value"}}}`)))
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), &unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "NamespaceList"}}))
+	unknown := &unstructured.Unstructured{}
+	unknown.SetGroupVersionKind(namespaceGVK)
+	err := ctx.Client().Get(ctx.GoContext(), types.NamespacedName{Name: "unknown"}, unknown)
+	require.True(t, errors.IsNotFound(err))
+
+	_, err = ctx.List(namespaceGVK)
+	require.NoError(t, err)
+	_, err = ctx.Get(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+
+	calls, err := ctx.Calls()
+	require.NoError(t, err)
+	require.Len(t, calls, 6)
+
+	// NOTE: the read done by Patch before sending the patch is not recorded
+	expected := []string{
+		"create v1/Namespace '/default'",
+		"patch v1/Namespace '/default'",
+		"list v1/Namespace",
+		"get v1/Namespace '/unknown'",
+		"list v1/Namespace",
+		"get v1/Namespace '/default'",
+	}
+	for i, call := range calls {
+		assert.Equal(t, expected[i], call.String())
+		assert.Equal(t, now, call.Timestamp)
+	}
+	assert.Equal(t, `{"metadata":{"labels":{"key":"value"}}}`, string(calls[1].Patch))
+	assert.NoError(t, calls[0].Err)
+	assert.True(t, errors.IsNotFound(calls[3].Err))
+
+	ctx.ResetCalls()
+	calls, err = ctx.Calls()
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+}
+
+func TestFeatureContext_Calls_Subresources(t *testing.T) {
+	ctx := initFakeScenarioWithCalls(t)
+	require.NoError(t, ctx.Create(namespaceGVK, namespaceDefault, &unstructured.Unstructured{}))
+	ctx.ResetCalls()
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(namespaceGVK)
+	obj.SetName(namespaceDefault.Name)
+	err := ctx.Client().Status().Patch(ctx.GoContext(), obj, ctrlclient.RawPatch(types.MergePatchType, []byte(`{"status":{"phase":"Active"}}`)))
+	require.NoError(t, err)
+
+	calls, err := ctx.Calls()
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "patch status of v1/Namespace '/default'", calls[0].String())
+	assert.Equal(t, "status", calls[0].Subresource)
+	assert.Equal(t, `{"status":{"phase":"Active"}}`, string(calls[0].Patch))
+}
+
+func TestFeatureContext_Calls_WithFaults(t *testing.T) {
+	ctx := initFakeScenarioWithCalls(t, kubernetes_ctx.WithFaultInjection())
+	require.NoError(t, ctx.InjectFault(kubernetes_ctx.Fault{Verb: "create", Reason: metav1.StatusReasonForbidden}))
+
+	err := ctx.Create(namespaceGVK, namespaceDefault, &unstructured.Unstructured{})
+	require.True(t, errors.IsForbidden(err))
+
+	calls, err := ctx.Calls()
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.True(t, errors.IsForbidden(calls[0].Err))
+}
+
+func TestFeatureContext_Calls_Disabled(t *testing.T) {
+	ctx := initFakeScenario(t)
+
+	_, err := ctx.Calls()
+	assert.EqualError(t, err, "calls can only be fetched when the call recording is enabled (see WithCallRecording)")
+}
+
+func TestClientIssuedCalls_Subresources(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithCallRecording(),
+		kubernetes_ctx.WithSteps(kubernetes_ctx.StepsCalls),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	var issuedCalls func(helpers.CallTable) error
+	for _, step := range scenarioContextMock.stepList {
+		if step.expr == `^Kubernetes client issued exactly these calls$` {
+			issuedCalls = step.fnc.(func(helpers.CallTable) error)
+		}
+	}
+	require.NotNil(t, issuedCalls)
+
+	require.NoError(t, ctx.Create(namespaceGVK, namespaceDefault, &unstructured.Unstructured{}))
+	ctx.ResetCalls()
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(namespaceGVK)
+	obj.SetName(namespaceDefault.Name)
+	require.NoError(t, ctx.Client().Status().Patch(ctx.GoContext(), obj, ctrlclient.RawPatch(types.MergePatchType, []byte(`{"status":{"phase":"Active"}}`))))
+
+	callTable := func(cells ...string) helpers.CallTable {
+		row := &messages.PickleStepArgument_PickleTable_PickleTableRow{}
+		for _, cell := range cells {
+			row.Cells = append(row.Cells, &messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{Value: cell})
+		}
+		return &messages.PickleStepArgument_PickleTable{Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{row}}
+	}
+	assert.NoError(t, issuedCalls(callTable("patch", "v1", "Namespace", "", "default", "status")))
+	assert.EqualError(t,
+		issuedCalls(callTable("patch", "v1", "Namespace", "", "default")),
+		"call #1 is 'patch status of v1/Namespace '/default'', not 'patch v1/Namespace '/default'': [patch status of v1/Namespace '/default']",
+	)
+}
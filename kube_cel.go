@@ -0,0 +1,278 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+	"github.com/thoas/go-funk"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+const (
+	celItems                = "[*]"
+	celAdditionalProperties = "{*}"
+)
+
+type (
+	// celRule is a compiled x-kubernetes-validations rule.
+	celRule struct {
+		// path is the schema path where the rule is defined (property
+		// names, celItems or celAdditionalProperties).
+		path []string
+		// schemaType is the type of the schema where the rule is defined.
+		schemaType string
+		// schema is the schema node where the rule is defined, used to
+		// type the numbers given to the rule.
+		schema map[string]interface{}
+
+		rule       string
+		message    string
+		program    cel.Program
+		transition bool
+	}
+
+	// celRules indexes all x-kubernetes-validations rules by GroupVersionKind.
+	celRules map[schema.GroupVersionKind][]*celRule
+)
+
+// celEnv is the CEL environment used to compile all rules.
+var celEnv = mustCELEnv()
+
+// mustCELEnv returns the CEL environment used to compile all rules, and
+// panics if it cannot be built.
+func mustCELEnv() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Declarations(
+			decls.NewVar("self", decls.Dyn),
+			decls.NewVar("oldSelf", decls.Dyn),
+		),
+		ext.Strings(),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("unable to build the CEL environment: %s", err))
+	}
+	return env
+}
+
+// newCELRules loads all CustomResourceDefinitions available in the given paths
+// and compiles their x-kubernetes-validations rules.
+//
+// NOTE: x-kubernetes-validations is not known by the apiextensions types used to
+// validate custom resources, so rules are extracted from the raw definition.
+func newCELRules(paths ...string) (celRules, error) {
+	objs, err := helpers.ReadManifests(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := celRules{}
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		if gvk.Group != apiextensions.GroupName || gvk.Kind != "CustomResourceDefinition" {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+		globalSchema, _, _ := unstructured.NestedMap(obj.Object, "spec", "validation", "openAPIV3Schema")
+		versions, _, _ := unstructured.NestedSlice(obj.Object, "spec", "versions")
+
+		for _, version := range versions {
+			version, _ := version.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(version, "name")
+			openAPIV3Schema, found, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+			if !found {
+				openAPIV3Schema = globalSchema
+			}
+
+			vrules, err := compileCELRules(openAPIV3Schema, nil)
+			if err != nil {
+				return nil, fmt.Errorf("invalid x-kubernetes-validations for %s/%s: %w", obj.GetName(), name, err)
+			}
+			if len(vrules) > 0 {
+				rules[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = vrules
+			}
+		}
+	}
+	return rules, nil
+}
+
+// compileCELRules compiles all x-kubernetes-validations rules defined in the
+// given schema node and its children.
+func compileCELRules(node map[string]interface{}, path []string) ([]*celRule, error) {
+	if node == nil {
+		return nil, nil
+	}
+
+	var rules []*celRule
+	validations, _, _ := unstructured.NestedSlice(node, "x-kubernetes-validations")
+	for _, validation := range validations {
+		validation, _ := validation.(map[string]interface{})
+		expr, _, _ := unstructured.NestedString(validation, "rule")
+		message, _, _ := unstructured.NestedString(validation, "message")
+		schemaType, _, _ := unstructured.NestedString(node, "type")
+
+		ast, issues := celEnv.Compile(expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("rule '%s': %w", expr, issues.Err())
+		}
+		program, err := celEnv.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", expr, err)
+		}
+		transition := isTransitionRule(ast)
+		if transition && funk.ContainsString(path, celItems) {
+			// NOTE: array items are never correlated with the old object
+			return nil, fmt.Errorf("rule '%s': transition rules are not supported under array items", expr)
+		}
+
+		rules = append(rules, &celRule{
+			path:       path,
+			schemaType: schemaType,
+			schema:     node,
+			rule:       expr,
+			message:    message,
+			program:    program,
+			transition: transition,
+		})
+	}
+
+	// NOTE: children are sorted to always return errors in the same order
+	properties, _, _ := unstructured.NestedMap(node, "properties")
+	children := map[string]interface{}{celItems: node["items"], celAdditionalProperties: node["additionalProperties"]}
+	segments := make([]string, 0, len(properties))
+	for name, property := range properties {
+		segments = append(segments, name)
+		children[name] = property
+	}
+	sort.Strings(segments)
+
+	for _, segment := range append(segments, celItems, celAdditionalProperties) {
+		child, _ := children[segment].(map[string]interface{})
+		crules, err := compileCELRules(child, append(append([]string{}, path...), segment))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, crules...)
+	}
+	return rules, nil
+}
+
+// isTransitionRule returns true if the rule references oldSelf.
+func isTransitionRule(ast *cel.Ast) bool {
+	checked, err := cel.AstToCheckedExpr(ast)
+	if err != nil {
+		return false
+	}
+
+	for _, reference := range checked.ReferenceMap {
+		if reference.Name == "oldSelf" {
+			return true
+		}
+	}
+	return false
+}
+
+// validate walks through the given values following the rule path and
+// evaluates the rule on every matching value.
+func (rule *celRule) validate(self, oldSelf interface{}, path []string, fldPath *field.Path) field.ErrorList {
+	if self == nil {
+		return nil
+	}
+
+	if len(path) > 0 {
+		var errs field.ErrorList
+		switch path[0] {
+		case celItems:
+			items, _ := self.([]interface{})
+			for i, item := range items {
+				// NOTE: items are not correlated with the old object, so
+				//       transition rules are refused by compileCELRules
+				errs = append(errs, rule.validate(item, nil, path[1:], fldPath.Index(i))...)
+			}
+		case celAdditionalProperties:
+			values, _ := self.(map[string]interface{})
+			oldValues, _ := oldSelf.(map[string]interface{})
+			for key, value := range values {
+				errs = append(errs, rule.validate(value, oldValues[key], path[1:], fldPath.Key(key))...)
+			}
+		default:
+			values, _ := self.(map[string]interface{})
+			oldValues, _ := oldSelf.(map[string]interface{})
+			errs = rule.validate(values[path[0]], oldValues[path[0]], path[1:], fldPath.Child(path[0]))
+		}
+		return errs
+	}
+
+	if rule.transition && oldSelf == nil {
+		return nil
+	}
+
+	out, _, err := rule.program.Eval(map[string]interface{}{"self": celValue(self, rule.schema), "oldSelf": celValue(oldSelf, rule.schema)})
+	switch {
+	case err != nil:
+		return field.ErrorList{field.Invalid(fldPath, rule.schemaType, fmt.Sprintf("rule evaluation error: %s", err))}
+	case out != types.True:
+		return field.ErrorList{field.Invalid(fldPath, rule.schemaType, rule.errorMessage())}
+	}
+	return nil
+}
+
+// celValue returns a copy of the given value where the numbers are typed
+// following the given schema, like the API server does when decoding custom
+// resources, because CEL doesn't compare numbers of different types: the
+// integral numbers of the integer fields are converted to int64 (they are
+// float64 when decoded from JSON or YAML) and the numbers of the number
+// fields to float64.
+func celValue(value interface{}, schema map[string]interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		additionalProperties, _ := schema["additionalProperties"].(map[string]interface{})
+
+		values := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			property, isProperty := properties[key].(map[string]interface{})
+			if !isProperty {
+				property = additionalProperties
+			}
+			values[key] = celValue(v, property)
+		}
+		return values
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+
+		values := make([]interface{}, len(value))
+		for i, v := range value {
+			values[i] = celValue(v, items)
+		}
+		return values
+	case float64:
+		if schema["type"] == "integer" && value == math.Trunc(value) && math.Abs(value) < math.MaxInt64 {
+			return int64(value)
+		}
+	case int64:
+		if schema["type"] == "number" {
+			return float64(value)
+		}
+	}
+	return value
+}
+
+// errorMessage returns the message returned when the rule fails.
+func (rule *celRule) errorMessage() string {
+	if rule.message != "" {
+		return rule.message
+	}
+	return fmt.Sprintf("failed rule: %s", rule.rule)
+}
@@ -0,0 +1,105 @@
+package kubernetes_ctx_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+func TestWithCustomResourceValidation_InvalidRule(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithCustomResourceValidation("features_errors/resources/crds"),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid x-kubernetes-validations for gadgets.example.com/v1: rule 'self.spec.size() >'")
+}
+
+func TestWithCustomResourceValidation_TransitionRuleUnderItems(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crds")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "gizmos.yaml"), []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gizmos.example.com
+spec:
+  group: example.com
+  names: {kind: Gizmo, listKind: GizmoList, plural: gizmos}
+  scope: Namespaced
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            ports:
+              type: array
+              items:
+                type: integer
+                x-kubernetes-validations:
+                  - rule: self == oldSelf
+`), 0644))
+
+	_, err = kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithCustomResourceValidation(dir),
+	)
+	assert.EqualError(t, err, "invalid x-kubernetes-validations for gizmos.example.com/v1: rule 'self == oldSelf': transition rules are not supported under array items")
+}
+
+func TestCELValidation_Create(t *testing.T) {
+	ctx := initFakeScenarioWithCRDValidation(t)
+
+	err := ctx.Create(widgetGVK, widgetDefault, yamlToUnstructured(t, `spec: {image: nginx, replicas: 11}`))
+	require.Error(t, err)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), `spec: Invalid value: "object": replicas must be lower than or equal to 10`)
+}
+
+func TestCELValidation_TransitionRule(t *testing.T) {
+	ctx := initFakeScenarioWithCRDValidation(t)
+
+	// transition rules are ignored on creation
+	err := ctx.Create(widgetGVK, widgetDefault, yamlToUnstructured(t, `spec: {image: nginx}`))
+	require.NoError(t, err)
+
+	obj, err := getWidget(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(obj.Object, int64(2), "spec", "replicas"))
+	require.NoError(t, ctx.Client().Update(ctx.GoContext(), obj))
+
+	require.NoError(t, unstructured.SetNestedField(obj.Object, "httpd", "spec", "image"))
+	err = ctx.Client().Update(ctx.GoContext(), obj)
+	require.Error(t, err)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), `spec.image: Invalid value: "string": image is immutable`)
+}
+
+func TestCELValidation_NumberField(t *testing.T) {
+	ctx := initFakeScenarioWithCRDValidation(t)
+
+	// NOTE: integral numbers of number fields are compared as doubles
+	require.NoError(t, ctx.Create(widgetGVK, widgetDefault, yamlToUnstructured(t, `spec: {image: nginx, ratio: 1.0}`)))
+
+	obj, err := getWidget(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(obj.Object, int64(2), "spec", "ratio"))
+	err = ctx.Client().Update(ctx.GoContext(), obj)
+	require.Error(t, err)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), `spec.ratio: Invalid value: "number": ratio must be lower than or equal to 1.5`)
+}
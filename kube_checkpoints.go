@@ -0,0 +1,166 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeStore wraps the fake client in order to be able to replace its whole
+// content, without rebuilding the clients wrapping it.
+type fakeStore struct{ client.Client }
+
+// SaveCheckpoint keeps a deep copy of all objects stored by the fake client
+// under the given name, until the end of the scenario.
+func (ctx *FeatureContext) SaveCheckpoint(name string) error {
+	objs, err := ctx.storedObjects()
+	if err != nil {
+		return err
+	}
+
+	if ctx.checkpoints == nil {
+		ctx.checkpoints = map[string][]runtime.Object{}
+	}
+	ctx.checkpoints[name] = objs
+	return nil
+}
+
+// RestoreCheckpoint replaces all objects stored by the fake client by the
+// ones kept by the given checkpoint, including their resourceVersion.
+// Neither the emulated controllers nor the watches are notified.
+func (ctx *FeatureContext) RestoreCheckpoint(name string) error {
+	objs, err := ctx.checkpoint(name)
+	if err != nil {
+		return err
+	}
+
+	copies := make([]runtime.Object, len(objs))
+	for i, obj := range objs {
+		copies[i] = obj.DeepCopyObject()
+	}
+	ctx.fakeStore.Client = fake.NewFakeClientWithScheme(ctx.fakeScheme, copies...)
+	return nil
+}
+
+// DiffCheckpoint returns the differences between the objects stored by the
+// fake client and the ones kept by the given checkpoint, one line per added
+// (+), removed (-) or modified (~) object. The resourceVersion is ignored.
+func (ctx *FeatureContext) DiffCheckpoint(name string) ([]string, error) {
+	saved, err := ctx.checkpoint(name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := ctx.storedObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := ctx.indexObjects(saved)
+	if err != nil {
+		return nil, err
+	}
+	after, err := ctx.indexObjects(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []string
+	for key, obj := range after {
+		previous, exists := before[key]
+		switch {
+		case !exists:
+			diffs = append(diffs, "+ "+key)
+		case !equality.Semantic.DeepEqual(previous.Object, obj.Object):
+			diffs = append(diffs, fmt.Sprintf("~ %s: %s", key, diff.ObjectReflectDiff(previous.Object, obj.Object)))
+		}
+	}
+	for key := range before {
+		if _, exists := after[key]; !exists {
+			diffs = append(diffs, "- "+key)
+		}
+	}
+
+	// NOTE: the operation is ignored in order to keep each object at the same place
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i][2:] < diffs[j][2:] })
+	return diffs, nil
+}
+
+// checkpoint returns the objects kept by the given checkpoint.
+func (ctx *FeatureContext) checkpoint(name string) ([]runtime.Object, error) {
+	if ctx.fakeStore == nil {
+		return nil, fmt.Errorf("checkpoints are only supported by the fake client")
+	}
+
+	objs, exists := ctx.checkpoints[name]
+	if !exists {
+		return nil, fmt.Errorf("checkpoint '%s' not found", name)
+	}
+	return objs, nil
+}
+
+// storedObjects returns a deep copy of all objects stored by the fake
+// client, listed kind by kind.
+func (ctx *FeatureContext) storedObjects() ([]runtime.Object, error) {
+	if ctx.fakeStore == nil {
+		return nil, fmt.Errorf("checkpoints are only supported by the fake client")
+	}
+
+	kinds, err := ctx.listableKinds()
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []runtime.Object
+	for _, gvk := range kinds {
+		list, err := ctx.fakeScheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err != nil {
+			return nil, err
+		}
+		list.GetObjectKind().SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+		if err := ctx.fakeStore.List(ctx.ctx, list); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj := item.DeepCopyObject()
+			// NOTE: required by the fake client to restore Unstructured objects
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
+
+// indexObjects converts the given objects to Unstructured objects, indexed
+// by their kind and their name.
+func (ctx *FeatureContext) indexObjects(objs []runtime.Object) (map[string]*unstructured.Unstructured, error) {
+	index := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		gvk, err := gvkForObject(ctx.fakeScheme, obj)
+		if err != nil {
+			return nil, err
+		}
+		uobj, err := toUnstructured(gvk, obj.DeepCopyObject())
+		if err != nil {
+			return nil, err
+		}
+		uobj.SetResourceVersion("")
+
+		namespacedName := types.NamespacedName{Namespace: uobj.GetNamespace(), Name: uobj.GetName()}
+		key := fmt.Sprintf("%s/%s '%s'", gvk.GroupVersion(), gvk.Kind, namespacedName)
+		index[key] = uobj
+	}
+	return index, nil
+}
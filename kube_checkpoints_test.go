@@ -0,0 +1,63 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+func TestFeatureContext_Checkpoints(t *testing.T) {
+	ctx := initFakeScenarioWithNamespaces(t)
+	serviceGVK := namespaceGVK.GroupVersion().WithKind("Service")
+	svc := types.NamespacedName{Namespace: "default", Name: "svc"}
+
+	require.NoError(t, ctx.Create(serviceGVK, svc, &unstructured.Unstructured{}))
+	before, err := ctx.Get(serviceGVK, svc)
+	require.NoError(t, err)
+	require.NoError(t, ctx.SaveCheckpoint("initial"))
+
+	diffs, err := ctx.DiffCheckpoint("initial")
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	require.NoError(t, ctx.Patch(serviceGVK, svc, types.MergePatchType, []byte(`{"metadata":{"labels":{"key":"value"}}}`)))
+	_, err = ctx.Delete(namespaceGVK, types.NamespacedName{Name: "kube-public"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Create(serviceGVK, types.NamespacedName{Namespace: "default", Name: "other"}, &unstructured.Unstructured{}))
+
+	diffs, err = ctx.DiffCheckpoint("initial")
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+	assert.Equal(t, "- v1/Namespace '/kube-public'", diffs[0])
+	assert.Equal(t, "+ v1/Service 'default/other'", diffs[1])
+	assert.Contains(t, diffs[2], "~ v1/Service 'default/svc': ")
+
+	require.NoError(t, ctx.RestoreCheckpoint("initial"))
+	diffs, err = ctx.DiffCheckpoint("initial")
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	// restored resources keep their resourceVersion
+	after, err := ctx.Get(serviceGVK, svc)
+	require.NoError(t, err)
+	assert.Equal(t, before.GetResourceVersion(), after.GetResourceVersion())
+	_, err = ctx.Get(serviceGVK, types.NamespacedName{Namespace: "default", Name: "other"})
+	assert.True(t, errors.IsNotFound(err))
+
+	assert.EqualError(t, ctx.RestoreCheckpoint("unknown"), "checkpoint 'unknown' not found")
+}
+
+func TestFeatureContext_Checkpoints_NotFakeClient(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(scenarioContextMock, kubernetes_ctx.WithClient(scheme, client))
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	assert.EqualError(t, ctx.SaveCheckpoint("initial"), "checkpoints are only supported by the fake client")
+}
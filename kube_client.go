@@ -1,7 +1,12 @@
 package kubernetes_ctx
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -21,10 +26,13 @@ func (ctx *FeatureContext) Create(
 ) error {
 	obj.SetGroupVersionKind(groupVersionKind)
 	obj.SetUID(types.UID(uuid.New().String()))
+	if creationTimestamp := obj.GetCreationTimestamp(); creationTimestamp.IsZero() {
+		obj.SetCreationTimestamp(ctx.now())
+	}
 	obj.SetName(namespacedName.Name)
 	obj.SetNamespace(namespacedName.Namespace)
 
-	kobj, err := ctx.scheme.New(groupVersionKind)
+	kobj, err := ctx.newObject(groupVersionKind)
 	if err != nil {
 		return err
 	}
@@ -43,7 +51,27 @@ func (ctx *FeatureContext) Get(
 	groupVersionKind schema.GroupVersionKind,
 	namespacedName types.NamespacedName,
 ) (*unstructured.Unstructured, error) {
-	kobj, err := ctx.get(groupVersionKind, namespacedName)
+	return ctx.getWith(ctx.ctx, groupVersionKind, namespacedName)
+}
+
+// get fetches the Kubernetes resource like Get, but as a read issued by the
+// feature context itself (like the ones of the assertion steps), which is
+// neither recorded nor affected by the injected faults.
+func (ctx *FeatureContext) get(
+	groupVersionKind schema.GroupVersionKind,
+	namespacedName types.NamespacedName,
+) (*unstructured.Unstructured, error) {
+	return ctx.getWith(ctx.internalContext(), groupVersionKind, namespacedName)
+}
+
+// getWith fetches the Kubernetes resource with the given golang context and
+// returns an Unstructured object.
+func (ctx *FeatureContext) getWith(
+	goctx context.Context,
+	groupVersionKind schema.GroupVersionKind,
+	namespacedName types.NamespacedName,
+) (*unstructured.Unstructured, error) {
+	kobj, err := ctx.getObject(goctx, groupVersionKind, namespacedName)
 	if err != nil {
 		return nil, err
 	}
@@ -53,19 +81,20 @@ func (ctx *FeatureContext) Get(
 	return &obj, err
 }
 
-// Get fetches the Kubernetes resource using the given APIVersion/Kind and
-// the name. It wraps the Get method of the "official" Kubernetes client.Client
-// interface, and returns a runtime.Object.
-func (ctx *FeatureContext) get(
+// getObject fetches the Kubernetes resource using the given APIVersion/Kind
+// and the name. It wraps the Get method of the "official" Kubernetes
+// client.Client interface, and returns a runtime.Object.
+func (ctx *FeatureContext) getObject(
+	goctx context.Context,
 	groupVersionKind schema.GroupVersionKind,
 	namespacedName types.NamespacedName,
 ) (runtime.Object, error) {
-	kobj, err := ctx.scheme.New(groupVersionKind)
+	kobj, err := ctx.newObject(groupVersionKind)
 	if err != nil {
 		return nil, err
 	}
 
-	err = ctx.client.Get(ctx.ctx, namespacedName, kobj)
+	err = ctx.client.Get(goctx, namespacedName, kobj)
 	if err != nil {
 		return nil, err
 	}
@@ -79,34 +108,43 @@ func (ctx *FeatureContext) List(
 	groupVersionKind schema.GroupVersionKind,
 	opts ...client.ListOption,
 ) ([]*unstructured.Unstructured, error) {
-	// NOTE: can be dangerous but seems working...
-	groupVersionKind.Kind += "List"
+	return ctx.listWith(ctx.ctx, ctx.client, groupVersionKind, opts...)
+}
 
-	kobj, err := ctx.scheme.New(groupVersionKind)
-	if err != nil {
-		return nil, err
-	}
+// list returns all Kubernetes resources like List, but as a read issued by
+// the feature context itself (see get).
+func (ctx *FeatureContext) list(
+	groupVersionKind schema.GroupVersionKind,
+	opts ...client.ListOption,
+) ([]*unstructured.Unstructured, error) {
+	return ctx.listWith(ctx.internalContext(), ctx.client, groupVersionKind, opts...)
+}
 
-	err = ctx.client.List(ctx.ctx, kobj, opts...)
-	if err != nil {
+// listWith lists the Kubernetes resources through the given client, with
+// the given golang context.
+func (ctx *FeatureContext) listWith(
+	goctx context.Context,
+	c client.Client,
+	groupVersionKind schema.GroupVersionKind,
+	opts ...client.ListOption,
+) ([]*unstructured.Unstructured, error) {
+	if _, err := ctx.restMapping(groupVersionKind); err != nil {
 		return nil, err
 	}
 
-	list := unstructured.Unstructured{}
-	list.Object, err = runtime.DefaultUnstructuredConverter.ToUnstructured(kobj)
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(groupVersionKind.GroupVersion().WithKind(groupVersionKind.Kind + "List"))
+
+	err := c.List(goctx, list, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	if !list.IsList() {
-		return nil, nil
-	}
-
 	var objs []*unstructured.Unstructured
-	return objs, list.EachListItem(func(object runtime.Object) error {
-		objs = append(objs, object.(*unstructured.Unstructured))
-		return nil
-	})
+	for i := range list.Items {
+		objs = append(objs, &list.Items[i])
+	}
+	return objs, nil
 }
 
 // Update updates a Kubernetes resource based on the given APIVersion/Kind
@@ -121,7 +159,7 @@ func (ctx *FeatureContext) Update(
 	obj.SetName(namespacedName.Name)
 	obj.SetNamespace(namespacedName.Namespace)
 
-	kobj, err := ctx.scheme.New(obj.GroupVersionKind())
+	kobj, err := ctx.newObject(groupVersionKind)
 	if err != nil {
 		return err
 	}
@@ -131,22 +169,28 @@ func (ctx *FeatureContext) Update(
 		return err
 	}
 
-	return ctx.client.Update(ctx.ctx, obj, opts...)
+	return ctx.client.Update(ctx.ctx, kobj, opts...)
 }
 
 // Patch patches a Kubernetes resource based on the given APIVersion/Kind
-// and the name with the given Patch value.
+// and the name with the given Patch value. Because the API server doesn't
+// support them on custom resources, strategic merge patches are converted
+// to merge patches on kinds registered as Unstructured.
 func (ctx *FeatureContext) Patch(
 	groupVersionKind schema.GroupVersionKind,
 	namespacedName types.NamespacedName,
 	pt types.PatchType,
 	data []byte,
 ) error {
-	obj, err := ctx.get(groupVersionKind, namespacedName)
+	obj, err := ctx.getObject(ctx.internalContext(), groupVersionKind, namespacedName)
 	if err != nil {
 		return err
 	}
 
+	if _, isUnstructured := obj.(runtime.Unstructured); isUnstructured && pt == types.StrategicMergePatchType {
+		pt = types.MergePatchType
+	}
+
 	return ctx.client.Patch(ctx.ctx, obj, client.RawPatch(pt, data))
 }
 
@@ -162,6 +206,11 @@ func (ctx *FeatureContext) Delete(
 		return nil, err
 	}
 
+	if ctx.finalizers && len(obj.GetFinalizers()) > 0 {
+		// NOTE: objects with finalizers are only marked as being deleted;
+		//       they are garbage collected once removed (see WithFinalizers)
+		return obj, nil
+	}
 	return obj, ctx.callGC(obj)
 }
 
@@ -172,7 +221,7 @@ func (ctx *FeatureContext) DeleteWithoutGC(
 	groupVersionKind schema.GroupVersionKind,
 	namespacedName types.NamespacedName,
 ) (*unstructured.Unstructured, error) {
-	kobj, err := ctx.get(groupVersionKind, namespacedName)
+	kobj, err := ctx.getObject(ctx.internalContext(), groupVersionKind, namespacedName)
 	if err != nil {
 		return nil, err
 	}
@@ -185,3 +234,99 @@ func (ctx *FeatureContext) DeleteWithoutGC(
 
 	return &obj, ctx.client.Delete(ctx.ctx, kobj)
 }
+
+// seedInitialObjects creates the initial objects directly inside the
+// client, before any interceptor.
+func (ctx *FeatureContext) seedInitialObjects() error {
+	for _, initialObject := range ctx.initialObjects {
+		groupVersionKind, err := gvkForObject(ctx.scheme, initialObject)
+		if err != nil {
+			return err
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.Object, err = runtime.DefaultUnstructuredConverter.ToUnstructured(initialObject.DeepCopyObject())
+		if err != nil {
+			return err
+		}
+		obj.SetGroupVersionKind(groupVersionKind)
+		obj.SetResourceVersion("")
+		if obj.GetUID() == "" {
+			obj.SetUID(types.UID(uuid.New().String()))
+		}
+		if creationTimestamp := obj.GetCreationTimestamp(); creationTimestamp.IsZero() {
+			obj.SetCreationTimestamp(ctx.now())
+		}
+
+		kobj, err := ctx.newObject(groupVersionKind)
+		if err != nil {
+			return err
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, kobj); err != nil {
+			return err
+		}
+		if err := ctx.client.Create(ctx.ctx, kobj); err != nil {
+			return fmt.Errorf("unable to create the initial object %s/%s '%s': %w", groupVersionKind.GroupVersion(), groupVersionKind.Kind, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, err)
+		}
+	}
+	return nil
+}
+
+// ListAll returns all Kubernetes resources of all kinds registered inside
+// the scheme, filtered by the given options. Only the namespaced kinds are
+// listed when a namespace is given. Resources served through several
+// versions are returned once, with the first version found. Resources served
+// by several API groups (like v1/Event and events.k8s.io/v1beta1/Event) are
+// returned once per group, because the groups can't be correlated.
+func (ctx *FeatureContext) ListAll(opts ...client.ListOption) ([]*unstructured.Unstructured, error) {
+	return ctx.listAllWith(ctx.ctx, ctx.client, opts...)
+}
+
+// listAll returns all Kubernetes resources like ListAll, but as reads issued
+// by the feature context itself (see get).
+func (ctx *FeatureContext) listAll(opts ...client.ListOption) ([]*unstructured.Unstructured, error) {
+	return ctx.listAllWith(ctx.internalContext(), ctx.client, opts...)
+}
+
+// listAllWith lists the Kubernetes resources of all kinds through the given
+// client, with the given golang context.
+func (ctx *FeatureContext) listAllWith(goctx context.Context, c client.Client, opts ...client.ListOption) ([]*unstructured.Unstructured, error) {
+	kinds, err := ctx.listableKinds()
+	if err != nil {
+		return nil, err
+	}
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	var objs []*unstructured.Unstructured
+	listed := map[string]bool{}
+	for _, groupVersionKind := range kinds {
+		mapping, err := ctx.restMapping(groupVersionKind)
+		if err != nil {
+			return nil, err
+		}
+		if listOpts.Namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			continue
+		}
+
+		kobjs, err := ctx.listWith(goctx, c, groupVersionKind, opts...)
+		switch {
+		case errors.IsNotFound(err) || errors.IsMethodNotSupported(err) || meta.IsNoMatchError(err):
+			// NOTE: kinds known by the scheme are not always served
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		for _, obj := range kobjs {
+			key := fmt.Sprintf("%s/%s/%s", groupVersionKind.GroupKind(), obj.GetNamespace(), obj.GetName())
+			if listed[key] {
+				continue
+			}
+			listed[key] = true
+			obj.SetGroupVersionKind(groupVersionKind)
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
@@ -2,13 +2,22 @@ package kubernetes_ctx_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
 )
 
 var (
@@ -34,7 +43,8 @@ func TestFeatureContext_Create(t *testing.T) {
 func TestFeatureContext_Create_KindNotFound(t *testing.T) {
 	ctx := initFakeScenario(t)
 	err := ctx.Create(notFoundGVK, namespaceDefault, &unstructured.Unstructured{})
-	assert.EqualError(t, err, "no kind \"NotFound\" is registered for version \"v1\" in scheme \"pkg/runtime/scheme.go:101\"")
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+	assert.EqualError(t, err, "unknown kind v1/NotFound: it must be registered in the scheme, defined by a CustomResourceDefinition or served by the API server")
 }
 
 func TestFeatureContext_Create_ResourceAlreadyExists(t *testing.T) {
@@ -53,7 +63,8 @@ func TestFeatureContext_Get(t *testing.T) {
 func TestFeatureContext_Get_KindNotFound(t *testing.T) {
 	ctx := initFakeScenario(t)
 	_, err := ctx.Get(notFoundGVK, namespaceDefault)
-	assert.EqualError(t, err, "no kind \"NotFound\" is registered for version \"v1\" in scheme \"pkg/runtime/scheme.go:101\"")
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+	assert.EqualError(t, err, "unknown kind v1/NotFound: it must be registered in the scheme, defined by a CustomResourceDefinition or served by the API server")
 }
 
 func TestFeatureContext_Get_ResourceNotFound(t *testing.T) {
@@ -73,7 +84,24 @@ func TestFeatureContext_List(t *testing.T) {
 func TestFeatureContext_List_KindNotFound(t *testing.T) {
 	ctx := initFakeScenario(t)
 	_, err := ctx.List(notFoundGVK)
-	assert.EqualError(t, err, "no kind \"NotFoundList\" is registered for version \"v1\" in scheme \"pkg/runtime/scheme.go:101\"")
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+	assert.EqualError(t, err, "unknown kind v1/NotFound: it must be registered in the scheme, defined by a CustomResourceDefinition or served by the API server")
+}
+
+func TestFeatureContext_ListAll(t *testing.T) {
+	ctx := initFakeScenarioWithNamespaces(t)
+	serviceGVK := schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+	require.NoError(t, ctx.Create(serviceGVK, types.NamespacedName{Namespace: "default", Name: "svc"}, &unstructured.Unstructured{}))
+
+	objs, err := ctx.ListAll()
+	require.NoError(t, err)
+	assert.Len(t, objs, 4)
+
+	objs, err = ctx.ListAll(ctrlclient.InNamespace("default"))
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	assert.Equal(t, serviceGVK, objs[0].GroupVersionKind())
+	assert.Equal(t, "svc", objs[0].GetName())
 }
 
 func TestFeatureContext_Update(t *testing.T) {
@@ -97,7 +125,8 @@ func TestFeatureContext_Update(t *testing.T) {
 func TestFeatureContext_Update_KindNotFound(t *testing.T) {
 	ctx := initFakeScenario(t)
 	err := ctx.Update(notFoundGVK, namespaceDefault, &unstructured.Unstructured{})
-	assert.EqualError(t, err, "no kind \"NotFound\" is registered for version \"v1\" in scheme \"pkg/runtime/scheme.go:101\"")
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+	assert.EqualError(t, err, "unknown kind v1/NotFound: it must be registered in the scheme, defined by a CustomResourceDefinition or served by the API server")
 }
 
 func TestFeatureContext_Update_ResourceNotFound(t *testing.T) {
@@ -145,7 +174,8 @@ func TestFeatureContext_Delete(t *testing.T) {
 func TestFeatureContext_Delete_KindNotFound(t *testing.T) {
 	ctx := initFakeScenario(t)
 	_, err := ctx.Delete(notFoundGVK, namespaceDefault)
-	assert.EqualError(t, err, "no kind \"NotFound\" is registered for version \"v1\" in scheme \"pkg/runtime/scheme.go:101\"")
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+	assert.EqualError(t, err, "unknown kind v1/NotFound: it must be registered in the scheme, defined by a CustomResourceDefinition or served by the API server")
 }
 
 func TestFeatureContext_Delete_ResourceNotFound(t *testing.T) {
@@ -153,3 +183,159 @@ func TestFeatureContext_Delete_ResourceNotFound(t *testing.T) {
 	_, err := ctx.Delete(namespaceGVK, namespaceDefault)
 	assert.EqualError(t, err, "namespaces \"default\" not found")
 }
+
+func TestFeatureContext_Create_WithClock(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := initFakeScenario(t, kubernetes_ctx.WithClock(fakeClock))
+
+	fakeClock.Step(time.Hour)
+	require.NoError(t, ctx.Create(namespaceGVK, namespaceDefault, &unstructured.Unstructured{}))
+	obj, err := ctx.Get(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	assert.True(t, fakeClock.Now().Equal(obj.GetCreationTimestamp().Time))
+}
+
+func TestFeatureContext_Delete_WithFinalizers(t *testing.T) {
+	fakeClock := newFakeClock()
+	var collected []string
+	ctx := initFakeScenario(t,
+		kubernetes_ctx.WithClock(fakeClock),
+		kubernetes_ctx.WithFinalizers(),
+		kubernetes_ctx.WithCustomGarbageCollector(func(_ *kubernetes_ctx.FeatureContext, obj *unstructured.Unstructured) error {
+			collected = append(collected, obj.GetName())
+			return nil
+		}),
+	)
+	require.NoError(t, ctx.Create(namespaceGVK, namespaceDefault, yamlToUnstructured(t, `metadata: {finalizers: [example.com/protection]}`)))
+
+	fakeClock.Step(time.Hour)
+	_, err := ctx.Delete(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	obj, err := ctx.Get(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	require.NotNil(t, obj.GetDeletionTimestamp())
+	assert.True(t, fakeClock.Now().Equal(obj.GetDeletionTimestamp().Time))
+	assert.Empty(t, collected)
+
+	// the deletionTimestamp can't be changed by the next writes
+	fakeClock.Step(time.Hour)
+	_, err = ctx.Delete(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Patch(namespaceGVK, namespaceDefault, types.MergePatchType, []byte(`{"metadata":{"deletionTimestamp":null}}`)))
+	obj, err = ctx.Get(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	require.NotNil(t, obj.GetDeletionTimestamp())
+	assert.True(t, fakeClock.Now().Add(-time.Hour).Equal(obj.GetDeletionTimestamp().Time))
+
+	require.NoError(t, ctx.Patch(namespaceGVK, namespaceDefault, types.MergePatchType, []byte(`{"metadata":{"finalizers":null}}`)))
+	_, err = ctx.Get(namespaceGVK, namespaceDefault)
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, []string{"default"}, collected)
+}
+
+func TestWithFinalizers_RealClient(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithClient(clientgoscheme.Scheme, fake.NewFakeClientWithScheme(clientgoscheme.Scheme)),
+		kubernetes_ctx.WithFinalizers(),
+	)
+	assert.EqualError(t, err, "finalizers can only be emulated on the fake client")
+}
+
+func TestWithInitialObjects(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithClock(fakeClock),
+		kubernetes_ctx.WithInitialObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			yamlToUnstructured(t, `{apiVersion: v1, kind: ConfigMap, metadata: {name: shared, namespace: default, uid: fixed}}`),
+		),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		// objects are created again for each scenario
+		scenarioContextMock.RunScenario()
+
+		namespace, err := ctx.Get(namespaceGVK, namespaceDefault)
+		require.NoError(t, err)
+		assert.NotEmpty(t, namespace.GetUID())
+		assert.True(t, fakeClock.Now().Equal(namespace.GetCreationTimestamp().Time))
+
+		configMap, err := ctx.Get(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, types.NamespacedName{Namespace: "default", Name: "shared"})
+		require.NoError(t, err)
+		assert.Equal(t, types.UID("fixed"), configMap.GetUID())
+
+		_, err = ctx.Delete(namespaceGVK, namespaceDefault)
+		require.NoError(t, err)
+	}
+}
+
+func TestWithFixtureDir(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithFixtureDir("features/resources/fixtures"),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	namespaces, err := ctx.List(namespaceGVK)
+	require.NoError(t, err)
+	require.Len(t, namespaces, 2)
+	assert.Equal(t, "default", namespaces[0].GetName())
+	assert.Equal(t, map[string]string{"key": "value"}, namespaces[1].GetLabels())
+
+	service, err := ctx.Get(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, types.NamespacedName{Namespace: "default", Name: "kubernetes"})
+	require.NoError(t, err)
+	assert.Equal(t, types.UID("2c9a7b9e-2f0c-4a57-9f0e-6c7f5f0f4a3d"), service.GetUID())
+}
+
+func TestWithFixtureDir_AlreadyExists(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithFixtureDir("features/resources/fixtures"),
+		kubernetes_ctx.WithFixtureDir("features/resources/fixtures/services.json"),
+	)
+	assert.EqualError(t, err, "unable to create the initial object v1/Service 'default/kubernetes': services \"kubernetes\" already exists")
+}
+
+func TestWithInitialObjects_RealClient(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithClient(scheme, client),
+		kubernetes_ctx.WithFixtureDir("features/resources/fixtures"),
+	)
+	assert.EqualError(t, err, "initial objects can only be seeded on the fake client")
+}
+
+func TestWithInitialObjects_SetupFailure(t *testing.T) {
+	// the fake client is only used by the preflight checks
+	preflight := true
+	withClient := kubernetes_ctx.FeatureContextOptionFnc(func(ctx *kubernetes_ctx.FeatureContext) {
+		if preflight {
+			kubernetes_ctx.WithFakeRuntimeClient()(ctx)
+			preflight = false
+			return
+		}
+		kubernetes_ctx.WithClient(clientgoscheme.Scheme, fake.NewFakeClientWithScheme(clientgoscheme.Scheme))(ctx)
+	})
+
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		withClient,
+		kubernetes_ctx.WithInitialObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	_, err = ctx.Get(namespaceGVK, namespaceDefault)
+	assert.EqualError(t, err, "unable to set up the feature context: initial objects can only be seeded on the fake client")
+}
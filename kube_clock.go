@@ -0,0 +1,60 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"time"
+)
+
+// steppableClock is a clock which can be driven by the feature context, like
+// clock.FakeClock.
+type steppableClock interface {
+	Step(time.Duration)
+	SetTime(time.Time)
+}
+
+// AdvanceTime moves the fake clock of the feature context forward by the
+// given duration, then notifies all emulators depending on the time (like
+// the CronJob controller).
+func (ctx *FeatureContext) AdvanceTime(duration time.Duration) error {
+	fakeClock, err := ctx.fakeClock()
+	if err != nil {
+		return err
+	}
+
+	fakeClock.Step(duration)
+	return ctx.emulateTime()
+}
+
+// SetTime sets the fake clock of the feature context to the given time, then
+// notifies all emulators depending on the time (like the CronJob
+// controller).
+func (ctx *FeatureContext) SetTime(t time.Time) error {
+	fakeClock, err := ctx.fakeClock()
+	if err != nil {
+		return err
+	}
+
+	fakeClock.SetTime(t)
+	return ctx.emulateTime()
+}
+
+// spendTime waits for the given duration on the feature context clock. A
+// fake clock is advanced through AdvanceTime, in order to notify the
+// emulators depending on the time, unless the emulators are already running.
+func (ctx *FeatureContext) spendTime(duration time.Duration) error {
+	if _, isSteppable := ctx.clock.(steppableClock); !isSteppable || ctx.emulating {
+		ctx.clock.Sleep(duration)
+		return nil
+	}
+	return ctx.AdvanceTime(duration)
+}
+
+// fakeClock returns the feature context clock if it can be driven by the
+// feature context.
+func (ctx *FeatureContext) fakeClock() (steppableClock, error) {
+	fakeClock, isSteppable := ctx.clock.(steppableClock)
+	if !isSteppable {
+		return nil, fmt.Errorf("time can only be changed with a fake clock (see WithClock), not %T", ctx.clock)
+	}
+	return fakeClock, nil
+}
@@ -0,0 +1,165 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-openapi/validate"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	structuraldefaulting "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
+	apiservervalidation "k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+type (
+	// customResourceSchema contains everything required to validate, default
+	// and prune a custom resource, like the API server does.
+	customResourceSchema struct {
+		structural            *structuralschema.Structural
+		validator             *validate.SchemaValidator
+		rules                 []*celRule
+		preserveUnknownFields bool
+	}
+
+	// customResourceSchemas indexes all known custom resource schemas by
+	// GroupVersionKind. It implements the admissionPlugin interface.
+	customResourceSchemas map[schema.GroupVersionKind]*customResourceSchema
+)
+
+// newCustomResourceSchemas loads all CustomResourceDefinitions available in the
+// given paths and extracts the schema and the x-kubernetes-validations rules
+// of each version.
+func newCustomResourceSchemas(paths ...string) (customResourceSchemas, error) {
+	crds, err := helpers.LoadCustomResourceDefinitions(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := newCELRules(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := customResourceSchemas{}
+	for _, crd := range crds {
+		for _, version := range crd.Spec.Versions {
+			validation, err := apiextensions.GetSchemaForVersion(crd, version.Name)
+			if err != nil {
+				return nil, err
+			}
+			if validation == nil || validation.OpenAPIV3Schema == nil {
+				continue
+			}
+
+			structural, err := structuralschema.NewStructural(validation.OpenAPIV3Schema)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema for %s/%s: %w", crd.Name, version.Name, err)
+			}
+
+			validator, _, err := apiservervalidation.NewSchemaValidator(validation)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema for %s/%s: %w", crd.Name, version.Name, err)
+			}
+
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			schemas[gvk] = &customResourceSchema{
+				structural:            structural,
+				validator:             validator,
+				rules:                 rules[gvk],
+				preserveUnknownFields: crd.Spec.PreserveUnknownFields != nil && *crd.Spec.PreserveUnknownFields,
+			}
+		}
+	}
+	return schemas, nil
+}
+
+// Handles returns true if a schema exists for the given kind.
+func (schemas customResourceSchemas) Handles(gvk schema.GroupVersionKind) bool {
+	_, exists := schemas[gvk]
+	return exists
+}
+
+// Admit defaults, prunes and validates the given custom resource, evaluating
+// also the x-kubernetes-validations rules (transition rules are only evaluated
+// on update). It returns an Invalid error if the resource doesn't match its
+// schema.
+func (schemas customResourceSchemas) Admit(_ *FeatureContext, _ admissionOperation, obj, old *unstructured.Unstructured) error {
+	crs := schemas[obj.GroupVersionKind()]
+
+	structuraldefaulting.Default(obj.Object, crs.structural)
+	if !crs.preserveUnknownFields {
+		pruning.Prune(obj.Object, crs.structural, true)
+	}
+
+	var oldObject interface{}
+	if old != nil {
+		oldObject = old.Object
+	}
+
+	errs := apiservervalidation.ValidateCustomResource(nil, obj.Object, crs.validator)
+	for _, rule := range crs.rules {
+		errs = append(errs, rule.validate(obj.Object, oldObject, rule.path, nil)...)
+	}
+	if len(errs) > 0 {
+		return errors.NewInvalid(obj.GroupVersionKind().GroupKind(), obj.GetName(), errs)
+	}
+	return nil
+}
+
+// schemeRegistrationLock avoids concurrent registration of custom resources.
+var schemeRegistrationLock sync.Mutex
+
+// registerCustomResources registers all kinds defined by the given
+// CustomResourceDefinitions as Unstructured inside the scheme, if they are
+// not already known. The global client-go scheme is refused, because it is
+// shared by the whole process.
+func registerCustomResources(s Scheme, crds []*apiextensions.CustomResourceDefinition) error {
+	rscheme, isRuntimeScheme := s.(*runtime.Scheme)
+	if !isRuntimeScheme {
+		return fmt.Errorf("custom resources can only be registered inside a *runtime.Scheme (current: %T)", s)
+	}
+	if rscheme == clientgoscheme.Scheme {
+		return fmt.Errorf("custom resources cannot be registered inside the client-go global scheme; use a dedicated scheme")
+	}
+
+	schemeRegistrationLock.Lock()
+	defer schemeRegistrationLock.Unlock()
+
+	for _, crd := range crds {
+		listKind := crd.Spec.Names.ListKind
+		if listKind == "" {
+			listKind = crd.Spec.Names.Kind + "List"
+		}
+
+		for _, version := range crd.Spec.Versions {
+			groupVersion := schema.GroupVersion{Group: crd.Spec.Group, Version: version.Name}
+
+			if !rscheme.Recognizes(groupVersion.WithKind(crd.Spec.Names.Kind)) {
+				rscheme.AddKnownTypeWithName(groupVersion.WithKind(crd.Spec.Names.Kind), &unstructured.Unstructured{})
+			}
+			if !rscheme.Recognizes(groupVersion.WithKind(listKind)) {
+				rscheme.AddKnownTypeWithName(groupVersion.WithKind(listKind), &unstructured.UnstructuredList{})
+			}
+		}
+	}
+	return nil
+}
+
+// copyScheme returns a new scheme knowing all types of the given one.
+// Conversion and defaulting functions are not copied.
+func copyScheme(s *runtime.Scheme) *runtime.Scheme {
+	copied := runtime.NewScheme()
+	for gvk, t := range s.AllKnownTypes() {
+		copied.AddKnownTypeWithName(gvk, reflect.New(t).Interface().(runtime.Object))
+	}
+	return copied
+}
@@ -0,0 +1,186 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+var (
+	widgetGVK     = schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+	widgetDefault = types.NamespacedName{Namespace: "default", Name: "widget"}
+)
+
+// initFakeScenarioWithCRDValidation generates a godoc ScenarioContext and
+// a FeatureContext with a fake client validating the Widget custom resource.
+func initFakeScenarioWithCRDValidation(t *testing.T) *kubernetes_ctx.FeatureContext {
+	return initFakeScenario(t,
+		kubernetes_ctx.WithFakeClient(runtime.NewScheme()),
+		kubernetes_ctx.WithCustomResourceDefinitions("features/resources/crds"),
+		kubernetes_ctx.WithCustomResourceValidation("features/resources/crds"),
+	)
+}
+
+// getWidget returns the default Widget.
+func getWidget(ctx *kubernetes_ctx.FeatureContext) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(widgetGVK)
+	return obj, ctx.Client().Get(ctx.GoContext(), widgetDefault, obj)
+}
+
+// initFakeScenarioWithCRDs generates a godoc ScenarioContext and a
+// FeatureContext with a fake client knowing the Widget custom resource.
+func initFakeScenarioWithCRDs(t *testing.T) *kubernetes_ctx.FeatureContext {
+	return initFakeScenario(t,
+		kubernetes_ctx.WithFakeClient(featureScheme()),
+		kubernetes_ctx.WithCustomResourceDefinitions("features/resources/crds"),
+	)
+}
+
+func TestWithCustomResourceDefinitions(t *testing.T) {
+	ctx := initFakeScenarioWithCRDs(t)
+	scheme := ctx.Scheme().(*runtime.Scheme)
+
+	assert.True(t, scheme.Recognizes(widgetGVK))
+	assert.True(t, scheme.Recognizes(widgetGVK.GroupVersion().WithKind("WidgetList")))
+}
+
+func TestWithCustomResourceDefinitions_InvalidScheme(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithClient(struct{ kubernetes_ctx.Scheme }{}, client),
+		kubernetes_ctx.WithCustomResourceDefinitions("features/resources/crds"),
+	)
+	assert.EqualError(t, err, "custom resources can only be registered inside a *runtime.Scheme (current: struct { kubernetes_ctx.Scheme })")
+}
+
+func TestWithCustomResourceDefinitions_SharedScheme(t *testing.T) {
+	ctx := initFakeScenario(t, kubernetes_ctx.WithCustomResourceDefinitions("features/resources/crds"))
+
+	assert.True(t, ctx.Scheme().(*runtime.Scheme).Recognizes(widgetGVK))
+	assert.False(t, clientgoscheme.Scheme.Recognizes(widgetGVK), "the client-go scheme must not be modified")
+	require.NoError(t, ctx.Create(widgetGVK, widgetDefault, &unstructured.Unstructured{}))
+	_, err := ctx.List(widgetGVK)
+	assert.NoError(t, err)
+}
+
+func TestWithCustomResourceDefinitions_GlobalScheme(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithClient(clientgoscheme.Scheme, fake.NewFakeClientWithScheme(clientgoscheme.Scheme)),
+		kubernetes_ctx.WithCustomResourceDefinitions("features/resources/crds"),
+	)
+	assert.EqualError(t, err, "custom resources cannot be registered inside the client-go global scheme; use a dedicated scheme")
+}
+
+func TestCustomResource_EndToEnd(t *testing.T) {
+	ctx := initFakeScenarioWithCRDs(t)
+
+	err := ctx.Create(widgetGVK, widgetDefault, yamlToUnstructured(t, `spec: {image: nginx}`))
+	require.NoError(t, err)
+
+	obj, err := ctx.Get(widgetGVK, widgetDefault)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", obj.Object["spec"].(map[string]interface{})["image"])
+
+	objs, err := ctx.List(widgetGVK)
+	require.NoError(t, err)
+	assert.Len(t, objs, 1)
+
+	err = ctx.Patch(widgetGVK, widgetDefault, types.StrategicMergePatchType, []byte(`{"spec":{"image":"httpd"}}`))
+	require.NoError(t, err)
+	obj, err = ctx.Get(widgetGVK, widgetDefault)
+	require.NoError(t, err)
+	assert.Equal(t, "httpd", obj.Object["spec"].(map[string]interface{})["image"])
+
+	_, err = ctx.Delete(widgetGVK, widgetDefault)
+	require.NoError(t, err)
+	_, err = ctx.Get(widgetGVK, widgetDefault)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestWithCustomResourceValidation_InvalidPath(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithCustomResourceValidation("features/resources/unknown"),
+	)
+	assert.EqualError(t, err, "stat features/resources/unknown: no such file or directory")
+}
+
+func TestCustomResourceValidation_Create(t *testing.T) {
+	ctx := initFakeScenarioWithCRDValidation(t)
+
+	obj := yamlToUnstructured(t, `
+spec:
+  image: nginx
+  unknown: field`)
+	err := ctx.Create(widgetGVK, widgetDefault, obj)
+	require.NoError(t, err)
+
+	obj, err = getWidget(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"image": "nginx", "replicas": int64(1)}, obj.Object["spec"])
+}
+
+func TestCustomResourceValidation_Create_Invalid(t *testing.T) {
+	ctx := initFakeScenarioWithCRDValidation(t)
+
+	obj := yamlToUnstructured(t, `
+spec:
+  replicas: 0`)
+	err := ctx.Create(widgetGVK, widgetDefault, obj)
+	require.Error(t, err)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), `Widget.example.com "widget" is invalid`)
+	assert.Contains(t, err.Error(), `spec.image: Required value`)
+	assert.Contains(t, err.Error(), `spec.replicas in body should be greater than or equal to 1`)
+
+	_, err = getWidget(ctx)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCustomResourceValidation_Update_Invalid(t *testing.T) {
+	ctx := initFakeScenarioWithCRDValidation(t)
+
+	err := ctx.Create(widgetGVK, widgetDefault, yamlToUnstructured(t, `spec: {image: nginx}`))
+	require.NoError(t, err)
+
+	obj, err := getWidget(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(obj.Object, "3", "spec", "replicas"))
+
+	err = ctx.Update(widgetGVK, widgetDefault, obj)
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestCustomResourceValidation_Patch(t *testing.T) {
+	ctx := initFakeScenarioWithCRDValidation(t)
+
+	err := ctx.Create(widgetGVK, widgetDefault, yamlToUnstructured(t, `spec: {image: nginx}`))
+	require.NoError(t, err)
+
+	obj, err := getWidget(ctx)
+	require.NoError(t, err)
+
+	err = ctx.Client().Patch(ctx.GoContext(), obj, ctrlclient.RawPatch(types.MergePatchType, []byte(`{"spec":{"replicas":3,"unknown":true}}`)))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"image": "nginx", "replicas": int64(3)}, obj.Object["spec"])
+
+	err = ctx.Client().Patch(ctx.GoContext(), obj, ctrlclient.RawPatch(types.JSONPatchType, []byte(`[{"op":"remove","path":"/spec/image"}]`)))
+	assert.True(t, errors.IsInvalid(err))
+
+	err = ctx.Client().Status().Patch(ctx.GoContext(), obj, ctrlclient.RawPatch(types.MergePatchType, []byte(`{"status":{"ready":"yes"}}`)))
+	assert.True(t, errors.IsInvalid(err))
+}
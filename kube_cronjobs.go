@@ -0,0 +1,258 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxMissedSchedules is the number of missed schedules after which a
+// CronJob is no longer started, like the Kubernetes CronJob controller.
+const maxMissedSchedules = 100
+
+var cronJobGroupVersionKind = batchv1beta1.SchemeGroupVersion.WithKind("CronJob")
+
+// cronJobController emulates the Kubernetes CronJob controller: Jobs are
+// created each time the feature context clock passes a schedule.
+// NOTE: CronJobs are managed through batch/v1beta1, the latest version
+// available with this Kubernetes API.
+type cronJobController struct{}
+
+// Handles returns true for CronJobs and Jobs.
+func (cronJobController) Handles(gvk schema.GroupVersionKind) bool {
+	return gvk == cronJobGroupVersionKind || gvk == jobGroupVersionKind
+}
+
+// Emulate reconciles the CronJob written or owning the written Job.
+func (c cronJobController) Emulate(ctx *FeatureContext, event emulationEvent) error {
+	if event.gvk == cronJobGroupVersionKind {
+		if event.operation == emulationDelete {
+			return nil
+		}
+		return c.reconcile(ctx, event.key)
+	}
+
+	return reconcileOwners(ctx, event, cronJobGroupVersionKind, &batchv1.Job{}, &batchv1beta1.CronJobList{}, c.reconcile)
+}
+
+// EmulateTime reconciles all CronJobs.
+func (c cronJobController) EmulateTime(ctx *FeatureContext) error {
+	cronJobs := &batchv1beta1.CronJobList{}
+	if err := ctx.serverClient().List(ctx.ctx, cronJobs); err != nil {
+		return err
+	}
+
+	for _, cronJob := range cronJobs.Items {
+		if err := c.reconcile(ctx, types.NamespacedName{Namespace: cronJob.Namespace, Name: cronJob.Name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c cronJobController) reconcile(ctx *FeatureContext, key types.NamespacedName) error {
+	cronJob := &batchv1beta1.CronJob{}
+	if err := getIfExists(ctx, key, cronJob); err != nil || cronJob.Name == "" {
+		return err
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := ctx.serverClient().List(ctx.ctx, jobs, client.InNamespace(key.Namespace)); err != nil {
+		return err
+	}
+
+	var active, succeeded, failed []*batchv1.Job
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !metav1.IsControlledBy(job, cronJob) {
+			continue
+		}
+
+		switch {
+		case !isJobFinished(job):
+			active = append(active, job)
+		case jobCondition(job, batchv1.JobFailed) == corev1.ConditionTrue:
+			failed = append(failed, job)
+		default:
+			succeeded = append(succeeded, job)
+		}
+	}
+
+	if err := c.removeOldestJobs(ctx, succeeded, int32OrDefault(cronJob.Spec.SuccessfulJobsHistoryLimit, 3)); err != nil {
+		return err
+	}
+	if err := c.removeOldestJobs(ctx, failed, int32OrDefault(cronJob.Spec.FailedJobsHistoryLimit, 1)); err != nil {
+		return err
+	}
+
+	status := batchv1beta1.CronJobStatus{LastScheduleTime: cronJob.Status.LastScheduleTime}
+
+	scheduledTime, err := c.nextScheduledTime(ctx, cronJob)
+	switch {
+	case err != nil:
+		// NOTE: like the real controller, invalid schedules are ignored
+	case scheduledTime == nil:
+	case cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend:
+	case cronJob.Spec.ConcurrencyPolicy == batchv1beta1.ForbidConcurrent && len(active) > 0:
+	default:
+		if cronJob.Spec.ConcurrencyPolicy == batchv1beta1.ReplaceConcurrent {
+			for _, job := range active {
+				if err := c.removeJob(ctx, job); err != nil {
+					return err
+				}
+			}
+			active = nil
+		}
+
+		job, err := c.createJob(ctx, cronJob, *scheduledTime)
+		if err != nil {
+			return err
+		}
+		active = append(active, job)
+		status.LastScheduleTime = &metav1.Time{Time: *scheduledTime}
+	}
+
+	for _, job := range active {
+		status.Active = append(status.Active, corev1.ObjectReference{
+			Kind:            jobGroupVersionKind.Kind,
+			APIVersion:      jobGroupVersionKind.GroupVersion().String(),
+			Namespace:       job.Namespace,
+			Name:            job.Name,
+			UID:             job.UID,
+			ResourceVersion: job.ResourceVersion,
+		})
+	}
+	sort.Slice(status.Active, func(i, j int) bool { return status.Active[i].Name < status.Active[j].Name })
+
+	if equality.Semantic.DeepEqual(cronJob.Status, status) {
+		return nil
+	}
+	cronJob.Status = status
+	return ctx.serverClient().Status().Update(ctx.ctx, cronJob)
+}
+
+// nextScheduledTime returns the latest schedule passed since the last
+// scheduled Job (or the CronJob creation), or nil if no Job must be
+// started.
+func (cronJobController) nextScheduledTime(ctx *FeatureContext, cronJob *batchv1beta1.CronJob) (*time.Time, error) {
+	schedule, err := cron.ParseStandard(cronJob.Spec.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	now := ctx.clock.Now()
+	earliest := cronJob.CreationTimestamp.Time
+	if cronJob.Status.LastScheduleTime != nil {
+		earliest = cronJob.Status.LastScheduleTime.Time
+	}
+	if deadline := cronJob.Spec.StartingDeadlineSeconds; deadline != nil {
+		if startingDeadline := now.Add(-time.Duration(*deadline) * time.Second); startingDeadline.After(earliest) {
+			earliest = startingDeadline
+		}
+	}
+
+	var (
+		scheduledTime *time.Time
+		missed        int
+	)
+	for t := schedule.Next(earliest); !t.After(now); t = schedule.Next(t) {
+		t := t
+		scheduledTime = &t
+		if missed++; missed > maxMissedSchedules {
+			return nil, fmt.Errorf("too many missed start times (> %d)", maxMissedSchedules)
+		}
+	}
+	return scheduledTime, nil
+}
+
+// createJob creates the Job of the given CronJob for the given schedule.
+func (cronJobController) createJob(ctx *FeatureContext, cronJob *batchv1beta1.CronJob, scheduledTime time.Time) (*batchv1.Job, error) {
+	template := cronJob.Spec.JobTemplate.DeepCopy()
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			// NOTE: like the real controller, the name depends on the schedule
+			//       in order to never start a Job twice
+			Name:              fmt.Sprintf("%s-%d", cronJob.Name, scheduledTime.Unix()/60),
+			Namespace:         cronJob.Namespace,
+			UID:               types.UID(uuid.New().String()),
+			CreationTimestamp: ctx.now(),
+			Labels:            template.Labels,
+			Annotations:       template.Annotations,
+			OwnerReferences:   []metav1.OwnerReference{*metav1.NewControllerRef(cronJob, cronJobGroupVersionKind)},
+		},
+		Spec: template.Spec,
+	}
+
+	err := ctx.serverClient().Create(ctx.ctx, job)
+	if errors.IsAlreadyExists(err) {
+		return job, ctx.serverClient().Get(ctx.ctx, types.NamespacedName{Namespace: job.Namespace, Name: job.Name}, job)
+	}
+	return job, err
+}
+
+// removeOldestJobs removes the oldest of the given Jobs, in order to keep
+// only the given number of Jobs.
+func (c cronJobController) removeOldestJobs(ctx *FeatureContext, jobs []*batchv1.Job, limit int32) error {
+	if len(jobs) <= int(limit) {
+		return nil
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobStartTime(jobs[i]).Before(jobStartTime(jobs[j])) })
+	for _, job := range jobs[:len(jobs)-int(limit)] {
+		if err := c.removeJob(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeJob removes the given Job and all its pods.
+func (cronJobController) removeJob(ctx *FeatureContext, job *batchv1.Job) error {
+	if err := ctx.serverClient().Delete(ctx.ctx, job); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(job)
+	if err != nil {
+		return err
+	}
+	obj.SetUnstructuredContent(content)
+	obj.SetGroupVersionKind(jobGroupVersionKind)
+	return ctx.callGC(obj)
+}
+
+// jobStartTime returns the time when the given Job has been started.
+func jobStartTime(job *batchv1.Job) time.Time {
+	if job.Status.StartTime != nil {
+		return job.Status.StartTime.Time
+	}
+	return job.CreationTimestamp.Time
+}
+
+// jobCondition returns the status of the given Job condition.
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) corev1.ConditionStatus {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status
+		}
+	}
+	return corev1.ConditionUnknown
+}
@@ -0,0 +1,111 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+var (
+	cronJobGVK    = schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+	cronJobBackup = types.NamespacedName{Namespace: "default", Name: "backup"}
+)
+
+// initFakeScenarioWithCronJobs generates a godoc ScenarioContext and a
+// FeatureContext with a fake client, a fake clock and the CronJob
+// controller, with a CronJob scheduled every 5 minutes.
+func initFakeScenarioWithCronJobs(t *testing.T, spec string) *kubernetes_ctx.FeatureContext {
+	ctx := initFakeScenario(t, kubernetes_ctx.WithClock(newFakeClock()), kubernetes_ctx.WithCronJobController())
+
+	require.NoError(t, ctx.Create(cronJobGVK, cronJobBackup, yamlToUnstructured(t, `
+spec:
+  schedule: "*/5 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers: [{name: backup, image: busybox}]
+`+spec)))
+	return ctx
+}
+
+// listJobs returns the name of all Jobs of the default namespace.
+func listJobs(t *testing.T, ctx *kubernetes_ctx.FeatureContext) []string {
+	jobs := &batchv1.JobList{}
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), jobs, ctrlclient.InNamespace("default")))
+
+	var names []string
+	for _, job := range jobs.Items {
+		names = append(names, job.Name)
+	}
+	return names
+}
+
+// finishJobs marks all Jobs of the default namespace as complete.
+func finishJobs(t *testing.T, ctx *kubernetes_ctx.FeatureContext) {
+	for _, name := range listJobs(t, ctx) {
+		require.NoError(t, ctx.Patch(
+			schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"},
+			types.NamespacedName{Namespace: "default", Name: name},
+			types.MergePatchType,
+			[]byte(`{"status":{"conditions":[{"type":"Complete","status":"True"}]}}`),
+		))
+	}
+}
+
+func TestCronJobController(t *testing.T) {
+	ctx := initFakeScenarioWithCronJobs(t, "")
+
+	require.NoError(t, ctx.AdvanceTime(4*time.Minute))
+	assert.Empty(t, listJobs(t, ctx))
+
+	require.NoError(t, ctx.AdvanceTime(time.Minute))
+	assert.Equal(t, []string{"backup-28401125"}, listJobs(t, ctx))
+
+	// NOTE: only the latest missed schedule is started
+	require.NoError(t, ctx.AdvanceTime(time.Hour))
+	assert.Equal(t, []string{"backup-28401125", "backup-28401185"}, listJobs(t, ctx))
+}
+
+func TestCronJobController_HistoryLimit(t *testing.T) {
+	ctx := initFakeScenarioWithCronJobs(t, `  successfulJobsHistoryLimit: 1`)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, ctx.AdvanceTime(5*time.Minute))
+		finishJobs(t, ctx)
+	}
+	assert.Equal(t, []string{"backup-28401135"}, listJobs(t, ctx))
+}
+
+func TestCronJobController_ReplaceConcurrent(t *testing.T) {
+	ctx := initFakeScenarioWithCronJobs(t, `  concurrencyPolicy: Replace`)
+
+	require.NoError(t, ctx.AdvanceTime(5*time.Minute))
+	require.NoError(t, ctx.AdvanceTime(5*time.Minute))
+	assert.Equal(t, []string{"backup-28401130"}, listJobs(t, ctx))
+}
+
+func TestCronJobController_StartingDeadline(t *testing.T) {
+	ctx := initFakeScenarioWithCronJobs(t, `  startingDeadlineSeconds: 60`)
+
+	require.NoError(t, ctx.AdvanceTime(7*time.Minute))
+	assert.Empty(t, listJobs(t, ctx))
+
+	require.NoError(t, ctx.AdvanceTime(3*time.Minute))
+	assert.Equal(t, []string{"backup-28401130"}, listJobs(t, ctx))
+}
+
+func TestFeatureContext_AdvanceTime_RealClock(t *testing.T) {
+	ctx := initFakeScenario(t)
+	err := ctx.AdvanceTime(time.Minute)
+	assert.EqualError(t, err, "time can only be changed with a fake clock (see WithClock), not clock.RealClock")
+}
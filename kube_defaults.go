@@ -0,0 +1,355 @@
+package kubernetes_ctx
+
+import (
+	"context"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// builtinDefaults holds the defaulting functions of the most used built-in
+// kinds, following the ones of the API server, because they are not
+// registered by the client-go scheme.
+var builtinDefaults = func() *runtime.Scheme {
+	// NOTE: these functions are a partial copy of the Kubernetes 1.18
+	//       defaulters (k8s.io/kubernetes/pkg/apis/*/v1/defaults.go), which
+	//       can't be imported; they are maintained by hand and only fill
+	//       the most used fields of these kinds
+	s := runtime.NewScheme()
+	s.AddTypeDefaultingFunc(&corev1.Pod{}, func(obj interface{}) { defaultPod(obj.(*corev1.Pod)) })
+	s.AddTypeDefaultingFunc(&corev1.Service{}, func(obj interface{}) { defaultService(obj.(*corev1.Service)) })
+	s.AddTypeDefaultingFunc(&appsv1.Deployment{}, func(obj interface{}) { defaultDeployment(obj.(*appsv1.Deployment)) })
+	s.AddTypeDefaultingFunc(&appsv1.ReplicaSet{}, func(obj interface{}) { defaultReplicaSet(obj.(*appsv1.ReplicaSet)) })
+	s.AddTypeDefaultingFunc(&appsv1.StatefulSet{}, func(obj interface{}) { defaultStatefulSet(obj.(*appsv1.StatefulSet)) })
+	s.AddTypeDefaultingFunc(&appsv1.DaemonSet{}, func(obj interface{}) { defaultDaemonSet(obj.(*appsv1.DaemonSet)) })
+	s.AddTypeDefaultingFunc(&batchv1.Job{}, func(obj interface{}) { defaultJob(obj.(*batchv1.Job)) })
+	s.AddTypeDefaultingFunc(&batchv1beta1.CronJob{}, func(obj interface{}) { defaultCronJob(obj.(*batchv1beta1.CronJob)) })
+	return s
+}()
+
+type (
+	// defaultingClient wraps a client.Client in order to fill the fields
+	// defaulted by the API server on all writes, including the ones of the
+	// emulated components. Patches are applied locally, defaulted and then
+	// written with an Update.
+	defaultingClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// defaultingStatusWriter fills the defaulted fields when the status
+	// subresource is written.
+	defaultingStatusWriter struct {
+		client.StatusWriter
+		client *defaultingClient
+	}
+)
+
+func (c *defaultingClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.ctx.applyDefaults(obj); err != nil {
+		return err
+	}
+	return c.Client.Create(goctx, obj, opts...)
+}
+
+func (c *defaultingClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.ctx.applyDefaults(obj); err != nil {
+		return err
+	}
+	return c.Client.Update(goctx, obj, opts...)
+}
+
+func (c *defaultingClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.ctx.patchLocally(goctx, c.Client, obj, patch); err != nil {
+		return err
+	}
+	return c.Update(goctx, obj, patchToUpdateOptions(opts))
+}
+
+func (c *defaultingClient) Status() client.StatusWriter {
+	return &defaultingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+func (w *defaultingStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.client.ctx.applyDefaults(obj); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(goctx, obj, opts...)
+}
+
+func (w *defaultingStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.client.ctx.patchLocally(goctx, w.client.Client, obj, patch); err != nil {
+		return err
+	}
+	return w.Update(goctx, obj, patchToUpdateOptions(opts))
+}
+
+// applyDefaults runs the defaulting functions registered inside the feature
+// context scheme (if it is a *runtime.Scheme) and then the built-in ones,
+// filling the fields still empty. Unstructured objects are converted to
+// their registered type, if any, because defaulting functions are
+// registered on typed objects.
+func (ctx *FeatureContext) applyDefaults(obj runtime.Object) error {
+	uobj, isUnstructured := obj.(*unstructured.Unstructured)
+	if !isUnstructured {
+		ctx.defaultObject(obj)
+		return nil
+	}
+
+	gvk := uobj.GroupVersionKind()
+	kobj, err := ctx.scheme.New(gvk)
+	switch {
+	case runtime.IsNotRegisteredError(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	if _, isUnstructured := kobj.(runtime.Unstructured); isUnstructured {
+		return nil
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(uobj.Object, kobj); err != nil {
+		return err
+	}
+	ctx.defaultObject(kobj)
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(kobj)
+	if err != nil {
+		return err
+	}
+	uobj.SetUnstructuredContent(content)
+	uobj.SetGroupVersionKind(gvk)
+	return nil
+}
+
+// defaultObject runs the defaulting functions on the given typed object.
+func (ctx *FeatureContext) defaultObject(obj runtime.Object) {
+	if ctx.defaulter != nil {
+		ctx.defaulter.Default(obj)
+	}
+	builtinDefaults.Default(obj)
+}
+
+func defaultPod(pod *corev1.Pod) {
+	defaultPodSpec(&pod.Spec)
+	if pod.Spec.EnableServiceLinks == nil {
+		enableServiceLinks := corev1.DefaultEnableServiceLinks
+		pod.Spec.EnableServiceLinks = &enableServiceLinks
+	}
+}
+
+func defaultPodSpec(spec *corev1.PodSpec) {
+	if spec.DNSPolicy == "" {
+		spec.DNSPolicy = corev1.DNSClusterFirst
+	}
+	if spec.RestartPolicy == "" {
+		spec.RestartPolicy = corev1.RestartPolicyAlways
+	}
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	if spec.SchedulerName == "" {
+		spec.SchedulerName = corev1.DefaultSchedulerName
+	}
+	defaultInt64(&spec.TerminationGracePeriodSeconds, corev1.DefaultTerminationGracePeriodSeconds)
+
+	for i := range spec.InitContainers {
+		defaultContainer(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		defaultContainer(&spec.Containers[i])
+	}
+}
+
+func defaultContainer(container *corev1.Container) {
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = defaultImagePullPolicy(container.Image)
+	}
+	if container.TerminationMessagePath == "" {
+		container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+	}
+	if container.TerminationMessagePolicy == "" {
+		container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	}
+	for i := range container.Ports {
+		if container.Ports[i].Protocol == "" {
+			container.Ports[i].Protocol = corev1.ProtocolTCP
+		}
+	}
+	for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe, container.StartupProbe} {
+		if probe != nil {
+			defaultProbe(probe)
+		}
+	}
+}
+
+// defaultImagePullPolicy returns Always for the images without tag or with
+// the latest one, and IfNotPresent otherwise.
+func defaultImagePullPolicy(image string) corev1.PullPolicy {
+	if strings.Contains(image, "@") {
+		return corev1.PullIfNotPresent
+	}
+
+	var tag string
+	name := image[strings.LastIndex(image, "/")+1:]
+	if idx := strings.LastIndex(name, ":"); idx >= 0 {
+		tag = name[idx+1:]
+	}
+	if tag == "" || tag == "latest" {
+		return corev1.PullAlways
+	}
+	return corev1.PullIfNotPresent
+}
+
+func defaultProbe(probe *corev1.Probe) {
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = 1
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = 10
+	}
+	if probe.SuccessThreshold == 0 {
+		probe.SuccessThreshold = 1
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = 3
+	}
+	if probe.HTTPGet != nil {
+		if probe.HTTPGet.Path == "" {
+			probe.HTTPGet.Path = "/"
+		}
+		if probe.HTTPGet.Scheme == "" {
+			probe.HTTPGet.Scheme = corev1.URISchemeHTTP
+		}
+	}
+}
+
+func defaultService(svc *corev1.Service) {
+	if svc.Spec.SessionAffinity == "" {
+		svc.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	}
+	if svc.Spec.Type == "" {
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+	}
+	if (svc.Spec.Type == corev1.ServiceTypeNodePort || svc.Spec.Type == corev1.ServiceTypeLoadBalancer) && svc.Spec.ExternalTrafficPolicy == "" {
+		svc.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	}
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort == intstr.FromInt(0) || port.TargetPort == intstr.FromString("") {
+			port.TargetPort = intstr.FromInt(int(port.Port))
+		}
+	}
+}
+
+func defaultDeployment(deployment *appsv1.Deployment) {
+	defaultInt32(&deployment.Spec.Replicas, 1)
+	if deployment.Spec.Strategy.Type == "" {
+		deployment.Spec.Strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+	}
+	if deployment.Spec.Strategy.Type == appsv1.RollingUpdateDeploymentStrategyType {
+		if deployment.Spec.Strategy.RollingUpdate == nil {
+			deployment.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{}
+		}
+		defaultIntOrString(&deployment.Spec.Strategy.RollingUpdate.MaxUnavailable, intstr.FromString("25%"))
+		defaultIntOrString(&deployment.Spec.Strategy.RollingUpdate.MaxSurge, intstr.FromString("25%"))
+	}
+	defaultInt32(&deployment.Spec.RevisionHistoryLimit, 10)
+	defaultInt32(&deployment.Spec.ProgressDeadlineSeconds, 600)
+	defaultPodSpec(&deployment.Spec.Template.Spec)
+}
+
+func defaultReplicaSet(rs *appsv1.ReplicaSet) {
+	defaultInt32(&rs.Spec.Replicas, 1)
+	defaultPodSpec(&rs.Spec.Template.Spec)
+}
+
+func defaultStatefulSet(sts *appsv1.StatefulSet) {
+	defaultInt32(&sts.Spec.Replicas, 1)
+	if sts.Spec.PodManagementPolicy == "" {
+		sts.Spec.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+	}
+	if sts.Spec.UpdateStrategy.Type == "" {
+		sts.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	}
+	if sts.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType {
+		if sts.Spec.UpdateStrategy.RollingUpdate == nil {
+			sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{}
+		}
+		defaultInt32(&sts.Spec.UpdateStrategy.RollingUpdate.Partition, 0)
+	}
+	defaultInt32(&sts.Spec.RevisionHistoryLimit, 10)
+	defaultPodSpec(&sts.Spec.Template.Spec)
+}
+
+func defaultDaemonSet(ds *appsv1.DaemonSet) {
+	if ds.Spec.UpdateStrategy.Type == "" {
+		ds.Spec.UpdateStrategy.Type = appsv1.RollingUpdateDaemonSetStrategyType
+	}
+	if ds.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType {
+		if ds.Spec.UpdateStrategy.RollingUpdate == nil {
+			ds.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateDaemonSet{}
+		}
+		defaultIntOrString(&ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable, intstr.FromInt(1))
+	}
+	defaultInt32(&ds.Spec.RevisionHistoryLimit, 10)
+	defaultPodSpec(&ds.Spec.Template.Spec)
+}
+
+func defaultJob(job *batchv1.Job) {
+	if job.Spec.Completions == nil && job.Spec.Parallelism == nil {
+		defaultInt32(&job.Spec.Completions, 1)
+	}
+	defaultInt32(&job.Spec.Parallelism, 1)
+	defaultInt32(&job.Spec.BackoffLimit, 6)
+	if len(job.Labels) == 0 {
+		job.Labels = job.Spec.Template.Labels
+	}
+	defaultPodSpec(&job.Spec.Template.Spec)
+}
+
+// defaultCronJob defaults the batch/v1beta1 CronJobs, the only version
+// existing in the supported Kubernetes API (1.18).
+func defaultCronJob(cronJob *batchv1beta1.CronJob) {
+	if cronJob.Spec.ConcurrencyPolicy == "" {
+		cronJob.Spec.ConcurrencyPolicy = batchv1beta1.AllowConcurrent
+	}
+	if cronJob.Spec.Suspend == nil {
+		suspend := false
+		cronJob.Spec.Suspend = &suspend
+	}
+	defaultInt32(&cronJob.Spec.SuccessfulJobsHistoryLimit, 3)
+	defaultInt32(&cronJob.Spec.FailedJobsHistoryLimit, 1)
+	// NOTE: the Job defaults are set when the Job is created
+	defaultPodSpec(&cronJob.Spec.JobTemplate.Spec.Template.Spec)
+}
+
+// defaultInt32 sets the given field to the default value if it is nil.
+func defaultInt32(field **int32, value int32) {
+	if *field == nil {
+		*field = &value
+	}
+}
+
+// defaultInt64 sets the given field to the default value if it is nil.
+func defaultInt64(field **int64, value int64) {
+	if *field == nil {
+		*field = &value
+	}
+}
+
+// defaultIntOrString sets the given field to the default value if it is nil.
+func defaultIntOrString(field **intstr.IntOrString, value intstr.IntOrString) {
+	if *field == nil {
+		*field = &value
+	}
+}
@@ -0,0 +1,173 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+var nginxDeployment = `
+spec:
+  selector:
+    matchLabels: {app: nginx}
+  template:
+    metadata:
+      labels: {app: nginx}
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.19
+        ports: [{containerPort: 80}]
+      - name: sidecar
+        image: registry.local:5000/sidecar
+`
+
+func TestWithDefaulting(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithDefaulting(),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	nginx := types.NamespacedName{Namespace: "default", Name: "nginx"}
+	require.NoError(t, ctx.Create(deploymentGVK, nginx, yamlToUnstructured(t, nginxDeployment)))
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), nginx, deployment))
+	assert.Equal(t, int32(1), *deployment.Spec.Replicas)
+	assert.Equal(t, appsv1.RollingUpdateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	assert.Equal(t, intstr.FromString("25%"), *deployment.Spec.Strategy.RollingUpdate.MaxSurge)
+	assert.Equal(t, int32(10), *deployment.Spec.RevisionHistoryLimit)
+	assert.Equal(t, corev1.RestartPolicyAlways, deployment.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, corev1.PullIfNotPresent, deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy)
+	assert.Equal(t, corev1.ProtocolTCP, deployment.Spec.Template.Spec.Containers[0].Ports[0].Protocol)
+	assert.Equal(t, corev1.PullAlways, deployment.Spec.Template.Spec.Containers[1].ImagePullPolicy)
+
+	// explicit values are kept
+	obj := yamlToUnstructured(t, nginxDeployment)
+	require.NoError(t, unstructured.SetNestedField(obj.Object, int64(3), "spec", "replicas"))
+	require.NoError(t, unstructured.SetNestedField(obj.Object, "Recreate", "spec", "strategy", "type"))
+	obj.SetResourceVersion(deployment.ResourceVersion)
+	require.NoError(t, ctx.Update(deploymentGVK, nginx, obj))
+
+	deployment = &appsv1.Deployment{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), nginx, deployment))
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+	assert.Equal(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	assert.Nil(t, deployment.Spec.Strategy.RollingUpdate)
+	assert.Equal(t, int32(600), *deployment.Spec.ProgressDeadlineSeconds)
+}
+
+func TestWithDefaulting_SchemeDefaulters(t *testing.T) {
+	scheme := featureScheme()
+	scheme.AddTypeDefaultingFunc(&corev1.ConfigMap{}, func(obj interface{}) {
+		configMap := obj.(*corev1.ConfigMap)
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{"key": "default"}
+		}
+	})
+
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithClient(scheme, fake.NewFakeClientWithScheme(scheme)),
+		kubernetes_ctx.WithDefaulting(),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	configMapGVK := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	config := types.NamespacedName{Namespace: "default", Name: "config"}
+	require.NoError(t, ctx.Create(configMapGVK, config, &unstructured.Unstructured{}))
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), config, configMap))
+	assert.Equal(t, map[string]string{"key": "default"}, configMap.Data)
+}
+
+func TestWithDefaulting_Patch(t *testing.T) {
+	ctx := initFakeScenario(t, kubernetes_ctx.WithDefaulting())
+
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	nginx := types.NamespacedName{Namespace: "default", Name: "nginx"}
+	require.NoError(t, ctx.Create(deploymentGVK, nginx, yamlToUnstructured(t, nginxDeployment)))
+	require.NoError(t, ctx.Patch(deploymentGVK, nginx, types.MergePatchType, []byte(`{"spec":{"strategy":null,"template":{"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}}}`)))
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), nginx, deployment))
+	assert.Equal(t, appsv1.RollingUpdateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	assert.Equal(t, corev1.PullAlways, deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy)
+}
+
+func TestWithDefaulting_Unstructured(t *testing.T) {
+	ctx := initFakeScenario(t, kubernetes_ctx.WithDefaulting())
+
+	obj := yamlToUnstructured(t, `
+apiVersion: v1
+kind: Pod
+metadata: {namespace: default, name: pod}
+spec: {containers: [{name: app, image: nginx:1.19}]}
+`)
+	require.NoError(t, ctx.Client().Create(ctx.GoContext(), obj))
+	assert.Equal(t, podGVK, obj.GroupVersionKind())
+
+	pod := &corev1.Pod{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), podDefault, pod))
+	assert.Equal(t, corev1.RestartPolicyAlways, pod.Spec.RestartPolicy)
+	assert.Equal(t, corev1.PullIfNotPresent, pod.Spec.Containers[0].ImagePullPolicy)
+}
+
+func TestWithDefaulting_EmulatedComponents(t *testing.T) {
+	ctx := initFakeScenario(t,
+		kubernetes_ctx.WithClock(newFakeClock()),
+		kubernetes_ctx.WithCronJobController(),
+		kubernetes_ctx.WithDefaulting(),
+	)
+	require.NoError(t, ctx.Create(cronJobGVK, cronJobBackup, yamlToUnstructured(t, `
+spec:
+  schedule: "*/5 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers: [{name: backup, image: busybox}]
+`)))
+	require.NoError(t, ctx.AdvanceTime(5*time.Minute))
+
+	jobs := &batchv1.JobList{}
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), jobs, ctrlclient.InNamespace("default")))
+	require.Len(t, jobs.Items, 1)
+	assert.Equal(t, int32(6), *jobs.Items[0].Spec.BackoffLimit)
+	assert.Equal(t, int32(1), *jobs.Items[0].Spec.Completions)
+}
+
+func TestWithoutDefaulting(t *testing.T) {
+	ctx := initFakeScenario(t)
+
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	nginx := types.NamespacedName{Namespace: "default", Name: "nginx"}
+	require.NoError(t, ctx.Create(deploymentGVK, nginx, yamlToUnstructured(t, nginxDeployment)))
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), nginx, deployment))
+	assert.Nil(t, deployment.Spec.Replicas)
+	assert.Empty(t, deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy)
+}
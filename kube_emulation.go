@@ -0,0 +1,187 @@
+package kubernetes_ctx
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// emulationOperation describes the write operation that triggers an
+// emulator.
+type emulationOperation string
+
+const (
+	emulationCreate emulationOperation = "create"
+	emulationUpdate emulationOperation = "update"
+	emulationDelete emulationOperation = "delete"
+
+	// maxEmulationEvents avoids infinite loops between emulators
+	maxEmulationEvents = 10000
+)
+
+type (
+	// emulationEvent describes a successful write on an object.
+	emulationEvent struct {
+		operation emulationOperation
+		gvk       schema.GroupVersionKind
+		key       types.NamespacedName
+		// status is true if only the status subresource was written
+		status bool
+	}
+
+	// emulator emulates a Kubernetes component (like a kubelet or a
+	// controller) reacting to the objects written through the feature
+	// context.
+	emulator interface {
+		// Handles returns true if the emulator must be notified of the
+		// writes on the given kind.
+		Handles(gvk schema.GroupVersionKind) bool
+		// Emulate is called synchronously after each successful write on
+		// a handled kind. All writes done by the emulator must use the
+		// feature context server client, in order to notify the other
+		// emulators.
+		Emulate(ctx *FeatureContext, event emulationEvent) error
+	}
+
+	// timedEmulator is an emulator also reacting to the time changes done
+	// by the feature context (see WithClock).
+	timedEmulator interface {
+		emulator
+		// EmulateTime is called each time the feature context clock is
+		// changed.
+		EmulateTime(ctx *FeatureContext) error
+	}
+
+	// emulationClient wraps a client.Client in order to notify all emulators
+	// after each successful write.
+	emulationClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// emulationStatusWriter wraps a client.StatusWriter in order to notify
+	// all emulators after each successful write.
+	emulationStatusWriter struct {
+		client.StatusWriter
+		client *emulationClient
+	}
+)
+
+func (c *emulationClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.notify(emulationCreate, obj, false)
+}
+
+func (c *emulationClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.Client.Update(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.notify(emulationUpdate, obj, false)
+}
+
+func (c *emulationClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(goctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	return c.notify(emulationUpdate, obj, false)
+}
+
+func (c *emulationClient) Delete(goctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.notify(emulationDelete, obj, false)
+}
+
+func (c *emulationClient) Status() client.StatusWriter {
+	return &emulationStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// notify calls all emulators handling the given object.
+func (c *emulationClient) notify(op emulationOperation, obj runtime.Object, status bool) error {
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	c.ctx.emulationQueue = append(c.ctx.emulationQueue, emulationEvent{
+		operation: op,
+		gvk:       gvk,
+		key:       types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()},
+		status:    status,
+	})
+	if c.ctx.emulating {
+		return nil
+	}
+	return c.ctx.emulate(func() error { return nil })
+}
+
+func (w *emulationStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.StatusWriter.Update(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return w.client.notify(emulationUpdate, obj, true)
+}
+
+func (w *emulationStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.StatusWriter.Patch(goctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	return w.client.notify(emulationUpdate, obj, true)
+}
+
+// emulateTime notifies all emulators depending on the time that the feature
+// context clock has changed.
+func (ctx *FeatureContext) emulateTime() error {
+	return ctx.emulate(func() error {
+		for _, emulator := range ctx.emulators {
+			if timedEmulator, isTimed := emulator.(timedEmulator); isTimed {
+				if err := timedEmulator.EmulateTime(ctx); err != nil {
+					return fmt.Errorf("failed to emulate the time: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// emulate runs the given function, then calls all emulators handling the
+// writes done since. Like real controllers, emulators are never called
+// recursively: writes done by an emulator are queued and handled once it
+// has finished.
+func (ctx *FeatureContext) emulate(fnc func() error) error {
+	ctx.emulating = true
+	defer func() { ctx.emulating, ctx.emulationQueue = false, nil }()
+
+	if err := fnc(); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(ctx.emulationQueue); i++ {
+		if i >= maxEmulationEvents {
+			return fmt.Errorf("emulation doesn't converge after %d events", maxEmulationEvents)
+		}
+
+		event := ctx.emulationQueue[i]
+		for _, emulator := range ctx.emulators {
+			if !emulator.Handles(event.gvk) {
+				continue
+			}
+			if err := emulator.Emulate(ctx, event); err != nil {
+				return fmt.Errorf("failed to emulate the %s of %s '%s': %w", event.operation, event.gvk.Kind, event.key, err)
+			}
+		}
+	}
+	return nil
+}
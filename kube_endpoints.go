@@ -0,0 +1,343 @@
+package kubernetes_ctx
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// endpointSliceControllerName is the value of the managed-by label of the
+// EndpointSlices managed by the emulated controller.
+const endpointSliceControllerName = "endpointslice-controller.k8s.io"
+
+var serviceGroupVersionKind = corev1.SchemeGroupVersion.WithKind("Service")
+
+// endpointsController emulates the Kubernetes Endpoints and EndpointSlice
+// controllers: each Service with a selector gets an Endpoints and
+// EndpointSlices listing the IP of its selected pods.
+// NOTE: EndpointSlices are managed through discovery.k8s.io/v1beta1, the
+// latest version available with this Kubernetes API.
+type endpointsController struct{}
+
+// Handles returns true for Services and Pods.
+func (endpointsController) Handles(gvk schema.GroupVersionKind) bool {
+	return gvk == serviceGroupVersionKind || gvk == podGroupVersionKind
+}
+
+// Emulate reconciles the Service written, or all Services of the namespace
+// of the written Pod.
+func (c endpointsController) Emulate(ctx *FeatureContext, event emulationEvent) error {
+	if event.gvk == serviceGroupVersionKind {
+		return c.reconcile(ctx, event.key)
+	}
+
+	services := &corev1.ServiceList{}
+	if err := ctx.serverClient().List(ctx.ctx, services, client.InNamespace(event.key.Namespace)); err != nil {
+		return err
+	}
+	for _, service := range services.Items {
+		if err := c.reconcile(ctx, types.NamespacedName{Namespace: service.Namespace, Name: service.Name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c endpointsController) reconcile(ctx *FeatureContext, key types.NamespacedName) error {
+	service := &corev1.Service{}
+	if err := getIfExists(ctx, key, service); err != nil {
+		return err
+	}
+
+	if service.Name == "" {
+		// NOTE: like the real controller, Endpoints are removed with their
+		//       service (EndpointSlices are owned by the service)
+		endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+		if err := ctx.serverClient().Delete(ctx.ctx, endpoints); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return c.reconcileSlices(ctx, key, nil, nil)
+	}
+	if service.Spec.Selector == nil {
+		// NOTE: Endpoints of services without selector are managed by users
+		return nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := ctx.serverClient().List(ctx.ctx, pods, client.InNamespace(key.Namespace), client.MatchingLabels(service.Spec.Selector)); err != nil {
+		return err
+	}
+
+	var selected []*corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.PodIP != "" && isPodActive(pod) {
+			selected = append(selected, pod)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+
+	if err := c.reconcileEndpoints(ctx, service, selected); err != nil {
+		return err
+	}
+	return c.reconcileSlices(ctx, key, service, selected)
+}
+
+// reconcileEndpoints updates the Endpoints of the given service; pods with
+// the same ports are grouped in the same subset.
+func (endpointsController) reconcileEndpoints(ctx *FeatureContext, service *corev1.Service, pods []*corev1.Pod) error {
+	var subsets []corev1.EndpointSubset
+	subsetIndex := map[string]int{}
+
+	for _, pod := range pods {
+		ports := endpointPorts(service, pod)
+		portsKey := hashOf(ports)
+		if _, exists := subsetIndex[portsKey]; !exists {
+			subsetIndex[portsKey] = len(subsets)
+			subsets = append(subsets, corev1.EndpointSubset{Ports: ports})
+		}
+
+		subset := &subsets[subsetIndex[portsKey]]
+		address := corev1.EndpointAddress{
+			IP:        pod.Status.PodIP,
+			NodeName:  stringOrNil(pod.Spec.NodeName),
+			TargetRef: podReference(pod),
+		}
+		if isPodReady(pod) || service.Spec.PublishNotReadyAddresses {
+			subset.Addresses = append(subset.Addresses, address)
+		} else {
+			subset.NotReadyAddresses = append(subset.NotReadyAddresses, address)
+		}
+	}
+
+	endpoints := &corev1.Endpoints{}
+	if err := getIfExists(ctx, types.NamespacedName{Namespace: service.Namespace, Name: service.Name}, endpoints); err != nil {
+		return err
+	}
+
+	if endpoints.Name == "" {
+		endpoints = &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         service.Namespace,
+				Name:              service.Name,
+				UID:               types.UID(uuid.New().String()),
+				CreationTimestamp: ctx.now(),
+				Labels:            service.Labels,
+			},
+			Subsets: subsets,
+		}
+		return ctx.serverClient().Create(ctx.ctx, endpoints)
+	}
+
+	if equality.Semantic.DeepEqual(endpoints.Subsets, subsets) && equality.Semantic.DeepEqual(endpoints.Labels, service.Labels) {
+		return nil
+	}
+	endpoints.Labels, endpoints.Subsets = service.Labels, subsets
+	return ctx.serverClient().Update(ctx.ctx, endpoints)
+}
+
+// reconcileSlices updates the EndpointSlices of the given service; like
+// Endpoints subsets, one EndpointSlice is managed per set of ports.
+func (endpointsController) reconcileSlices(ctx *FeatureContext, key types.NamespacedName, service *corev1.Service, pods []*corev1.Pod) error {
+	existing := &discoveryv1beta1.EndpointSliceList{}
+	err := ctx.serverClient().List(ctx.ctx, existing, client.InNamespace(key.Namespace), client.MatchingLabels{
+		discoveryv1beta1.LabelServiceName: key.Name,
+		discoveryv1beta1.LabelManagedBy:   endpointSliceControllerName,
+	})
+	if err != nil {
+		return err
+	}
+
+	desired := map[string]*discoveryv1beta1.EndpointSlice{}
+	var names []string
+	for _, pod := range pods {
+		ports := endpointSlicePorts(service, pod)
+		name := key.Name + "-" + hashOf(ports)
+		if _, exists := desired[name]; !exists {
+			desired[name] = &discoveryv1beta1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         key.Namespace,
+					Name:              name,
+					UID:               types.UID(uuid.New().String()),
+					CreationTimestamp: ctx.now(),
+					Labels: map[string]string{
+						discoveryv1beta1.LabelServiceName: key.Name,
+						discoveryv1beta1.LabelManagedBy:   endpointSliceControllerName,
+					},
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(service, serviceGroupVersionKind)},
+				},
+				AddressType: discoveryv1beta1.AddressTypeIPv4,
+				Ports:       ports,
+			}
+			names = append(names, name)
+		}
+
+		ready := isPodReady(pod)
+		desired[name].Endpoints = append(desired[name].Endpoints, discoveryv1beta1.Endpoint{
+			Addresses:  []string{pod.Status.PodIP},
+			Conditions: discoveryv1beta1.EndpointConditions{Ready: &ready},
+			TargetRef:  podReference(pod),
+			Topology:   topologyOf(pod),
+		})
+	}
+
+	for i := range existing.Items {
+		slice := &existing.Items[i]
+		expected, exists := desired[slice.Name]
+		if !exists {
+			if err := ctx.serverClient().Delete(ctx.ctx, slice); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		delete(desired, slice.Name)
+
+		if equality.Semantic.DeepEqual(slice.Endpoints, expected.Endpoints) && equality.Semantic.DeepEqual(slice.Ports, expected.Ports) {
+			continue
+		}
+		slice.Endpoints, slice.Ports = expected.Endpoints, expected.Ports
+		if err := ctx.serverClient().Update(ctx.ctx, slice); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if slice, exists := desired[name]; exists {
+			if err := ctx.serverClient().Create(ctx.ctx, slice); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ServiceEndpoints returns the name of the ready pods listed in the
+// Endpoints of the given service.
+func (ctx *FeatureContext) ServiceEndpoints(namespacedName types.NamespacedName) ([]string, error) {
+	endpoints := &corev1.Endpoints{}
+	if err := ctx.client.Get(ctx.internalContext(), namespacedName, endpoints); err != nil {
+		return nil, err
+	}
+
+	var pods []string
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+				pods = append(pods, address.TargetRef.Name)
+			}
+		}
+	}
+	sort.Strings(pods)
+	return pods, nil
+}
+
+// endpointPorts returns the Endpoints ports of the given service for the
+// given pod. Ports with a named target port not exposed by the pod are
+// ignored.
+func endpointPorts(service *corev1.Service, pod *corev1.Pod) []corev1.EndpointPort {
+	var ports []corev1.EndpointPort
+	for _, servicePort := range service.Spec.Ports {
+		port, found := targetPort(servicePort, pod)
+		if !found {
+			continue
+		}
+		ports = append(ports, corev1.EndpointPort{
+			Name:        servicePort.Name,
+			Port:        port,
+			Protocol:    protocolOrDefault(servicePort.Protocol),
+			AppProtocol: servicePort.AppProtocol,
+		})
+	}
+	return ports
+}
+
+// endpointSlicePorts returns the EndpointSlice ports of the given service
+// for the given pod.
+func endpointSlicePorts(service *corev1.Service, pod *corev1.Pod) []discoveryv1beta1.EndpointPort {
+	var ports []discoveryv1beta1.EndpointPort
+	for _, port := range endpointPorts(service, pod) {
+		port := port
+		ports = append(ports, discoveryv1beta1.EndpointPort{
+			Name:        &port.Name,
+			Port:        &port.Port,
+			Protocol:    &port.Protocol,
+			AppProtocol: port.AppProtocol,
+		})
+	}
+	return ports
+}
+
+// targetPort resolves the target port of a service port on the given pod.
+func targetPort(servicePort corev1.ServicePort, pod *corev1.Pod) (int32, bool) {
+	switch {
+	case servicePort.TargetPort.Type == intstr.String:
+		for _, container := range pod.Spec.Containers {
+			for _, port := range container.Ports {
+				if port.Name == servicePort.TargetPort.StrVal && protocolOrDefault(port.Protocol) == protocolOrDefault(servicePort.Protocol) {
+					return port.ContainerPort, true
+				}
+			}
+		}
+		return 0, false
+	case servicePort.TargetPort.IntVal == 0:
+		return servicePort.Port, true
+	default:
+		return servicePort.TargetPort.IntVal, true
+	}
+}
+
+// podReference returns a reference to the given pod.
+func podReference(pod *corev1.Pod) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind:            "Pod",
+		Namespace:       pod.Namespace,
+		Name:            pod.Name,
+		UID:             pod.UID,
+		ResourceVersion: pod.ResourceVersion,
+	}
+}
+
+// topologyOf returns the topology of an EndpointSlice endpoint.
+func topologyOf(pod *corev1.Pod) map[string]string {
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	return map[string]string{"kubernetes.io/hostname": pod.Spec.NodeName}
+}
+
+// protocolOrDefault returns the given protocol, or TCP if empty.
+func protocolOrDefault(protocol corev1.Protocol) corev1.Protocol {
+	if protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return protocol
+}
+
+// stringOrNil returns a pointer to the given string, or nil if empty.
+func stringOrNil(str string) *string {
+	if str == "" {
+		return nil
+	}
+	return &str
+}
+
+// hashOf returns a short and stable hash of the given value.
+func hashOf(value interface{}) string {
+	hasher := fnv.New32a()
+	data, _ := json.Marshal(value)
+	_, _ = hasher.Write(data)
+	return utilrand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
@@ -0,0 +1,118 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+var (
+	serviceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+	serviceWeb = types.NamespacedName{Namespace: "default", Name: "web"}
+)
+
+// initFakeScenarioWithEndpoints generates a godoc ScenarioContext and a
+// FeatureContext with a fake client, the endpoints controller and a manual
+// simulated kubelet.
+func initFakeScenarioWithEndpoints(t *testing.T, opts ...kubernetes_ctx.FeatureContextOption) *kubernetes_ctx.FeatureContext {
+	ctx := initFakeScenario(t, append([]kubernetes_ctx.FeatureContextOption{
+		kubernetes_ctx.WithEndpointsController(),
+		kubernetes_ctx.WithKubeletSimulator(kubernetes_ctx.KubeletOptions{Manual: true}),
+	}, opts...)...)
+
+	require.NoError(t, ctx.Create(serviceGVK, serviceWeb, yamlToUnstructured(t, `
+spec:
+  selector: {app: web}
+  ports: [{name: http, port: 80, targetPort: http}]
+`)))
+	for _, name := range []string{"web-1", "web-2"} {
+		require.NoError(t, ctx.Create(podGVK, types.NamespacedName{Namespace: "default", Name: name}, yamlToUnstructured(t, `
+metadata: {labels: {app: web}}
+spec: {containers: [{name: app, image: nginx, ports: [{name: http, containerPort: 8080}]}]}
+`)))
+	}
+	return ctx
+}
+
+func TestEndpointsController(t *testing.T) {
+	ctx := initFakeScenarioWithEndpoints(t)
+
+	pods, err := ctx.ServiceEndpoints(serviceWeb)
+	require.NoError(t, err)
+	assert.Empty(t, pods)
+
+	endpoints := &corev1.Endpoints{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), serviceWeb, endpoints))
+	require.Len(t, endpoints.Subsets, 1)
+	assert.Len(t, endpoints.Subsets[0].NotReadyAddresses, 2)
+	assert.Equal(t, int32(8080), endpoints.Subsets[0].Ports[0].Port)
+
+	require.NoError(t, ctx.MakePodReady(types.NamespacedName{Namespace: "default", Name: "web-2"}))
+	pods, err = ctx.ServiceEndpoints(serviceWeb)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web-2"}, pods)
+
+	slices := &discoveryv1beta1.EndpointSliceList{}
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), slices, ctrlclient.InNamespace("default")))
+	require.Len(t, slices.Items, 1)
+	slice := slices.Items[0]
+	assert.Equal(t, "web", slice.Labels[discoveryv1beta1.LabelServiceName])
+	assert.Equal(t, int32(8080), *slice.Ports[0].Port)
+	require.Len(t, slice.Endpoints, 2)
+	assert.False(t, *slice.Endpoints[0].Conditions.Ready)
+	assert.True(t, *slice.Endpoints[1].Conditions.Ready)
+
+	// endpoints are removed with the service
+	_, err = ctx.Delete(serviceGVK, serviceWeb)
+	require.NoError(t, err)
+	_, err = ctx.ServiceEndpoints(serviceWeb)
+	assert.EqualError(t, err, `endpoints "web" not found`)
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), slices, ctrlclient.InNamespace("default")))
+	assert.Empty(t, slices.Items)
+}
+
+func TestEndpointsController_Metadata(t *testing.T) {
+	ctx := initFakeScenarioWithEndpoints(t, kubernetes_ctx.WithClock(newFakeClock()))
+	require.NoError(t, ctx.MakePodReady(types.NamespacedName{Namespace: "default", Name: "web-1"}))
+	now := ctx.Clock().Now()
+
+	endpoints := &corev1.Endpoints{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), serviceWeb, endpoints))
+	slices := &discoveryv1beta1.EndpointSliceList{}
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), slices, ctrlclient.InNamespace("default")))
+	require.Len(t, slices.Items, 1)
+
+	for _, obj := range []metav1.Object{endpoints, &slices.Items[0]} {
+		assert.NotEmpty(t, obj.GetUID())
+		assert.True(t, now.Equal(obj.GetCreationTimestamp().Time))
+	}
+	assert.NotEqual(t, endpoints.UID, slices.Items[0].UID)
+}
+
+func TestEndpointsController_Selector(t *testing.T) {
+	ctx := initFakeScenarioWithEndpoints(t)
+	require.NoError(t, ctx.MakePodReady(types.NamespacedName{Namespace: "default", Name: "web-1"}))
+	require.NoError(t, ctx.MakePodReady(types.NamespacedName{Namespace: "default", Name: "web-2"}))
+
+	pods, err := ctx.ServiceEndpoints(serviceWeb)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web-1", "web-2"}, pods)
+
+	pod := &corev1.Pod{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), types.NamespacedName{Namespace: "default", Name: "web-1"}, pod))
+	pod.Labels = map[string]string{"app": "other"}
+	require.NoError(t, ctx.Client().Update(ctx.GoContext(), pod))
+
+	pods, err = ctx.ServiceEndpoints(serviceWeb)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web-2"}, pods)
+}
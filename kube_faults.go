@@ -0,0 +1,255 @@
+package kubernetes_ctx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// injectedFaultMessage is the message of all injected errors.
+const injectedFaultMessage = "fault injected by the feature context"
+
+type (
+	// Fault describes an error or a latency injected on the requests sent
+	// through the feature context client (see WithFaultInjection). Empty
+	// fields match all requests.
+	Fault struct {
+		// Verb is the request verb, like get, list, create, update, patch,
+		// delete or deletecollection.
+		Verb             string
+		GroupVersionKind schema.GroupVersionKind
+		Namespace        string
+		Name             string
+
+		// Times is the number of matching requests affected by the fault;
+		// zero means all of them.
+		Times int
+		// Reason is the reason of the error returned instead of sending
+		// the request; empty means that the request is sent.
+		Reason metav1.StatusReason
+		// Latency is the time spent, on the feature context clock, before
+		// handling the request. With a fake clock, the time is advanced
+		// like with FeatureContext.AdvanceTime, notifying the emulators
+		// depending on the time (like the CronJob controller).
+		Latency time.Duration
+	}
+
+	// faultRequest describes a request which can be affected by a fault.
+	faultRequest struct {
+		verb      string
+		gvk       schema.GroupVersionKind
+		resource  schema.GroupResource
+		namespace string
+		name      string
+	}
+
+	// faultClient wraps a client.Client in order to inject the faults
+	// matching the requests. The reads issued by the feature context
+	// itself (like the ones of the assertion steps) are never affected.
+	faultClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// faultStatusWriter wraps a client.StatusWriter in order to inject the
+	// faults matching the requests on the status subresource.
+	faultStatusWriter struct {
+		client.StatusWriter
+		client *faultClient
+	}
+)
+
+// faultErrors associates the reasons of injectable errors with their
+// constructor.
+var faultErrors = map[metav1.StatusReason]func(request faultRequest) error{
+	metav1.StatusReasonAlreadyExists: func(r faultRequest) error { return errors.NewAlreadyExists(r.resource, r.name) },
+	metav1.StatusReasonBadRequest:    func(faultRequest) error { return errors.NewBadRequest(injectedFaultMessage) },
+	metav1.StatusReasonConflict: func(r faultRequest) error {
+		return errors.NewConflict(r.resource, r.name, fmt.Errorf(injectedFaultMessage))
+	},
+	metav1.StatusReasonForbidden: func(r faultRequest) error {
+		return errors.NewForbidden(r.resource, r.name, fmt.Errorf(injectedFaultMessage))
+	},
+	metav1.StatusReasonGone:          func(faultRequest) error { return errors.NewGone(injectedFaultMessage) },
+	metav1.StatusReasonInternalError: func(faultRequest) error { return errors.NewInternalError(fmt.Errorf(injectedFaultMessage)) },
+	metav1.StatusReasonInvalid: func(r faultRequest) error {
+		return errors.NewInvalid(r.gvk.GroupKind(), r.name, field.ErrorList{field.InternalError(nil, fmt.Errorf(injectedFaultMessage))})
+	},
+	metav1.StatusReasonMethodNotAllowed:   func(r faultRequest) error { return errors.NewMethodNotSupported(r.resource, r.verb) },
+	metav1.StatusReasonNotFound:           func(r faultRequest) error { return errors.NewNotFound(r.resource, r.name) },
+	metav1.StatusReasonServiceUnavailable: func(faultRequest) error { return errors.NewServiceUnavailable(injectedFaultMessage) },
+	metav1.StatusReasonTimeout:            func(faultRequest) error { return errors.NewTimeoutError(injectedFaultMessage, 0) },
+	metav1.StatusReasonTooManyRequests:    func(faultRequest) error { return errors.NewTooManyRequests(injectedFaultMessage, 0) },
+	metav1.StatusReasonUnauthorized:       func(faultRequest) error { return errors.NewUnauthorized(injectedFaultMessage) },
+}
+
+// InjectFault injects the given fault on all following requests sent
+// through the feature context client, until it has affected the given
+// number of requests. The fault injection must be enabled with
+// WithFaultInjection.
+func (ctx *FeatureContext) InjectFault(fault Fault) error {
+	if !ctx.faultInjection {
+		return fmt.Errorf("faults can only be injected when the fault injection is enabled (see WithFaultInjection)")
+	}
+	if _, exists := faultErrors[fault.Reason]; fault.Reason != "" && !exists {
+		return fmt.Errorf("unknown fault reason '%s'", fault.Reason)
+	}
+
+	fault.Verb = strings.ToLower(fault.Verb)
+	ctx.faults = append(ctx.faults, &fault)
+	return nil
+}
+
+// ClearFaults removes all injected faults.
+func (ctx *FeatureContext) ClearFaults() { ctx.faults = nil }
+
+// matches returns true if the fault affects the given request.
+func (fault Fault) matches(request faultRequest) bool {
+	return (fault.Verb == "" || fault.Verb == request.verb) &&
+		(fault.GroupVersionKind.Empty() || fault.GroupVersionKind == request.gvk) &&
+		(fault.Namespace == "" || fault.Namespace == request.namespace) &&
+		(fault.Name == "" || fault.Name == request.name)
+}
+
+// inject applies the first fault matching the given request, and returns
+// the error to return instead of sending the request, if any.
+func (c *faultClient) inject(verb string, obj runtime.Object, namespace, name string) error {
+	if len(c.ctx.faults) == 0 {
+		return nil
+	}
+
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return err
+	}
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	request := faultRequest{verb: verb, gvk: gvk, namespace: namespace, name: name}
+
+	for i, fault := range c.ctx.faults {
+		if !fault.matches(request) {
+			continue
+		}
+
+		if fault.Times > 0 {
+			if fault.Times--; fault.Times == 0 {
+				c.ctx.faults = append(c.ctx.faults[:i:i], c.ctx.faults[i+1:]...)
+			}
+		}
+		if fault.Latency > 0 {
+			if err := c.ctx.spendTime(fault.Latency); err != nil {
+				return err
+			}
+		}
+		if fault.Reason == "" {
+			return nil
+		}
+
+		mapping, err := c.ctx.restMapping(gvk)
+		if err != nil {
+			return err
+		}
+		request.resource = mapping.Resource.GroupResource()
+		return faultErrors[fault.Reason](request)
+	}
+	return nil
+}
+
+// injectOnObject applies the first fault matching the given request on an
+// existing object.
+func (c *faultClient) injectOnObject(verb string, obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	return c.inject(verb, obj, accessor.GetNamespace(), accessor.GetName())
+}
+
+func (c *faultClient) Get(goctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if isInternalRead(goctx) {
+		return c.Client.Get(goctx, key, obj)
+	}
+	if err := c.inject("get", obj, key.Namespace, key.Name); err != nil {
+		return err
+	}
+	return c.Client.Get(goctx, key, obj)
+}
+
+func (c *faultClient) List(goctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if isInternalRead(goctx) {
+		return c.Client.List(goctx, list, opts...)
+	}
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	if err := c.inject("list", list, listOpts.Namespace, ""); err != nil {
+		return err
+	}
+	return c.Client.List(goctx, list, opts...)
+}
+
+func (c *faultClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.injectOnObject("create", obj); err != nil {
+		return err
+	}
+	return c.Client.Create(goctx, obj, opts...)
+}
+
+func (c *faultClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.injectOnObject("update", obj); err != nil {
+		return err
+	}
+	return c.Client.Update(goctx, obj, opts...)
+}
+
+func (c *faultClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.injectOnObject("patch", obj); err != nil {
+		return err
+	}
+	return c.Client.Patch(goctx, obj, patch, opts...)
+}
+
+func (c *faultClient) Delete(goctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.injectOnObject("delete", obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(goctx, obj, opts...)
+}
+
+func (c *faultClient) DeleteAllOf(goctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	deleteOpts := client.DeleteAllOfOptions{}
+	deleteOpts.ApplyOptions(opts)
+
+	if err := c.inject("deletecollection", obj, deleteOpts.Namespace, ""); err != nil {
+		return err
+	}
+	return c.Client.DeleteAllOf(goctx, obj, opts...)
+}
+
+func (c *faultClient) Status() client.StatusWriter {
+	return &faultStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+func (w *faultStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.client.injectOnObject("update", obj); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(goctx, obj, opts...)
+}
+
+func (w *faultStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.client.injectOnObject("patch", obj); err != nil {
+		return err
+	}
+	return w.StatusWriter.Patch(goctx, obj, patch, opts...)
+}
@@ -0,0 +1,107 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+// initFakeScenarioWithFaults generates a godoc ScenarioContext and a
+// FeatureContext with a fake client, a fake clock and the fault injection.
+func initFakeScenarioWithFaults(t *testing.T, opts ...kubernetes_ctx.FeatureContextOption) *kubernetes_ctx.FeatureContext {
+	return initFakeScenario(t, append([]kubernetes_ctx.FeatureContextOption{
+		kubernetes_ctx.WithClock(newFakeClock()),
+		kubernetes_ctx.WithFaultInjection(),
+	}, opts...)...)
+}
+
+func TestFeatureContext_InjectFault(t *testing.T) {
+	ctx := initFakeScenarioWithFaults(t)
+	require.NoError(t, ctx.Create(namespaceGVK, namespaceDefault, &unstructured.Unstructured{}))
+
+	require.NoError(t, ctx.InjectFault(kubernetes_ctx.Fault{
+		Verb:             "get",
+		GroupVersionKind: namespaceGVK,
+		Name:             "default",
+		Times:            2,
+		Reason:           metav1.StatusReasonServiceUnavailable,
+		Latency:          time.Second,
+	}))
+
+	// NOTE: the read done by Patch before sending the patch is never affected
+	require.NoError(t, ctx.Patch(namespaceGVK, namespaceDefault, types.MergePatchType, []byte(`{"metadata":{"labels":{"key":"value"}}}`)))
+
+	for i := 0; i < 2; i++ {
+		_, err := ctx.Get(namespaceGVK, namespaceDefault)
+		assert.True(t, errors.IsServiceUnavailable(err))
+	}
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 2, 0, time.UTC), ctx.Clock().Now())
+
+	// faults are removed once they affected the given number of requests
+	_, err := ctx.Get(namespaceGVK, namespaceDefault)
+	assert.NoError(t, err)
+
+	assert.EqualError(t, ctx.InjectFault(kubernetes_ctx.Fault{Reason: "Unknown"}), "unknown fault reason 'Unknown'")
+}
+
+func TestFeatureContext_InjectFault_NotOnEmulators(t *testing.T) {
+	ctx := initFakeScenarioWithFaults(t,
+		kubernetes_ctx.WithWorkloadControllers(),
+		kubernetes_ctx.WithKubeletSimulator(kubernetes_ctx.KubeletOptions{}),
+	)
+	require.NoError(t, ctx.InjectFault(kubernetes_ctx.Fault{Verb: "create", Reason: metav1.StatusReasonForbidden}))
+
+	err := ctx.Create(deploymentGVK, types.NamespacedName{Namespace: "default", Name: "nginx"}, &unstructured.Unstructured{})
+	assert.True(t, errors.IsForbidden(err))
+
+	ctx.ClearFaults()
+	require.NoError(t, ctx.Create(deploymentGVK, types.NamespacedName{Namespace: "default", Name: "nginx"}, yamlToUnstructured(t, `
+spec:
+  replicas: 2
+  selector: {matchLabels: {app: nginx}}
+  template:
+    metadata: {labels: {app: nginx}}
+    spec: {containers: [{name: app, image: nginx}]}
+`)))
+
+	// NOTE: the pods are created by the emulated controllers, never affected
+	require.NoError(t, ctx.InjectFault(kubernetes_ctx.Fault{Verb: "create", Reason: metav1.StatusReasonForbidden}))
+	require.NoError(t, ctx.Patch(deploymentGVK, types.NamespacedName{Namespace: "default", Name: "nginx"}, types.MergePatchType, []byte(`{"spec":{"replicas":3}}`)))
+	assert.Len(t, listPods(t, ctx), 3)
+}
+
+func TestFeatureContext_InjectFault_Disabled(t *testing.T) {
+	ctx := initFakeScenario(t)
+	assert.EqualError(t,
+		ctx.InjectFault(kubernetes_ctx.Fault{Reason: metav1.StatusReasonConflict}),
+		"faults can only be injected when the fault injection is enabled (see WithFaultInjection)",
+	)
+}
+
+func TestFeatureContext_InjectFault_LatencyNotifiesEmulators(t *testing.T) {
+	ctx := initFakeScenarioWithFaults(t, kubernetes_ctx.WithCronJobController())
+	require.NoError(t, ctx.Create(cronJobGVK, cronJobBackup, yamlToUnstructured(t, `
+spec:
+  schedule: "*/5 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers: [{name: backup, image: busybox}]
+`)))
+	require.NoError(t, ctx.InjectFault(kubernetes_ctx.Fault{Verb: "get", Times: 1, Latency: 5 * time.Minute}))
+
+	// NOTE: the CronJob controller is notified of the time spent
+	_, err := ctx.Get(cronJobGVK, cronJobBackup)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-28401125"}, listJobs(t, ctx))
+}
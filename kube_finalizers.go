@@ -0,0 +1,161 @@
+package kubernetes_ctx
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type (
+	// finalizerClient wraps the fake client in order to delete the objects
+	// with finalizers like the API server: they are only marked with a
+	// deletionTimestamp, taken from the feature context clock, and removed
+	// once their last finalizer is removed. Patches are applied locally and
+	// then written with an Update.
+	finalizerClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// finalizerStatusWriter keeps the deletionTimestamp when the status
+	// subresource is written.
+	finalizerStatusWriter struct {
+		client.StatusWriter
+		client *finalizerClient
+	}
+)
+
+func (c *finalizerClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	deletionTimestamp, err := c.keepDeletionTimestamp(goctx, obj)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Update(goctx, obj, opts...); err != nil {
+		return err
+	}
+
+	if deletionTimestamp == nil {
+		return nil
+	}
+	return c.removeIfFinalized(goctx, obj)
+}
+
+func (c *finalizerClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.ctx.patchLocally(goctx, c.Client, obj, patch); err != nil {
+		return err
+	}
+	return c.Update(goctx, obj, patchToUpdateOptions(opts))
+}
+
+func (c *finalizerClient) Delete(goctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	current, err := c.stored(goctx, obj)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		// the underlying client will return the right error
+		return c.Client.Delete(goctx, obj, opts...)
+	}
+
+	accessor, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(accessor.GetFinalizers()) == 0:
+		return c.Client.Delete(goctx, obj, opts...)
+	case accessor.GetDeletionTimestamp() != nil:
+		// NOTE: the object is already being deleted
+		return nil
+	}
+
+	now := c.ctx.now()
+	accessor.SetDeletionTimestamp(&now)
+	return c.Client.Update(goctx, current)
+}
+
+func (c *finalizerClient) Status() client.StatusWriter {
+	return &finalizerStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// stored returns the stored version of the given object, or nil if it
+// doesn't exist.
+func (c *finalizerClient) stored(goctx context.Context, obj runtime.Object) (runtime.Object, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	current := obj.DeepCopyObject()
+	err = c.Client.Get(goctx, client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}, current)
+	switch {
+	case errors.IsNotFound(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return current, nil
+}
+
+// keepDeletionTimestamp restores the deletionTimestamp of the given object
+// from its stored version, ignoring the changes made by the caller, and
+// returns it.
+func (c *finalizerClient) keepDeletionTimestamp(goctx context.Context, obj runtime.Object) (*metav1.Time, error) {
+	current, err := c.stored(goctx, obj)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	currentAccessor, err := meta.Accessor(current)
+	if err != nil {
+		return nil, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	accessor.SetDeletionTimestamp(currentAccessor.GetDeletionTimestamp())
+	return currentAccessor.GetDeletionTimestamp(), nil
+}
+
+// removeIfFinalized removes the given object, being deleted, once it has
+// no more finalizers, then calls the garbage collector on it.
+func (c *finalizerClient) removeIfFinalized(goctx context.Context, obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if len(accessor.GetFinalizers()) > 0 {
+		return nil
+	}
+
+	if err := c.Client.Delete(goctx, obj); err != nil {
+		return err
+	}
+
+	owner := &unstructured.Unstructured{}
+	owner.Object, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	return c.ctx.callGC(owner)
+}
+
+func (w *finalizerStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if _, err := w.client.keepDeletionTimestamp(goctx, obj); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(goctx, obj, opts...)
+}
+
+func (w *finalizerStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.client.ctx.patchLocally(goctx, w.client.Client, obj, patch); err != nil {
+		return err
+	}
+	return w.Update(goctx, obj, patchToUpdateOptions(opts))
+}
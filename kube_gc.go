@@ -4,15 +4,26 @@ import (
 	"reflect"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
 // NaiveGC performs a manual and naive garbage collector using the given object as
-// owner.
+// owner. It looks for owned objects of every kind known by the feature context
+// scheme (or the client-go scheme if the feature context scheme doesn't list
+// its kinds).
 func NaiveGC(ctx *FeatureContext, owner *unstructured.Unstructured) error {
-	for kind, ktype := range scheme.Scheme.AllKnownTypes() {
+	knownTypes := scheme.Scheme.AllKnownTypes()
+	if s, isTypeLister := ctx.scheme.(interface {
+		AllKnownTypes() map[schema.GroupVersionKind]reflect.Type
+	}); isTypeLister {
+		knownTypes = s.AllKnownTypes()
+	}
+
+	for kind, ktype := range knownTypes {
 		if !strings.HasSuffix(kind.Kind, "List") {
 			// ignore non List
 			continue
@@ -26,8 +37,10 @@ func NaiveGC(ctx *FeatureContext, owner *unstructured.Unstructured) error {
 		if _, isRuntimeObject := kobj.Interface().(runtime.Object); !isRuntimeObject {
 			continue
 		}
+		// NOTE: required for kinds registered as Unstructured
+		kobj.Interface().(runtime.Object).GetObjectKind().SetGroupVersionKind(kind)
 
-		err := ctx.client.List(ctx.ctx, kobj.Interface().(runtime.Object))
+		err := ctx.client.List(ctx.internalContext(), kobj.Interface().(runtime.Object))
 		if err != nil {
 			return err
 		}
@@ -61,12 +74,22 @@ func NaiveGC(ctx *FeatureContext, owner *unstructured.Unstructured) error {
 			if len(obj.GetOwnerReferences()) == 0 {
 				continue
 			}
+			if obj.GetKind() == "" {
+				// NOTE: items of typed lists don't have any kind
+				obj.SetGroupVersionKind(kind.GroupVersion().WithKind(strings.TrimSuffix(kind.Kind, "List")))
+			}
 
 			// NOTE: how it works on when several owner exists ?
 			for _, ownerReference := range obj.GetOwnerReferences() {
 				if ownerReference.UID == owner.GetUID() {
 					err := ctx.Client().Delete(ctx.ctx, &obj)
-					if err != nil {
+					if err != nil && !errors.IsNotFound(err) {
+						return err
+					}
+
+					// NOTE: owned objects can also be owners (like ReplicaSets
+					//       owned by a Deployment)
+					if err := NaiveGC(ctx, &obj); err != nil {
 						return err
 					}
 				}
@@ -53,3 +53,45 @@ metadata:
 	_, err = ctx.Get(endpoints.GroupVersionKind(), types.NamespacedName{Namespace: endpoints.GetNamespace(), Name: endpoints.GetName()})
 	assert.True(t, errors.IsNotFound(err))
 }
+
+func TestNaiveGC_CustomResource(t *testing.T) {
+	const (
+		rawService = `apiVersion: v1
+kind: Service
+metadata:
+  name: ownerService
+  namespace: default
+spec:
+  clusterIP: None`
+		rawWidget = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  namespace: default
+  ownerReferences:
+    - apiVersion: v1
+      kind: Service
+      name: ownerService
+      uid: %s
+spec:
+  image: nginx`
+	)
+
+	ctx := initFakeScenarioWithCRDs(t)
+
+	svc := yamlToUnstructured(t, rawService)
+	err := ctx.Create(svc.GroupVersionKind(), types.NamespacedName{Namespace: svc.GetNamespace(), Name: svc.GetName()}, svc)
+	require.NoError(t, err)
+	svc, err = ctx.Get(svc.GroupVersionKind(), types.NamespacedName{Namespace: svc.GetNamespace(), Name: svc.GetName()})
+	require.NoError(t, err)
+
+	widget := yamlToUnstructured(t, fmt.Sprintf(rawWidget, svc.GetUID()))
+	err = ctx.Create(widgetGVK, widgetDefault, widget)
+	require.NoError(t, err)
+
+	err = kubernetes_ctx.NaiveGC(ctx, svc)
+	require.NoError(t, err)
+
+	_, err = ctx.Get(widgetGVK, widgetDefault)
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -0,0 +1,363 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ContainerFailure describes how the containers of a specific image fail
+// when they are started by the simulated kubelet.
+type ContainerFailure string
+
+const (
+	// ImagePullBackOff simulates an image that can't be pulled; the pod
+	// stays Pending.
+	ImagePullBackOff ContainerFailure = "ImagePullBackOff"
+	// CrashLoopBackOff simulates a container that always exits with the
+	// exit code 1; the pod is Running but never ready.
+	CrashLoopBackOff ContainerFailure = "CrashLoopBackOff"
+)
+
+// KubeletOptions configures the simulated kubelet.
+type KubeletOptions struct {
+	// Manual keeps created pods Pending until they are started by a step
+	// (like `Kubernetes pod '<NamespacedName>' becomes ready`).
+	Manual bool
+	// ImageFailures configures how containers fail, per image. Images can be
+	// given with or without their tag.
+	ImageFailures map[string]ContainerFailure
+}
+
+// kubelet simulates the kubelet behaviour on the pods created through the
+// feature context: pods are scheduled on a fake node, get an IP and their
+// containers are started.
+type kubelet struct {
+	opts   KubeletOptions
+	nextIP int
+}
+
+var podGroupVersionKind = corev1.SchemeGroupVersion.WithKind("Pod")
+
+// Handles returns true for pods.
+func (k *kubelet) Handles(gvk schema.GroupVersionKind) bool { return gvk == podGroupVersionKind }
+
+// Emulate starts all new pods, unless the kubelet is in manual mode. Like
+// the kubelet, the started containers are first Running but not ready, and
+// become ready with a following status update.
+func (k *kubelet) Emulate(ctx *FeatureContext, event emulationEvent) error {
+	switch {
+	case event.operation == emulationCreate:
+		return ctx.updatePodStatus(event.key, func(pod *corev1.Pod) error {
+			k.schedule(pod, ctx.now())
+			if !k.opts.Manual {
+				k.start(pod, ctx.now())
+			}
+			return nil
+		})
+	case event.operation == emulationUpdate && event.status && !k.opts.Manual:
+		return k.ready(ctx, event.key)
+	}
+	return nil
+}
+
+// schedule sets the status of a pod newly scheduled on a node: Pending,
+// with all its containers being created.
+func (k *kubelet) schedule(pod *corev1.Pod, now metav1.Time) {
+	if pod.Status.PodIP != "" {
+		return
+	}
+
+	k.nextIP++
+	podIP := fmt.Sprintf("10.244.%d.%d", k.nextIP/254, k.nextIP%254+1)
+
+	pod.Status.Phase = corev1.PodPending
+	pod.Status.HostIP = "10.0.0.1"
+	pod.Status.PodIP = podIP
+	pod.Status.PodIPs = []corev1.PodIP{{IP: podIP}}
+	pod.Status.StartTime = &now
+	pod.Status.QOSClass = corev1.PodQOSBestEffort
+	setPodCondition(pod, corev1.PodScheduled, true, now)
+	setPodCondition(pod, corev1.PodInitialized, true, now)
+
+	pod.Status.InitContainerStatuses = nil
+	for _, container := range pod.Spec.InitContainers {
+		pod.Status.InitContainerStatuses = append(pod.Status.InitContainerStatuses, corev1.ContainerStatus{
+			Name:    container.Name,
+			Image:   container.Image,
+			ImageID: imageID(container.Image),
+			Ready:   true,
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Reason: "Completed", StartedAt: now, FinishedAt: now},
+			},
+		})
+	}
+
+	pod.Status.ContainerStatuses = nil
+	for _, container := range pod.Spec.Containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name:  container.Name,
+			Image: container.Image,
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+		})
+	}
+	updatePodReadiness(pod, now)
+}
+
+// start starts all containers of a pod, following the configured image
+// failures.
+func (k *kubelet) start(pod *corev1.Pod, now metav1.Time) {
+	for i, status := range pod.Status.ContainerStatuses {
+		status.ImageID = imageID(status.Image)
+		status.LastTerminationState = corev1.ContainerState{}
+		status.Ready, status.Started = false, new(bool)
+
+		switch k.failureOf(status.Image) {
+		case ImagePullBackOff:
+			status.ImageID = ""
+			status.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+				Reason:  string(ImagePullBackOff),
+				Message: fmt.Sprintf("Back-off pulling image \"%s\"", status.Image),
+			}}
+		case CrashLoopBackOff:
+			status.RestartCount++
+			status.LastTerminationState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1, Reason: "Error", StartedAt: now, FinishedAt: now,
+			}}
+			status.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+				Reason:  string(CrashLoopBackOff),
+				Message: fmt.Sprintf("back-off 10s restarting failed container=%s pod=%s", status.Name, pod.Name),
+			}}
+		default:
+			started := true
+			status.Started = &started
+			status.State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: now}}
+		}
+		pod.Status.ContainerStatuses[i] = status
+	}
+
+	pod.Status.Phase = corev1.PodPending
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil || status.RestartCount > 0 {
+			pod.Status.Phase = corev1.PodRunning
+		}
+	}
+	updatePodReadiness(pod, now)
+}
+
+// ready marks the started containers of the given pod as ready, if any of
+// them is not ready yet.
+func (k *kubelet) ready(ctx *FeatureContext, namespacedName types.NamespacedName) error {
+	pod := &corev1.Pod{}
+	err := ctx.serverClient().Get(ctx.ctx, namespacedName, pod)
+	switch {
+	case errors.IsNotFound(err):
+		// NOTE: the pod can be removed before its status update is handled
+		return nil
+	case err != nil:
+		return err
+	}
+
+	updated := false
+	for i, status := range pod.Status.ContainerStatuses {
+		if status.State.Running == nil || status.Started == nil || !*status.Started || status.Ready {
+			continue
+		}
+		pod.Status.ContainerStatuses[i].Ready = true
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+
+	updatePodReadiness(pod, ctx.now())
+	return ctx.serverClient().Status().Update(ctx.ctx, pod)
+}
+
+// failureOf returns the failure configured for the given image.
+func (k *kubelet) failureOf(image string) ContainerFailure {
+	if failure, exists := k.opts.ImageFailures[image]; exists {
+		return failure
+	}
+	return k.opts.ImageFailures[imageName(image)]
+}
+
+// MakePodReady starts all containers of the given pod (ignoring the
+// configured image failures) and marks it as ready.
+func (ctx *FeatureContext) MakePodReady(namespacedName types.NamespacedName) error {
+	return ctx.updatePodStatus(namespacedName, func(pod *corev1.Pod) error {
+		now := ctx.now()
+		ctx.podKubelet().schedule(pod, now)
+
+		started := true
+		for i, status := range pod.Status.ContainerStatuses {
+			if status.State.Running == nil {
+				status.State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: now}}
+			}
+			status.ImageID = imageID(status.Image)
+			status.Ready, status.Started = true, &started
+			pod.Status.ContainerStatuses[i] = status
+		}
+
+		pod.Status.Phase = corev1.PodRunning
+		updatePodReadiness(pod, now)
+		return nil
+	})
+}
+
+// TerminatePodContainer terminates the given container with the given exit
+// code. Like the kubelet does, the container is restarted following the pod
+// restart policy (a failed container is then in CrashLoopBackOff) and the
+// pod phase becomes Succeeded or Failed once all its containers are
+// terminated without being restarted.
+func (ctx *FeatureContext) TerminatePodContainer(namespacedName types.NamespacedName, container string, exitCode int32) error {
+	return ctx.updatePodStatus(namespacedName, func(pod *corev1.Pod) error {
+		now := ctx.now()
+		ctx.podKubelet().schedule(pod, now)
+
+		idx := -1
+		for i, status := range pod.Status.ContainerStatuses {
+			if status.Name == container {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("container '%s' not found in pod '%s'", container, namespacedName)
+		}
+
+		reason := "Completed"
+		if exitCode != 0 {
+			reason = "Error"
+		}
+
+		status := pod.Status.ContainerStatuses[idx]
+		terminated := &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: reason, FinishedAt: now}
+		if status.State.Running != nil {
+			terminated.StartedAt = status.State.Running.StartedAt
+		}
+		status.Ready, status.Started = false, new(bool)
+
+		restartPolicy := pod.Spec.RestartPolicy
+		switch {
+		case restartPolicy == corev1.RestartPolicyNever,
+			restartPolicy == corev1.RestartPolicyOnFailure && exitCode == 0:
+			status.State = corev1.ContainerState{Terminated: terminated}
+		case exitCode != 0:
+			status.RestartCount++
+			status.LastTerminationState = corev1.ContainerState{Terminated: terminated}
+			status.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+				Reason:  string(CrashLoopBackOff),
+				Message: fmt.Sprintf("back-off 10s restarting failed container=%s pod=%s", status.Name, pod.Name),
+			}}
+		default:
+			started := true
+			status.RestartCount++
+			status.LastTerminationState = corev1.ContainerState{Terminated: terminated}
+			status.State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: now}}
+			status.Ready, status.Started = true, &started
+		}
+		pod.Status.ContainerStatuses[idx] = status
+
+		pod.Status.Phase = terminatedPodPhase(pod)
+		updatePodReadiness(pod, now)
+		return nil
+	})
+}
+
+// podKubelet returns the simulated kubelet, or a new one if the kubelet
+// simulation is not enabled.
+func (ctx *FeatureContext) podKubelet() *kubelet {
+	if ctx.kubelet == nil {
+		ctx.kubelet = &kubelet{opts: KubeletOptions{Manual: true}}
+	}
+	return ctx.kubelet
+}
+
+// updatePodStatus updates the status of the given pod with the given
+// function, through the server client.
+func (ctx *FeatureContext) updatePodStatus(namespacedName types.NamespacedName, fnc func(pod *corev1.Pod) error) error {
+	pod := &corev1.Pod{}
+	if err := ctx.serverClient().Get(ctx.ctx, namespacedName, pod); err != nil {
+		return err
+	}
+	if err := fnc(pod); err != nil {
+		return err
+	}
+	return ctx.serverClient().Status().Update(ctx.ctx, pod)
+}
+
+// terminatedPodPhase returns the phase of a pod after the termination of one
+// of its containers.
+func terminatedPodPhase(pod *corev1.Pod) corev1.PodPhase {
+	phase := corev1.PodSucceeded
+	for _, status := range pod.Status.ContainerStatuses {
+		switch {
+		case status.State.Terminated == nil:
+			return corev1.PodRunning
+		case status.State.Terminated.ExitCode != 0:
+			phase = corev1.PodFailed
+		}
+	}
+	return phase
+}
+
+// updatePodReadiness updates the Ready and ContainersReady conditions of the
+// given pod, based on its container statuses.
+func updatePodReadiness(pod *corev1.Pod, now metav1.Time) {
+	ready := len(pod.Status.ContainerStatuses) > 0
+	for _, status := range pod.Status.ContainerStatuses {
+		ready = ready && status.Ready
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		ready = false
+	}
+
+	setPodCondition(pod, corev1.ContainersReady, ready, now)
+	setPodCondition(pod, corev1.PodReady, ready, now)
+}
+
+// setPodCondition sets the given condition of a pod, updating the transition
+// time only when its status changes.
+func setPodCondition(pod *corev1.Pod, conditionType corev1.PodConditionType, value bool, now metav1.Time) {
+	status := corev1.ConditionFalse
+	if value {
+		status = corev1.ConditionTrue
+	}
+
+	for i, condition := range pod.Status.Conditions {
+		if condition.Type != conditionType {
+			continue
+		}
+		if condition.Status != status {
+			pod.Status.Conditions[i].Status = status
+			pod.Status.Conditions[i].LastTransitionTime = now
+		}
+		return
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+		Type:               conditionType,
+		Status:             status,
+		LastTransitionTime: now,
+	})
+}
+
+// imageName returns the image without its tag or digest.
+func imageName(image string) string {
+	if idx := strings.Index(image, "@"); idx >= 0 {
+		image = image[:idx]
+	}
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		image = image[:idx]
+	}
+	return image
+}
+
+// imageID returns a fake image ID for the given image.
+func imageID(image string) string {
+	return "docker-pullable://" + imageName(image)
+}
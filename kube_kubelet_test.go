@@ -0,0 +1,168 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+// initFakeScenarioWithKubelet generates a godoc ScenarioContext and a
+// FeatureContext with a fake client and a simulated kubelet.
+func initFakeScenarioWithKubelet(t *testing.T, opts kubernetes_ctx.KubeletOptions) *kubernetes_ctx.FeatureContext {
+	return initFakeScenario(t, kubernetes_ctx.WithKubeletSimulator(opts))
+}
+
+// createPod creates the default/pod pod with the given containers image
+// and returns it once handled by the kubelet.
+func createPod(t *testing.T, ctx *kubernetes_ctx.FeatureContext, restartPolicy corev1.RestartPolicy, images ...string) *corev1.Pod {
+	obj := yamlToUnstructured(t, `spec: {containers: []}`)
+	var containers []interface{}
+	for i, image := range images {
+		containers = append(containers, map[string]interface{}{"name": []string{"app", "sidecar"}[i], "image": image})
+	}
+	obj.Object["spec"] = map[string]interface{}{"containers": containers, "restartPolicy": string(restartPolicy)}
+
+	require.NoError(t, ctx.Create(podGVK, podDefault, obj))
+	return getPod(t, ctx, podDefault)
+}
+
+// getPod returns the given pod.
+func getPod(t *testing.T, ctx *kubernetes_ctx.FeatureContext, namespacedName types.NamespacedName) *corev1.Pod {
+	pod := &corev1.Pod{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), namespacedName, pod))
+	return pod
+}
+
+// podCondition returns the status of the given pod condition.
+func podCondition(pod *corev1.Pod, conditionType corev1.PodConditionType) corev1.ConditionStatus {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status
+		}
+	}
+	return corev1.ConditionUnknown
+}
+
+func TestKubeletSimulator(t *testing.T) {
+	ctx := initFakeScenarioWithKubelet(t, kubernetes_ctx.KubeletOptions{})
+	pod := createPod(t, ctx, corev1.RestartPolicyAlways, "nginx:1.19", "busybox")
+
+	assert.Equal(t, corev1.PodRunning, pod.Status.Phase)
+	assert.Equal(t, "10.244.0.2", pod.Status.PodIP)
+	assert.Equal(t, corev1.ConditionTrue, podCondition(pod, corev1.PodReady))
+	require.Len(t, pod.Status.ContainerStatuses, 2)
+	for _, status := range pod.Status.ContainerStatuses {
+		assert.True(t, status.Ready)
+		assert.NotNil(t, status.State.Running)
+	}
+}
+
+func TestKubeletSimulator_RunningBeforeReady(t *testing.T) {
+	ctx := initFakeScenarioWithKubelet(t, kubernetes_ctx.KubeletOptions{})
+	w, err := ctx.Watch(podGVK)
+	require.NoError(t, err)
+	defer w.Stop()
+
+	createPod(t, ctx, corev1.RestartPolicyAlways, "nginx")
+
+	assert.Equal(t, watch.Added, nextEvent(t, w).Type)
+	for _, ready := range []corev1.ConditionStatus{corev1.ConditionFalse, corev1.ConditionTrue} {
+		event := nextEvent(t, w)
+		require.Equal(t, watch.Modified, event.Type)
+
+		pod := &corev1.Pod{}
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(event.Object.(*unstructured.Unstructured).Object, pod))
+		assert.Equal(t, corev1.PodRunning, pod.Status.Phase)
+		assert.Equal(t, ready, podCondition(pod, corev1.PodReady))
+		assert.Equal(t, ready == corev1.ConditionTrue, pod.Status.ContainerStatuses[0].Ready)
+	}
+}
+
+func TestKubeletSimulator_Manual(t *testing.T) {
+	ctx := initFakeScenarioWithKubelet(t, kubernetes_ctx.KubeletOptions{Manual: true})
+	pod := createPod(t, ctx, corev1.RestartPolicyAlways, "nginx")
+
+	assert.Equal(t, corev1.PodPending, pod.Status.Phase)
+	assert.Equal(t, corev1.ConditionFalse, podCondition(pod, corev1.PodReady))
+	assert.Equal(t, "ContainerCreating", pod.Status.ContainerStatuses[0].State.Waiting.Reason)
+
+	require.NoError(t, ctx.MakePodReady(podDefault))
+	pod = getPod(t, ctx, podDefault)
+	assert.Equal(t, corev1.PodRunning, pod.Status.Phase)
+	assert.Equal(t, corev1.ConditionTrue, podCondition(pod, corev1.PodReady))
+}
+
+func TestKubeletSimulator_ImageFailures(t *testing.T) {
+	ctx := initFakeScenarioWithKubelet(t, kubernetes_ctx.KubeletOptions{
+		ImageFailures: map[string]kubernetes_ctx.ContainerFailure{
+			"registry.local/missing": kubernetes_ctx.ImagePullBackOff,
+			"crash:1.0":              kubernetes_ctx.CrashLoopBackOff,
+		},
+	})
+
+	pod := createPod(t, ctx, corev1.RestartPolicyAlways, "registry.local/missing:latest")
+	assert.Equal(t, corev1.PodPending, pod.Status.Phase)
+	assert.Equal(t, "ImagePullBackOff", pod.Status.ContainerStatuses[0].State.Waiting.Reason)
+	assert.Equal(t, corev1.ConditionFalse, podCondition(pod, corev1.PodReady))
+
+	_, err := ctx.Delete(podGVK, podDefault)
+	require.NoError(t, err)
+
+	pod = createPod(t, ctx, corev1.RestartPolicyAlways, "nginx", "crash:1.0")
+	assert.Equal(t, corev1.PodRunning, pod.Status.Phase)
+	assert.Equal(t, "CrashLoopBackOff", pod.Status.ContainerStatuses[1].State.Waiting.Reason)
+	assert.Equal(t, int32(1), pod.Status.ContainerStatuses[1].RestartCount)
+	assert.Equal(t, corev1.ConditionFalse, podCondition(pod, corev1.PodReady))
+}
+
+func TestFeatureContext_TerminatePodContainer(t *testing.T) {
+	tests := map[string]struct {
+		restartPolicy corev1.RestartPolicy
+		exitCode      int32
+		phase         corev1.PodPhase
+		restartCount  int32
+		waiting       string
+	}{
+		"Always/0":    {restartPolicy: corev1.RestartPolicyAlways, exitCode: 0, phase: corev1.PodRunning, restartCount: 1},
+		"Always/1":    {restartPolicy: corev1.RestartPolicyAlways, exitCode: 1, phase: corev1.PodRunning, restartCount: 1, waiting: "CrashLoopBackOff"},
+		"OnFailure/0": {restartPolicy: corev1.RestartPolicyOnFailure, exitCode: 0, phase: corev1.PodSucceeded},
+		"OnFailure/1": {restartPolicy: corev1.RestartPolicyOnFailure, exitCode: 1, phase: corev1.PodRunning, restartCount: 1, waiting: "CrashLoopBackOff"},
+		"Never/0":     {restartPolicy: corev1.RestartPolicyNever, exitCode: 0, phase: corev1.PodSucceeded},
+		"Never/1":     {restartPolicy: corev1.RestartPolicyNever, exitCode: 1, phase: corev1.PodFailed},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := initFakeScenarioWithKubelet(t, kubernetes_ctx.KubeletOptions{})
+			createPod(t, ctx, test.restartPolicy, "nginx")
+
+			require.NoError(t, ctx.TerminatePodContainer(podDefault, "app", test.exitCode))
+			pod := getPod(t, ctx, podDefault)
+
+			status := pod.Status.ContainerStatuses[0]
+			assert.Equal(t, test.phase, pod.Status.Phase)
+			assert.Equal(t, test.restartCount, status.RestartCount)
+			if test.waiting != "" {
+				require.NotNil(t, status.State.Waiting)
+				assert.Equal(t, test.waiting, status.State.Waiting.Reason)
+			}
+			assert.Equal(t, test.phase == corev1.PodRunning && test.waiting == "", status.Ready)
+		})
+	}
+}
+
+func TestFeatureContext_TerminatePodContainer_NotFound(t *testing.T) {
+	ctx := initFakeScenarioWithKubelet(t, kubernetes_ctx.KubeletOptions{})
+	createPod(t, ctx, corev1.RestartPolicyAlways, "nginx")
+
+	err := ctx.TerminatePodContainer(podDefault, "unknown", 0)
+	assert.EqualError(t, err, "container 'unknown' not found in pod 'default/pod'")
+}
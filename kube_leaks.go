@@ -0,0 +1,145 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type (
+	// LeakDetectionOptions configures the detection of the objects left
+	// behind by the scenarios (see WithLeakDetection).
+	LeakDetectionOptions struct {
+		// Namespaces lists the namespaces in which the resources of all
+		// namespaced kinds known by the scheme are inspected.
+		Namespaces []string
+		// Kinds lists the kinds inspected in all namespaces.
+		Kinds []schema.GroupVersionKind
+		// OnLeak is called at the end of each scenario which left objects
+		// behind, with a *LeakError, or with the error raised while
+		// listing the objects. By default, errors are written as warnings
+		// on the standard error.
+		OnLeak func(err error)
+		// FailOnLeak stops the test suite with a panic carrying the
+		// *LeakError, once OnLeak has been called.
+		// NOTE: godog doesn't allow the hooks to fail a scenario once its
+		//       steps are done, so the whole suite is stopped instead
+		FailOnLeak bool
+	}
+
+	// LeakError describes the objects left behind by a scenario.
+	LeakError struct {
+		Scenario string
+		Objects  []string
+	}
+
+	// leakDetector keeps the inventory of the inspected objects taken
+	// at the beginning of the scenario.
+	leakDetector struct {
+		opts      LeakDetectionOptions
+		inventory map[string]string
+		err       error
+	}
+)
+
+func (err *LeakError) Error() string {
+	return fmt.Sprintf("scenario '%s' leaked %d object(s): %s", err.Scenario, len(err.Objects), strings.Join(err.Objects, ", "))
+}
+
+// IsLeak returns true if the given error describes objects left behind by
+// a scenario.
+func IsLeak(err error) bool {
+	_, isLeak := err.(*LeakError)
+	return isLeak
+}
+
+// warnLeak is the default OnLeak handler.
+func warnLeak(err error) { fmt.Fprintf(os.Stderr, "WARNING: %s\n", err) }
+
+// takeInventory keeps the inspected objects existing at the beginning of
+// the scenario.
+func (ctx *FeatureContext) takeInventory() {
+	ctx.leaks.inventory, ctx.leaks.err = ctx.inventory()
+}
+
+// detectLeaks compares the inspected objects with the ones existing at the
+// beginning of the scenario, and reports the new ones.
+// Objects being deleted are not reported, because they are already cleaned.
+func (ctx *FeatureContext) detectLeaks(sc *godog.Scenario) {
+	onLeak := ctx.leaks.opts.OnLeak
+	if onLeak == nil {
+		onLeak = warnLeak
+	}
+
+	var scenario string
+	if sc != nil {
+		scenario = sc.Name
+	}
+
+	if ctx.leaks.err != nil {
+		onLeak(fmt.Errorf("unable to detect leaks of scenario '%s': %w", scenario, ctx.leaks.err))
+		return
+	}
+	inventory, err := ctx.inventory()
+	if err != nil {
+		onLeak(fmt.Errorf("unable to detect leaks of scenario '%s': %w", scenario, err))
+		return
+	}
+
+	var leaked []string
+	for key, description := range inventory {
+		if _, existed := ctx.leaks.inventory[key]; !existed {
+			leaked = append(leaked, description)
+		}
+	}
+	if len(leaked) > 0 {
+		sort.Strings(leaked)
+		err := &LeakError{Scenario: scenario, Objects: leaked}
+		onLeak(err)
+		if ctx.leaks.opts.FailOnLeak {
+			panic(err)
+		}
+	}
+}
+
+// inventory lists all inspected objects, through the client without any
+// interceptor (in order to be neither recorded nor denied).
+func (ctx *FeatureContext) inventory() (map[string]string, error) {
+	inventory := map[string]string{}
+	add := func(objs []*unstructured.Unstructured) {
+		for _, obj := range objs {
+			if obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			groupVersionKind := obj.GroupVersionKind()
+			namespacedName := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+			inventory[resourceKey(groupVersionKind.GroupKind(), namespacedName)] = describeResource(groupVersionKind, namespacedName)
+		}
+	}
+
+	for _, namespace := range ctx.leaks.opts.Namespaces {
+		objs, err := ctx.listAllWith(ctx.internalContext(), ctx.serverClient(), client.InNamespace(namespace))
+		if err != nil {
+			return nil, err
+		}
+		add(objs)
+	}
+	for _, groupVersionKind := range ctx.leaks.opts.Kinds {
+		objs, err := ctx.listWith(ctx.internalContext(), ctx.serverClient(), groupVersionKind)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			obj.SetGroupVersionKind(groupVersionKind)
+		}
+		add(objs)
+	}
+	return inventory, nil
+}
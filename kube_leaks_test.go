@@ -0,0 +1,99 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+func TestWithLeakDetection(t *testing.T) {
+	var leaks []error
+	var ctx *kubernetes_ctx.FeatureContext
+	scenarioContextMock := MockScenarioContext()
+
+	configMapGVK := namespaceGVK.GroupVersion().WithKind("ConfigMap")
+	cleaned := types.NamespacedName{Namespace: "default", Name: "cleaned"}
+	// NOTE: cleanup hooks are registered before the feature context, in
+	//       order to run before the leak detection
+	scenarioContextMock.AfterScenario(func(*godog.Scenario, error) {
+		_, err := ctx.Delete(configMapGVK, cleaned)
+		require.NoError(t, err)
+	})
+
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithFixtureDir("features/resources/fixtures"),
+		kubernetes_ctx.WithLeakDetection(kubernetes_ctx.LeakDetectionOptions{
+			Namespaces: []string{"default"},
+			Kinds:      []schema.GroupVersionKind{namespaceGVK},
+			OnLeak:     func(err error) { leaks = append(leaks, err) },
+		}),
+	)
+	require.NoError(t, err)
+
+	scenario := &godog.Scenario{Name: "leaking scenario"}
+	scenarioContextMock.RunScenarioWith(scenario)
+	require.NoError(t, ctx.Create(configMapGVK, cleaned, &unstructured.Unstructured{}))
+	require.NoError(t, ctx.Create(configMapGVK, types.NamespacedName{Namespace: "default", Name: "leaked"}, &unstructured.Unstructured{}))
+	require.NoError(t, ctx.Create(namespaceGVK, types.NamespacedName{Name: "leaked"}, &unstructured.Unstructured{}))
+	_, err = ctx.Delete(namespaceGVK, types.NamespacedName{Name: "kube-system"})
+	require.NoError(t, err)
+	scenarioContextMock.EndScenarioWith(scenario, nil)
+
+	require.Len(t, leaks, 1)
+	assert.True(t, kubernetes_ctx.IsLeak(leaks[0]))
+	assert.EqualError(t, leaks[0], "scenario 'leaking scenario' leaked 2 object(s): v1/ConfigMap 'default/leaked', v1/Namespace '/leaked'")
+
+	leaks = nil
+	scenarioContextMock.RunScenarioWith(scenario)
+	require.NoError(t, ctx.Create(configMapGVK, cleaned, &unstructured.Unstructured{}))
+	scenarioContextMock.EndScenarioWith(scenario, nil)
+	assert.Empty(t, leaks)
+}
+
+func TestWithLeakDetection_FailOnLeak(t *testing.T) {
+	var leaks []error
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithLeakDetection(kubernetes_ctx.LeakDetectionOptions{
+			Kinds:      []schema.GroupVersionKind{namespaceGVK},
+			OnLeak:     func(err error) { leaks = append(leaks, err) },
+			FailOnLeak: true,
+		}),
+	)
+	require.NoError(t, err)
+	hooks := len(scenarioContextMock.afterScenarioList)
+
+	scenario := &godog.Scenario{Name: "leaking scenario"}
+	scenarioContextMock.RunScenarioWith(scenario)
+	require.NoError(t, ctx.Create(namespaceGVK, types.NamespacedName{Name: "leaked"}, &unstructured.Unstructured{}))
+	assert.PanicsWithError(t,
+		"scenario 'leaking scenario' leaked 1 object(s): v1/Namespace '/leaked'",
+		func() { scenarioContextMock.EndScenarioWith(scenario, nil) },
+	)
+	assert.Len(t, leaks, 1)
+
+	// the hooks are registered once, whatever the number of scenarios
+	scenarioContextMock.RunScenarioWith(scenario)
+	scenarioContextMock.EndScenarioWith(scenario, nil)
+	assert.Len(t, scenarioContextMock.afterScenarioList, hooks)
+}
+
+func TestWithLeakDetection_NothingToInspect(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithLeakDetection(kubernetes_ctx.LeakDetectionOptions{}),
+	)
+	assert.EqualError(t, err, "leak detection requires at least one namespace or kind to inspect")
+}
@@ -0,0 +1,287 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+// clusterScopedKinds lists all Kubernetes built-in kinds which are not
+// namespaced. It is used to build a RESTMapper from a scheme, which doesn't
+// have any information about the resource scope.
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "ComponentStatus"}:                                            true,
+	{Group: "", Kind: "Namespace"}:                                                  true,
+	{Group: "", Kind: "Node"}:                                                       true,
+	{Group: "", Kind: "PersistentVolume"}:                                           true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:               true,
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}:                           true,
+	{Group: "authentication.k8s.io", Kind: "TokenReview"}:                           true,
+	{Group: "authorization.k8s.io", Kind: "SelfSubjectAccessReview"}:                true,
+	{Group: "authorization.k8s.io", Kind: "SelfSubjectRulesReview"}:                 true,
+	{Group: "authorization.k8s.io", Kind: "SubjectAccessReview"}:                    true,
+	{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}:               true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}:                     true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}:     true,
+	{Group: "networking.k8s.io", Kind: "IngressClass"}:                              true,
+	{Group: "node.k8s.io", Kind: "RuntimeClass"}:                                    true,
+	{Group: "policy", Kind: "PodSecurityPolicy"}:                                    true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                       true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                             true,
+	{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                    true,
+	{Group: "storage.k8s.io", Kind: "CSINode"}:                                      true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                 true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttachment"}:                             true,
+}
+
+// newSchemeRESTMapper builds a RESTMapper with all kinds known by the given
+// scheme (if it is able to list them) and defined by the given
+// CustomResourceDefinitions. Only kinds with an ObjectMeta are mapped;
+// lists, options and internal kinds are ignored.
+func newSchemeRESTMapper(s Scheme, crds []*apiextensions.CustomResourceDefinition) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+
+	if s, isTypeLister := s.(interface {
+		AllKnownTypes() map[schema.GroupVersionKind]reflect.Type
+	}); isTypeLister {
+		for gvk, ktype := range s.AllKnownTypes() {
+			if gvk.Version == runtime.APIVersionInternal {
+				continue
+			}
+			if _, isObject := reflect.New(ktype).Interface().(metav1.Object); !isObject {
+				continue
+			}
+
+			scope := meta.RESTScopeNamespace
+			if clusterScopedKinds[gvk.GroupKind()] {
+				scope = meta.RESTScopeRoot
+			}
+			mapper.Add(gvk, scope)
+		}
+	}
+
+	// NOTE: custom resources are registered after the scheme kinds in order
+	//       to override their scope and resource names
+	for _, crd := range crds {
+		scope := meta.RESTScopeNamespace
+		if crd.Spec.Scope == apiextensions.ClusterScoped {
+			scope = meta.RESTScopeRoot
+		}
+
+		for _, version := range crd.Spec.Versions {
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			mapper.AddSpecific(
+				gvk,
+				gvk.GroupVersion().WithResource(crd.Spec.Names.Plural),
+				gvk.GroupVersion().WithResource(crd.Spec.Names.Singular),
+				scope,
+			)
+		}
+	}
+	return mapper
+}
+
+// restMapping resolves the given kind through the feature context RESTMapper.
+// It returns an UnknownKindError if the kind is not known.
+func (ctx *FeatureContext) restMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := ctx.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	switch {
+	case meta.IsNoMatchError(err):
+		return nil, &UnknownKindError{GroupVersionKind: gvk, Err: err}
+	case err != nil:
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// newObject returns a new empty object of the given kind. It uses the Go type
+// registered in the scheme if any, or an Unstructured object otherwise.
+func (ctx *FeatureContext) newObject(gvk schema.GroupVersionKind) (runtime.Object, error) {
+	if _, err := ctx.restMapping(gvk); err != nil {
+		return nil, err
+	}
+
+	kobj, err := ctx.scheme.New(gvk)
+	if runtime.IsNotRegisteredError(err) {
+		kobj, err = &unstructured.Unstructured{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: required for kinds registered as Unstructured
+	kobj.GetObjectKind().SetGroupVersionKind(gvk)
+	return kobj, nil
+}
+
+// listableKinds returns all kinds registered inside the scheme which can be
+// listed, sorted by group, version and kind.
+func (ctx *FeatureContext) listableKinds() ([]schema.GroupVersionKind, error) {
+	rscheme, isRuntimeScheme := ctx.scheme.(*runtime.Scheme)
+	if !isRuntimeScheme {
+		return nil, fmt.Errorf("kinds can only be enumerated from a *runtime.Scheme (current: %T)", ctx.scheme)
+	}
+	knownTypes := rscheme.AllKnownTypes()
+
+	var kinds []schema.GroupVersionKind
+	for gvk := range knownTypes {
+		if gvk.Version == runtime.APIVersionInternal {
+			continue
+		}
+		if _, hasList := knownTypes[gvk.GroupVersion().WithKind(gvk.Kind+"List")]; !hasList {
+			continue
+		}
+		if _, err := ctx.restMapping(gvk); err != nil {
+			continue
+		}
+		kinds = append(kinds, gvk)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].String() < kinds[j].String() })
+	return kinds, nil
+}
+
+// defaultKindAliases contains the short names of the Kubernetes built-in
+// resources, like kubectl knows them.
+var defaultKindAliases = map[string]string{
+	"cm":     "configmaps",
+	"crd":    "customresourcedefinitions.apiextensions.k8s.io",
+	"crds":   "customresourcedefinitions.apiextensions.k8s.io",
+	"cj":     "cronjobs.batch",
+	"cs":     "componentstatuses",
+	"csr":    "certificatesigningrequests.certificates.k8s.io",
+	"deploy": "deployments.apps",
+	"ds":     "daemonsets.apps",
+	"ep":     "endpoints",
+	"ev":     "events",
+	"hpa":    "horizontalpodautoscalers.autoscaling",
+	"ing":    "ingresses.networking.k8s.io",
+	"limits": "limitranges",
+	"netpol": "networkpolicies.networking.k8s.io",
+	"no":     "nodes",
+	"ns":     "namespaces",
+	"pc":     "priorityclasses.scheduling.k8s.io",
+	"pdb":    "poddisruptionbudgets.policy",
+	"po":     "pods",
+	"psp":    "podsecuritypolicies.policy",
+	"pv":     "persistentvolumes",
+	"pvc":    "persistentvolumeclaims",
+	"quota":  "resourcequotas",
+	"rc":     "replicationcontrollers",
+	"rs":     "replicasets.apps",
+	"sa":     "serviceaccounts",
+	"sc":     "storageclasses.storage.k8s.io",
+	"sts":    "statefulsets.apps",
+	"svc":    "services",
+}
+
+// legacyGroups contains the API groups whose kinds have been moved to other
+// groups. They are ignored when resolving a kind matching also other groups.
+var legacyGroups = map[string]bool{"extensions": true}
+
+// ResolveGroupVersionKind converts the given string into a GroupVersionKind.
+// In addition of the complete forms (`apps/v1/Deployment` or `v1/Pod`), it
+// accepts kinds (`Deployment`), resources (`deployments`) and short names
+// (`deploy`), optionally followed by their group (`Deployment.apps`). These
+// short forms are resolved through the RESTMapper, using the preferred
+// version of the matching group. It returns an AmbiguousKindError if the
+// name matches kinds of several groups.
+func (ctx *FeatureContext) ResolveGroupVersionKind(str string) (schema.GroupVersionKind, error) {
+	if strings.Contains(str, "/") {
+		return helpers.GroupVersionKindFrom(str)
+	}
+
+	resource, group := splitKindName(str)
+	if alias, exists := ctx.kindAlias(resource); exists {
+		var aliasGroup string
+		resource, aliasGroup = splitKindName(alias)
+		if group == "" {
+			group = aliasGroup
+		}
+	}
+
+	kinds, err := ctx.mapper.KindsFor(schema.GroupVersionResource{Group: group, Resource: resource})
+	switch {
+	case meta.IsNoMatchError(err):
+		return schema.GroupVersionKind{}, &UnknownKindError{Name: str, Err: err}
+	case err != nil:
+		return schema.GroupVersionKind{}, err
+	}
+
+	candidates := preferredKinds(kinds)
+	if len(candidates) > 1 {
+		return schema.GroupVersionKind{}, &AmbiguousKindError{Name: str, Candidates: candidates}
+	}
+	return candidates[0], nil
+}
+
+// kindAlias returns the resource name targeted by the given alias, looking
+// first in the aliases given through the options, then in the
+// CustomResourceDefinitions short names and finally in the Kubernetes
+// built-in short names.
+func (ctx *FeatureContext) kindAlias(alias string) (string, bool) {
+	alias = strings.ToLower(alias)
+
+	if resource, exists := ctx.kindAliases[alias]; exists {
+		return resource, true
+	}
+	for _, crd := range ctx.crds {
+		for _, shortName := range crd.Spec.Names.ShortNames {
+			if shortName == alias {
+				return crd.Spec.Names.Plural + "." + crd.Spec.Group, true
+			}
+		}
+	}
+	resource, exists := defaultKindAliases[alias]
+	return resource, exists
+}
+
+// splitKindName splits names like `deployments.apps` into a resource (or
+// kind) name and a group.
+func splitKindName(name string) (string, string) {
+	if idx := strings.Index(name, "."); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return name, ""
+}
+
+// preferredKinds keeps only the preferred version of each group, using the
+// Kubernetes version priority (v1 > v1beta2 > v1beta1 > v1alpha1). If the
+// core group is part of the candidates, it is always preferred, like
+// kubectl does; legacy groups are dropped if other groups match.
+func preferredKinds(kinds []schema.GroupVersionKind) []schema.GroupVersionKind {
+	preferred := map[string]schema.GroupVersionKind{}
+	for _, kind := range kinds {
+		current, exists := preferred[kind.Group]
+		if !exists || version.CompareKubeAwareVersionStrings(kind.Version, current.Version) > 0 {
+			preferred[kind.Group] = kind
+		}
+	}
+
+	if kind, exists := preferred[""]; exists {
+		return []schema.GroupVersionKind{kind}
+	}
+
+	var candidates []schema.GroupVersionKind
+	for group, kind := range preferred {
+		if legacyGroups[group] && len(preferred) > 1 {
+			continue
+		}
+		candidates = append(candidates, kind)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Group < candidates[j].Group })
+	return candidates
+}
@@ -0,0 +1,156 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+func TestFeatureContext_RESTMapper(t *testing.T) {
+	ctx := initFakeScenarioWithCRDs(t)
+
+	tests := map[string]struct {
+		gvk      schema.GroupVersionKind
+		resource string
+		scope    meta.RESTScopeName
+	}{
+		"Namespace":   {gvk: namespaceGVK, resource: "namespaces", scope: meta.RESTScopeNameRoot},
+		"Pod":         {gvk: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, resource: "pods", scope: meta.RESTScopeNameNamespace},
+		"ClusterRole": {gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, resource: "clusterroles", scope: meta.RESTScopeNameRoot},
+		"Widget":      {gvk: widgetGVK, resource: "widgets", scope: meta.RESTScopeNameNamespace},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mapping, err := ctx.RESTMapper().RESTMapping(test.gvk.GroupKind(), test.gvk.Version)
+			require.NoError(t, err)
+			assert.Equal(t, test.resource, mapping.Resource.Resource)
+			assert.Equal(t, test.scope, mapping.Scope.Name())
+		})
+	}
+
+	_, err := ctx.RESTMapper().RESTMapping(schema.GroupKind{Kind: "PodList"}, "v1")
+	assert.True(t, meta.IsNoMatchError(err))
+}
+
+func TestWithRESTMapper(t *testing.T) {
+	gadgetGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(gadgetGVK, meta.RESTScopeNamespace)
+
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithRESTMapper(mapper),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+	assert.Equal(t, mapper, ctx.RESTMapper())
+
+	// kinds unknown by the scheme are managed through Unstructured objects
+	err = ctx.Create(gadgetGVK, types.NamespacedName{Namespace: "default", Name: "gadget"}, &unstructured.Unstructured{})
+	require.NoError(t, err)
+	obj, err := ctx.Get(gadgetGVK, types.NamespacedName{Namespace: "default", Name: "gadget"})
+	require.NoError(t, err)
+	assert.Equal(t, "gadget", obj.GetName())
+
+	_, err = ctx.Get(namespaceGVK, namespaceDefault)
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+}
+
+func TestFeatureContext_ResolveGroupVersionKind(t *testing.T) {
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	ingressGVK := schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}
+	eventGVK := schema.GroupVersionKind{Version: "v1", Kind: "Event"}
+
+	ctx := initFakeScenarioWithCRDs(t)
+
+	tests := map[string]schema.GroupVersionKind{
+		"apps/v1/Deployment":      deploymentGVK,
+		"apps/v1beta1/Deployment": {Group: "apps", Version: "v1beta1", Kind: "Deployment"},
+		"v1/Namespace":            namespaceGVK,
+		"Deployment":              deploymentGVK,
+		"deployment":              deploymentGVK,
+		"deployments":             deploymentGVK,
+		"deploy":                  deploymentGVK,
+		"Deployment.apps":         deploymentGVK,
+		"deployments.apps":        deploymentGVK,
+		"Namespace":               namespaceGVK,
+		"ns":                      namespaceGVK,
+		"Ingress":                 ingressGVK,
+		"Event":                   eventGVK,
+		"Widget":                  widgetGVK,
+		"widgets.example.com":     widgetGVK,
+		"wg":                      widgetGVK,
+	}
+
+	for name, expected := range tests {
+		t.Run(name, func(t *testing.T) {
+			gvk, err := ctx.ResolveGroupVersionKind(name)
+			require.NoError(t, err)
+			assert.Equal(t, expected, gvk)
+		})
+	}
+}
+
+func TestFeatureContext_ResolveGroupVersionKind_Unknown(t *testing.T) {
+	ctx := initFakeScenario(t)
+
+	_, err := ctx.ResolveGroupVersionKind("Unknown")
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+	assert.EqualError(t, err, "unknown kind Unknown: it must be registered in the scheme, defined by a CustomResourceDefinition or served by the API server")
+
+	_, err = ctx.ResolveGroupVersionKind("Deployment.batch")
+	assert.True(t, kubernetes_ctx.IsUnknownKind(err))
+}
+
+func TestFeatureContext_ResolveGroupVersionKind_Ambiguous(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1beta1", Kind: "Gadget"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "Gadget"}, meta.RESTScopeNamespace)
+
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithRESTMapper(mapper),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	_, err = ctx.ResolveGroupVersionKind("gadgets")
+	assert.True(t, kubernetes_ctx.IsAmbiguousKind(err))
+	assert.EqualError(t, err, "ambiguous kind gadgets: it matches example.com/v1/Gadget, example.org/v1alpha1/Gadget")
+
+	gvk, err := ctx.ResolveGroupVersionKind("Gadget.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "Gadget"}, gvk)
+}
+
+func TestWithKindAliases(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithKindAliases(map[string]string{"Deploy": "statefulsets.apps", "job": "jobs.batch"}),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	gvk, err := ctx.ResolveGroupVersionKind("deploy")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}, gvk)
+
+	gvk, err = ctx.ResolveGroupVersionKind("job")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, gvk)
+}
@@ -0,0 +1,139 @@
+package kubernetes_ctx
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type (
+	// metadataClient wraps the fake client in order to manage the metadata
+	// fields set by the API server (uid, creationTimestamp, generation and
+	// the name generated from generateName) on all writes. Patches are
+	// applied locally and then written with an Update.
+	metadataClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// metadataStatusWriter keeps the metadata fields set by the API server
+	// when the status subresource is written.
+	metadataStatusWriter struct {
+		client.StatusWriter
+		client *metadataClient
+	}
+)
+
+func (c *metadataClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	if accessor.GetName() == "" && accessor.GetGenerateName() != "" {
+		accessor.SetName(accessor.GetGenerateName() + utilrand.String(5))
+	}
+	accessor.SetUID(types.UID(uuid.New().String()))
+	accessor.SetCreationTimestamp(c.ctx.now())
+	accessor.SetGeneration(1)
+	return c.Client.Create(goctx, obj, opts...)
+}
+
+func (c *metadataClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.keepMetadata(goctx, obj, true); err != nil {
+		return err
+	}
+	return c.Client.Update(goctx, obj, opts...)
+}
+
+func (c *metadataClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.ctx.patchLocally(goctx, c.Client, obj, patch); err != nil {
+		return err
+	}
+	return c.Update(goctx, obj, patchToUpdateOptions(opts))
+}
+
+func (c *metadataClient) Status() client.StatusWriter {
+	return &metadataStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// keepMetadata restores the metadata fields set by the API server from the
+// stored version of the given object, ignoring the changes made by the
+// caller. The generation is incremented if required and anything other
+// than the metadata or the status has changed.
+func (c *metadataClient) keepMetadata(goctx context.Context, obj runtime.Object, bumpGeneration bool) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	current := obj.DeepCopyObject()
+	err = c.Client.Get(goctx, client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}, current)
+	switch {
+	case errors.IsNotFound(err):
+		// the underlying client will return the right error
+		return nil
+	case err != nil:
+		return err
+	}
+	currentAccessor, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+
+	accessor.SetUID(currentAccessor.GetUID())
+	accessor.SetCreationTimestamp(currentAccessor.GetCreationTimestamp())
+	accessor.SetGeneration(currentAccessor.GetGeneration())
+	if !bumpGeneration {
+		return nil
+	}
+
+	changed, err := specChanged(current, obj)
+	if err != nil {
+		return err
+	}
+	if changed {
+		accessor.SetGeneration(currentAccessor.GetGeneration() + 1)
+	}
+	return nil
+}
+
+// specChanged returns true if anything other than the metadata or the
+// status differs between both objects.
+func specChanged(old, obj runtime.Object) (bool, error) {
+	oldContent, err := toUnstructuredContent(old)
+	if err != nil {
+		return false, err
+	}
+	content, err := toUnstructuredContent(obj)
+	if err != nil {
+		return false, err
+	}
+
+	for _, field := range []string{"apiVersion", "kind", "metadata", "status"} {
+		delete(oldContent, field)
+		delete(content, field)
+	}
+	return !equality.Semantic.DeepEqual(oldContent, content), nil
+}
+
+func (w *metadataStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.client.keepMetadata(goctx, obj, false); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(goctx, obj, opts...)
+}
+
+func (w *metadataStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.client.ctx.patchLocally(goctx, w.client.Client, obj, patch); err != nil {
+		return err
+	}
+	return w.Update(goctx, obj, patchToUpdateOptions(opts))
+}
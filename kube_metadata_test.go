@@ -0,0 +1,100 @@
+package kubernetes_ctx_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+func initFakeScenarioWithServerMetadata(t *testing.T, now time.Time) *kubernetes_ctx.FeatureContext {
+	return initFakeScenario(t, kubernetes_ctx.WithServerMetadata(), kubernetes_ctx.WithClock(clock.NewFakeClock(now)))
+}
+
+func TestWithServerMetadata(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := initFakeScenarioWithServerMetadata(t, now)
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	nginx := types.NamespacedName{Namespace: "default", Name: "nginx"}
+
+	obj := yamlToUnstructured(t, `
+metadata:
+  creationTimestamp: "2000-01-01T00:00:00Z"
+spec:
+  replicas: 1
+  selector:
+    matchLabels: {app: nginx}
+  template:
+    metadata:
+      labels: {app: nginx}
+    spec:
+      containers: [{name: nginx, image: nginx}]
+`)
+	require.NoError(t, ctx.Create(deploymentGVK, nginx, obj))
+
+	deployment, err := ctx.Get(deploymentGVK, nginx)
+	require.NoError(t, err)
+	uid := deployment.GetUID()
+	assert.Equal(t, int64(1), deployment.GetGeneration())
+	assert.True(t, now.Equal(deployment.GetCreationTimestamp().Time))
+
+	// metadata changes don't increment the generation
+	deployment.SetLabels(map[string]string{"key": "value"})
+	deployment.SetCreationTimestamp(metav1.NewTime(now.Add(time.Hour)))
+	deployment.SetUID("changed")
+	require.NoError(t, ctx.Update(deploymentGVK, nginx, deployment))
+	deployment, err = ctx.Get(deploymentGVK, nginx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deployment.GetGeneration())
+	assert.Equal(t, uid, deployment.GetUID())
+	assert.True(t, now.Equal(deployment.GetCreationTimestamp().Time))
+
+	// spec changes increment the generation
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(2), "spec", "replicas"))
+	require.NoError(t, ctx.Update(deploymentGVK, nginx, deployment))
+	require.NoError(t, ctx.Patch(deploymentGVK, nginx, types.MergePatchType, []byte(`{"spec":{"replicas":3}}`)))
+	deployment, err = ctx.Get(deploymentGVK, nginx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deployment.GetGeneration())
+
+	// status changes don't increment the generation
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(3), "status", "observedGeneration"))
+	require.NoError(t, ctx.Client().Status().Update(ctx.GoContext(), deployment))
+	deployment, err = ctx.Get(deploymentGVK, nginx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deployment.GetGeneration())
+}
+
+func TestWithServerMetadata_GenerateName(t *testing.T) {
+	ctx := initFakeScenarioWithServerMetadata(t, time.Now())
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", GenerateName: "config-"}}
+	require.NoError(t, ctx.Client().Create(ctx.GoContext(), configMap))
+	assert.True(t, strings.HasPrefix(configMap.Name, "config-"))
+	assert.Len(t, configMap.Name, len("config-")+5)
+
+	configMaps := &corev1.ConfigMapList{}
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), configMaps, ctrlclient.InNamespace("default")))
+	require.Len(t, configMaps.Items, 1)
+	assert.Equal(t, configMap.Name, configMaps.Items[0].Name)
+}
+
+func TestWithServerMetadata_RealClient(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithServerMetadata(),
+		kubernetes_ctx.WithClient(scheme, client),
+	)
+	assert.EqualError(t, err, "server-side metadata can only be emulated on the fake client")
+}
@@ -0,0 +1,596 @@
+package kubernetes_ctx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	serviceAccountUserPrefix  = "system:serviceaccount:"
+	serviceAccountGroupPrefix = "system:serviceaccounts"
+	authenticatedGroup        = "system:authenticated"
+	mastersGroup              = "system:masters"
+
+	actAsTagPrefix = "@as:"
+)
+
+type (
+	// userInfo describes the user used by the feature context client.
+	userInfo struct {
+		name   string
+		groups []string
+	}
+
+	// rbacAttributes describes a request that must be authorized.
+	rbacAttributes struct {
+		verb        string
+		namespace   string
+		apiGroup    string
+		resource    string
+		subresource string
+		name        string
+	}
+
+	// rbacClient wraps a client.Client in order to authorize all requests
+	// with the RBAC rules of the current user. On a fake client, RBAC rules
+	// are evaluated locally from the Role, ClusterRole, RoleBinding and
+	// ClusterRoleBinding objects; on a real cluster, the user is
+	// impersonated by the impersonatingClient.
+	rbacClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// rbacStatusWriter wraps a client.StatusWriter in order to authorize
+	// all requests on the status subresource.
+	rbacStatusWriter struct {
+		client.StatusWriter
+		client *rbacClient
+	}
+
+	// impersonatingClient wraps the real cluster client in order to send
+	// the requests as the user carried by their context (see rbacClient),
+	// below all other client wrappers.
+	impersonatingClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// impersonatingStatusWriter sends the requests on the status
+	// subresource as the user carried by their context.
+	impersonatingStatusWriter struct {
+		client.StatusWriter
+		client *impersonatingClient
+	}
+
+	// impersonatedUserKey is the context key of the user to impersonate.
+	impersonatedUserKey struct{}
+)
+
+// newUserInfo returns the user information of the given user name. Groups
+// of service accounts and the authenticated group are automatically added,
+// like the API server does.
+func newUserInfo(name string, groups ...string) *userInfo {
+	user := &userInfo{name: name, groups: append([]string{}, groups...)}
+
+	if strings.HasPrefix(name, serviceAccountUserPrefix) {
+		items := strings.Split(strings.TrimPrefix(name, serviceAccountUserPrefix), ":")
+		if len(items) == 2 {
+			user.groups = append(user.groups, serviceAccountGroupPrefix, serviceAccountGroupPrefix+":"+items[0])
+		}
+	}
+	user.groups = append(user.groups, authenticatedGroup)
+	return user
+}
+
+// serviceAccountUserName returns the user name of the given service account.
+func serviceAccountUserName(namespace, name string) string {
+	return serviceAccountUserPrefix + namespace + ":" + name
+}
+
+// ActAs configures the feature context client to send all requests as the
+// given user (and groups). Requests are then authorized with the RBAC rules
+// of this user. An empty user name disables it.
+func (ctx *FeatureContext) ActAs(name string, groups ...string) {
+	if name == "" {
+		ctx.user = nil
+		return
+	}
+
+	if !ctx.rbacEnforced {
+		ctx.rbacEnforced = true
+		ctx.wrapServerClient()
+	}
+	ctx.user = newUserInfo(name, groups...)
+}
+
+// taggedUser returns the user given through the `@as:<user>` tag of the
+// scenario, if any.
+func taggedUser(sc *godog.Scenario) string {
+	if sc == nil {
+		return ""
+	}
+
+	for _, tag := range sc.Tags {
+		if strings.HasPrefix(tag.Name, actAsTagPrefix) {
+			return strings.TrimPrefix(tag.Name, actAsTagPrefix)
+		}
+	}
+	return ""
+}
+
+// IsAllowed returns true if the given user (and groups) is allowed to
+// perform the given verb on the given kind, inside the given namespace (or
+// cluster wide if the namespace is empty).
+func (ctx *FeatureContext) IsAllowed(
+	name string,
+	groups []string,
+	verb string,
+	groupVersionKind schema.GroupVersionKind,
+	namespace string,
+) (bool, error) {
+	mapping, err := ctx.restMapping(groupVersionKind)
+	if err != nil {
+		return false, err
+	}
+
+	attributes := rbacAttributes{
+		verb:      verb,
+		namespace: namespace,
+		apiGroup:  mapping.Resource.Group,
+		resource:  mapping.Resource.Resource,
+	}
+	return ctx.authorize(ctx.ctx, newUserInfo(name, groups...), attributes)
+}
+
+// authorize returns true if the given user is allowed to send the request
+// described by the given attributes. It uses a SubjectAccessReview on a
+// real cluster and evaluates the RBAC rules locally otherwise.
+func (ctx *FeatureContext) authorize(goctx context.Context, user *userInfo, attributes rbacAttributes) (bool, error) {
+	if ctx.impersonation != nil {
+		review := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user.name,
+				Groups: user.groups,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   attributes.namespace,
+					Verb:        attributes.verb,
+					Group:       attributes.apiGroup,
+					Resource:    attributes.resource,
+					Subresource: attributes.subresource,
+					Name:        attributes.name,
+				},
+			},
+		}
+		if err := ctx.serverClient().Create(goctx, review); err != nil {
+			return false, err
+		}
+		return review.Status.Allowed, nil
+	}
+
+	for _, group := range user.groups {
+		if group == mastersGroup {
+			return true, nil
+		}
+	}
+
+	rules, err := ctx.rbacRules(goctx, user, attributes.namespace)
+	if err != nil {
+		return false, err
+	}
+	for _, rule := range rules {
+		if ruleAllows(rule, attributes) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rbacRules returns all RBAC rules granted to the given user in the given
+// namespace (or only cluster wide if the namespace is empty).
+func (ctx *FeatureContext) rbacRules(goctx context.Context, user *userInfo, namespace string) ([]rbacv1.PolicyRule, error) {
+	c := ctx.serverClient()
+
+	var rules []rbacv1.PolicyRule
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := c.List(goctx, clusterRoleBindings); err != nil {
+		return nil, err
+	}
+	for _, binding := range clusterRoleBindings.Items {
+		if !bindingMatches(binding.Subjects, "", user) {
+			continue
+		}
+		roleRules, err := ctx.roleRules(goctx, binding.RoleRef, "")
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, roleRules...)
+	}
+
+	if namespace == "" {
+		return rules, nil
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := c.List(goctx, roleBindings, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for _, binding := range roleBindings.Items {
+		if !bindingMatches(binding.Subjects, binding.Namespace, user) {
+			continue
+		}
+		roleRules, err := ctx.roleRules(goctx, binding.RoleRef, binding.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, roleRules...)
+	}
+	return rules, nil
+}
+
+// roleRules returns the rules of the Role or the ClusterRole referenced by
+// a binding. Missing roles don't grant anything.
+func (ctx *FeatureContext) roleRules(goctx context.Context, ref rbacv1.RoleRef, namespace string) ([]rbacv1.PolicyRule, error) {
+	var (
+		rules []rbacv1.PolicyRule
+		err   error
+	)
+
+	switch ref.Kind {
+	case "ClusterRole":
+		role := &rbacv1.ClusterRole{}
+		err = ctx.serverClient().Get(goctx, client.ObjectKey{Name: ref.Name}, role)
+		rules = role.Rules
+	case "Role":
+		role := &rbacv1.Role{}
+		err = ctx.serverClient().Get(goctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, role)
+		rules = role.Rules
+	}
+
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return rules, err
+}
+
+// bindingMatches returns true if the given user is one of the binding
+// subjects.
+func bindingMatches(subjects []rbacv1.Subject, namespace string, user *userInfo) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if subject.Name == user.name {
+				return true
+			}
+		case rbacv1.GroupKind:
+			for _, group := range user.groups {
+				if subject.Name == group {
+					return true
+				}
+			}
+		case rbacv1.ServiceAccountKind:
+			saNamespace := subject.Namespace
+			if saNamespace == "" {
+				saNamespace = namespace
+			}
+			if serviceAccountUserName(saNamespace, subject.Name) == user.name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleAllows returns true if the given rule allows the request described by
+// the given attributes.
+func ruleAllows(rule rbacv1.PolicyRule, attributes rbacAttributes) bool {
+	if !matchesAny(rule.Verbs, attributes.verb) || !matchesAny(rule.APIGroups, attributes.apiGroup) {
+		return false
+	}
+	if len(rule.ResourceNames) > 0 && !matchesAny(rule.ResourceNames, attributes.name) {
+		return false
+	}
+
+	resource := attributes.resource
+	if attributes.subresource != "" {
+		resource += "/" + attributes.subresource
+	}
+	for _, ruleResource := range rule.Resources {
+		switch {
+		case ruleResource == rbacv1.ResourceAll, ruleResource == resource:
+			return true
+		case attributes.subresource != "" && ruleResource == "*/"+attributes.subresource:
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny returns true if the value or a wildcard is part of the list.
+func matchesAny(list []string, value string) bool {
+	for _, item := range list {
+		if item == value || item == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// forbidden returns the error sent by the API server when a user is not
+// allowed to send a request.
+func forbidden(user *userInfo, attributes rbacAttributes) error {
+	resource := attributes.resource
+	if attributes.subresource != "" {
+		resource += "/" + attributes.subresource
+	}
+
+	scope := "at the cluster scope"
+	if attributes.namespace != "" {
+		scope = fmt.Sprintf("in the namespace %q", attributes.namespace)
+	}
+
+	return errors.NewForbidden(
+		schema.GroupResource{Group: attributes.apiGroup, Resource: resource},
+		attributes.name,
+		fmt.Errorf("User %q cannot %s resource %q in API group %q %s", user.name, attributes.verb, resource, attributes.apiGroup, scope),
+	)
+}
+
+// impersonatedClient returns a client impersonating the given user on the
+// real cluster.
+func (ctx *FeatureContext) impersonatedClient(user *userInfo) (client.Client, error) {
+	key := user.name + "|" + strings.Join(user.groups, ",")
+	if c, exists := ctx.impersonatedClients[key]; exists {
+		return c, nil
+	}
+
+	rscheme, isRuntimeScheme := ctx.scheme.(*runtime.Scheme)
+	if !isRuntimeScheme {
+		return nil, fmt.Errorf("impersonation requires a *runtime.Scheme (current: %T)", ctx.scheme)
+	}
+
+	cfg := rest.CopyConfig(ctx.impersonation)
+	cfg.Impersonate = rest.ImpersonationConfig{UserName: user.name, Groups: user.groups}
+	c, err := client.New(cfg, client.Options{Scheme: rscheme, Mapper: ctx.mapper})
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.impersonatedClients == nil {
+		ctx.impersonatedClients = map[string]client.Client{}
+	}
+	ctx.impersonatedClients[key] = c
+	return c, nil
+}
+
+// attributesFor returns the attributes of a request on the given object.
+func (c *rbacClient) attributesFor(verb string, obj runtime.Object, namespace, name string) (rbacAttributes, error) {
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return rbacAttributes{}, err
+	}
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+
+	mapping, err := c.ctx.restMapping(gvk)
+	if err != nil {
+		return rbacAttributes{}, err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = ""
+	}
+
+	return rbacAttributes{
+		verb:      verb,
+		namespace: namespace,
+		apiGroup:  mapping.Resource.Group,
+		resource:  mapping.Resource.Resource,
+		name:      name,
+	}, nil
+}
+
+// do authorizes the request on the given object and calls the given function
+// with the request context. On a real cluster, the request is not authorized
+// locally but the context carries the user to impersonate.
+func (c *rbacClient) do(goctx context.Context, verb, subresource string, obj runtime.Object, namespace, name string, fnc func(context.Context) error) error {
+	user := c.ctx.user
+	if user == nil {
+		return fnc(goctx)
+	}
+
+	if c.ctx.impersonation != nil {
+		return fnc(context.WithValue(goctx, impersonatedUserKey{}, user))
+	}
+
+	attributes, err := c.attributesFor(verb, obj, namespace, name)
+	if err != nil {
+		return err
+	}
+	attributes.subresource = subresource
+
+	allowed, err := c.ctx.authorize(goctx, user, attributes)
+	switch {
+	case err != nil:
+		return err
+	case !allowed:
+		return forbidden(user, attributes)
+	}
+	return fnc(goctx)
+}
+
+func (c *rbacClient) Get(goctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return c.do(goctx, "get", "", obj, key.Namespace, key.Name, func(goctx context.Context) error {
+		return c.Client.Get(goctx, key, obj)
+	})
+}
+
+func (c *rbacClient) List(goctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	return c.do(goctx, "list", "", list, listOpts.Namespace, "", func(goctx context.Context) error {
+		return c.Client.List(goctx, list, opts...)
+	})
+}
+
+func (c *rbacClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: the name is not known during the authorization of a creation
+	return c.do(goctx, "create", "", obj, accessor.GetNamespace(), "", func(goctx context.Context) error {
+		return c.Client.Create(goctx, obj, opts...)
+	})
+}
+
+func (c *rbacClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return c.doOnObject(goctx, "update", "", obj, func(goctx context.Context) error {
+		return c.Client.Update(goctx, obj, opts...)
+	})
+}
+
+func (c *rbacClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.doOnObject(goctx, "patch", "", obj, func(goctx context.Context) error {
+		return c.Client.Patch(goctx, obj, patch, opts...)
+	})
+}
+
+func (c *rbacClient) Delete(goctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	return c.doOnObject(goctx, "delete", "", obj, func(goctx context.Context) error {
+		return c.Client.Delete(goctx, obj, opts...)
+	})
+}
+
+func (c *rbacClient) DeleteAllOf(goctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	deleteOpts := client.DeleteAllOfOptions{}
+	deleteOpts.ApplyOptions(opts)
+
+	return c.do(goctx, "deletecollection", "", obj, deleteOpts.Namespace, "", func(goctx context.Context) error {
+		return c.Client.DeleteAllOf(goctx, obj, opts...)
+	})
+}
+
+func (c *rbacClient) Status() client.StatusWriter {
+	return &rbacStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// doOnObject authorizes a request on an existing object.
+func (c *rbacClient) doOnObject(goctx context.Context, verb, subresource string, obj runtime.Object, fnc func(context.Context) error) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	return c.do(goctx, verb, subresource, obj, accessor.GetNamespace(), accessor.GetName(), fnc)
+}
+
+func (w *rbacStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return w.client.doOnObject(goctx, "update", "status", obj, func(goctx context.Context) error {
+		return w.StatusWriter.Update(goctx, obj, opts...)
+	})
+}
+
+func (w *rbacStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.client.doOnObject(goctx, "patch", "status", obj, func(goctx context.Context) error {
+		return w.StatusWriter.Patch(goctx, obj, patch, opts...)
+	})
+}
+
+// clientFor returns the client impersonating the user carried by the given
+// request context, if any.
+func (c *impersonatingClient) clientFor(goctx context.Context) (client.Client, error) {
+	user, _ := goctx.Value(impersonatedUserKey{}).(*userInfo)
+	if user == nil {
+		return c.Client, nil
+	}
+	return c.ctx.impersonatedClient(user)
+}
+
+func (c *impersonatingClient) Get(goctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	impersonated, err := c.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.Get(goctx, key, obj)
+}
+
+func (c *impersonatingClient) List(goctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	impersonated, err := c.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.List(goctx, list, opts...)
+}
+
+func (c *impersonatingClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	impersonated, err := c.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.Create(goctx, obj, opts...)
+}
+
+func (c *impersonatingClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	impersonated, err := c.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.Update(goctx, obj, opts...)
+}
+
+func (c *impersonatingClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	impersonated, err := c.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.Patch(goctx, obj, patch, opts...)
+}
+
+func (c *impersonatingClient) Delete(goctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	impersonated, err := c.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.Delete(goctx, obj, opts...)
+}
+
+func (c *impersonatingClient) DeleteAllOf(goctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	impersonated, err := c.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.DeleteAllOf(goctx, obj, opts...)
+}
+
+func (c *impersonatingClient) Status() client.StatusWriter {
+	return &impersonatingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+func (w *impersonatingStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	impersonated, err := w.client.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.Status().Update(goctx, obj, opts...)
+}
+
+func (w *impersonatingStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	impersonated, err := w.client.clientFor(goctx)
+	if err != nil {
+		return err
+	}
+	return impersonated.Status().Patch(goctx, obj, patch, opts...)
+}
@@ -0,0 +1,212 @@
+package kubernetes_ctx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+var (
+	podGVK                = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	roleGVK               = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}
+	roleBindingGVK        = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}
+	clusterRoleGVK        = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	clusterRoleBindingGVK = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}
+	podDefault            = types.NamespacedName{Namespace: "default", Name: "pod"}
+)
+
+// initFakeScenarioWithRBAC generates a godoc ScenarioContext and a
+// FeatureContext with a fake client containing a pod, a Role allowing to
+// read pods in the default namespace bound to the user 'alice' and a
+// ClusterRole allowing to manage pods bound to the group 'admins'.
+func initFakeScenarioWithRBAC(t *testing.T, opts ...kubernetes_ctx.FeatureContextOption) *kubernetes_ctx.FeatureContext {
+	ctx := initFakeScenario(t, opts...)
+
+	for gvk, obj := range map[schema.GroupVersionKind]string{
+		podGVK: `
+metadata: {name: pod, namespace: default}`,
+		roleGVK: `
+metadata: {name: pod-reader, namespace: default}
+rules: [{apiGroups: [""], resources: [pods], verbs: [get, list]}]`,
+		roleBindingGVK: `
+metadata: {name: pod-reader, namespace: default}
+roleRef: {apiGroup: rbac.authorization.k8s.io, kind: Role, name: pod-reader}
+subjects:
+  - {kind: User, name: alice}
+  - {kind: ServiceAccount, name: reader}`,
+		clusterRoleGVK: `
+metadata: {name: pod-admin}
+rules: [{apiGroups: [""], resources: [pods, pods/status], verbs: ["*"]}]`,
+		clusterRoleBindingGVK: `
+metadata: {name: pod-admin}
+roleRef: {apiGroup: rbac.authorization.k8s.io, kind: ClusterRole, name: pod-admin}
+subjects: [{kind: Group, name: admins}]`,
+	} {
+		uobj := yamlToUnstructured(t, obj)
+		err := ctx.Create(gvk, types.NamespacedName{Namespace: uobj.GetNamespace(), Name: uobj.GetName()}, uobj)
+		require.NoError(t, err)
+	}
+	return ctx
+}
+
+func TestFeatureContext_ActAs(t *testing.T) {
+	ctx := initFakeScenarioWithRBAC(t)
+	ctx.ActAs("alice")
+
+	_, err := ctx.Get(podGVK, podDefault)
+	assert.NoError(t, err)
+	_, err = ctx.List(podGVK, ctrlclient.InNamespace("default"))
+	assert.NoError(t, err)
+
+	_, err = ctx.List(podGVK)
+	assert.True(t, errors.IsForbidden(err))
+	assert.EqualError(t, err, `pods is forbidden: User "alice" cannot list resource "pods" in API group "" at the cluster scope`)
+
+	err = ctx.Create(podGVK, types.NamespacedName{Namespace: "default", Name: "new"}, &unstructured.Unstructured{})
+	assert.True(t, errors.IsForbidden(err))
+	assert.EqualError(t, err, `pods is forbidden: User "alice" cannot create resource "pods" in API group "" in the namespace "default"`)
+
+	_, err = ctx.Delete(podGVK, podDefault)
+	assert.True(t, errors.IsForbidden(err))
+	assert.EqualError(t, err, `pods "pod" is forbidden: User "alice" cannot delete resource "pods" in API group "" in the namespace "default"`)
+
+	ctx.ActAs("")
+	_, err = ctx.Delete(podGVK, podDefault)
+	assert.NoError(t, err)
+}
+
+func TestFeatureContext_ActAs_Groups(t *testing.T) {
+	ctx := initFakeScenarioWithRBAC(t)
+	ctx.ActAs("bob", "admins")
+
+	obj, err := ctx.Get(podGVK, podDefault)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(obj.Object, "Running", "status", "phase"))
+
+	pod, err := ctx.Get(podGVK, podDefault)
+	require.NoError(t, err)
+	pod.Object["status"] = obj.Object["status"]
+	assert.NoError(t, ctx.Client().Status().Update(ctx.GoContext(), pod))
+
+	ctx.ActAs("alice")
+	assert.True(t, errors.IsForbidden(ctx.Client().Status().Update(ctx.GoContext(), pod)))
+}
+
+func TestFeatureContext_ActAs_ServiceAccount(t *testing.T) {
+	ctx := initFakeScenarioWithRBAC(t)
+
+	ctx.ActAs("system:serviceaccount:default:reader")
+	_, err := ctx.Get(podGVK, podDefault)
+	assert.NoError(t, err)
+
+	ctx.ActAs("system:serviceaccount:kube-system:reader")
+	_, err = ctx.Get(podGVK, podDefault)
+	assert.True(t, errors.IsForbidden(err))
+}
+
+func TestFeatureContext_ActAs_Tag(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(scenarioContextMock, kubernetes_ctx.WithFakeRuntimeClient())
+	require.NoError(t, err)
+
+	scenarioContextMock.RunScenarioWith(&godog.Scenario{Tags: []*messages.Pickle_PickleTag{{Name: "@as:alice"}}})
+	_, err = ctx.Get(podGVK, podDefault)
+	assert.True(t, errors.IsNotFound(err), "the tagged user must be used only by the steps")
+
+	scenarioContextMock.RunStep()
+	_, err = ctx.Get(podGVK, podDefault)
+	assert.True(t, errors.IsForbidden(err))
+
+	scenarioContextMock.RunScenarioWith(&godog.Scenario{})
+	scenarioContextMock.RunStep()
+	_, err = ctx.Get(podGVK, podDefault)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestFeatureContext_IsAllowed(t *testing.T) {
+	ctx := initFakeScenarioWithRBAC(t)
+
+	tests := map[string]struct {
+		user      string
+		groups    []string
+		verb      string
+		namespace string
+		allowed   bool
+	}{
+		"alice get pods in default":       {user: "alice", verb: "get", namespace: "default", allowed: true},
+		"alice get pods in kube-system":   {user: "alice", verb: "get", namespace: "kube-system"},
+		"alice get pods cluster wide":     {user: "alice", verb: "get"},
+		"alice delete pods in default":    {user: "alice", verb: "delete", namespace: "default"},
+		"admins delete pods cluster wide": {user: "bob", groups: []string{"admins"}, verb: "delete", allowed: true},
+		"masters delete pods":             {user: "root", groups: []string{"system:masters"}, verb: "delete", allowed: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			allowed, err := ctx.IsAllowed(test.user, test.groups, test.verb, podGVK, test.namespace)
+			require.NoError(t, err)
+			assert.Equal(t, test.allowed, allowed)
+		})
+	}
+}
+
+func TestFeatureContext_ActAs_CallRecording(t *testing.T) {
+	ctx := initFakeScenarioWithRBAC(t, kubernetes_ctx.WithCallRecording())
+	ctx.ResetCalls()
+	ctx.ActAs("alice")
+
+	err := ctx.Create(podGVK, types.NamespacedName{Namespace: "default", Name: "new"}, &unstructured.Unstructured{})
+	assert.True(t, errors.IsForbidden(err))
+
+	calls, err := ctx.Calls()
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "create v1/Pod 'default/new'", calls[0].String())
+	assert.True(t, errors.IsForbidden(calls[0].Err))
+}
+
+func TestFeatureContext_ActAs_Impersonation(t *testing.T) {
+	var impersonated []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		impersonated = append(impersonated, r.Header.Get("Impersonate-User"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "pod", "namespace": "default"}}`))
+	}))
+	defer server.Close()
+
+	ctx := initFakeScenario(t,
+		kubernetes_ctx.WithImpersonation(&rest.Config{Host: server.URL}),
+		kubernetes_ctx.WithCallRecording(),
+	)
+
+	// requests are only sent to the impersonated client when acting as a user
+	_, err := ctx.Get(podGVK, podDefault)
+	assert.True(t, errors.IsNotFound(err))
+	assert.Empty(t, impersonated)
+
+	ctx.ActAs("alice")
+	pod := &unstructured.Unstructured{}
+	pod.SetGroupVersionKind(podGVK)
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), podDefault, pod))
+	assert.Equal(t, []string{"alice"}, impersonated)
+
+	calls, err := ctx.Calls()
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	assert.True(t, errors.IsNotFound(calls[0].Err))
+	assert.Equal(t, "get v1/Pod 'default/pod'", calls[1].String())
+	assert.NoError(t, calls[1].Err)
+}
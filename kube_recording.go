@@ -0,0 +1,224 @@
+package kubernetes_ctx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type (
+	// objectRecorder keeps the first version read of all objects, shared by
+	// all scenarios, and writes them inside a fixture file at the end of
+	// each scenario.
+	// NOTE: reconcilers can send requests concurrently
+	objectRecorder struct {
+		sync.Mutex
+		path    string
+		objects map[string]*unstructured.Unstructured
+		// updated is true when objects were recorded since the last write
+		updated bool
+		// created lists the objects created by the current scenario, which
+		// must not be recorded because they don't exist before it
+		created map[string]bool
+	}
+
+	// objectRecordingClient wraps a client.Client in order to record all
+	// objects read through it.
+	objectRecordingClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+)
+
+// newObjectRecorder returns a recorder writing all objects inside the
+// given file.
+func newObjectRecorder(path string) *objectRecorder {
+	return &objectRecorder{path: path, objects: map[string]*unstructured.Unstructured{}}
+}
+
+// objectKey returns the key identifying the given object.
+func objectKey(gvk schema.GroupVersionKind, namespace, name string) string {
+	return strings.Join([]string{gvk.GroupVersion().String(), gvk.Kind, namespace, name}, "/")
+}
+
+// reset forgets the objects created by the previous scenario.
+func (recorder *objectRecorder) reset() {
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.created = map[string]bool{}
+}
+
+// markCreated excludes the given object from the recording.
+func (recorder *objectRecorder) markCreated(gvk schema.GroupVersionKind, obj metav1.Object) {
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.created[objectKey(gvk, obj.GetNamespace(), obj.GetName())] = true
+}
+
+// record keeps the given objects if they are read for the first time.
+func (recorder *objectRecorder) record(objs ...*unstructured.Unstructured) {
+	recorder.Lock()
+	defer recorder.Unlock()
+
+	for _, obj := range objs {
+		key := objectKey(obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		if _, exists := recorder.objects[key]; exists || recorder.created[key] {
+			continue
+		}
+
+		obj = obj.DeepCopy()
+		// NOTE: server-side fields are removed in order to keep the
+		//       fixture deterministic and usable by a fake client
+		obj.SetResourceVersion("")
+		obj.SetSelfLink("")
+		obj.SetManagedFields(nil)
+		recorder.objects[key] = obj
+		recorder.updated = true
+	}
+}
+
+// flush writes the fixture file if any object was recorded since the last
+// write.
+func (recorder *objectRecorder) flush() error {
+	recorder.Lock()
+	defer recorder.Unlock()
+
+	if !recorder.updated {
+		return nil
+	}
+	if err := recorder.write(); err != nil {
+		return fmt.Errorf("unable to write the recorded objects: %w", err)
+	}
+	recorder.updated = false
+	return nil
+}
+
+// write writes all recorded objects inside the fixture file, as a
+// multi-document YAML sorted by kind, namespace and name.
+func (recorder *objectRecorder) write() error {
+	keys := make([]string, 0, len(recorder.objects))
+	for key := range recorder.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	for _, key := range keys {
+		if err := encoder.Encode(recorder.objects[key].Object); err != nil {
+			return err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	// NOTE: the objects are written in a temporary file, then renamed, in
+	//       order to never leave a truncated fixture
+	fd, err := ioutil.TempFile(filepath.Dir(recorder.path), filepath.Base(recorder.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fd.Name())
+
+	_, err = fd.Write(buf.Bytes())
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(fd.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(fd.Name(), recorder.path)
+}
+
+// toUnstructured converts the given object to an Unstructured object with
+// the given kind.
+func toUnstructured(gvk schema.GroupVersionKind, obj runtime.Object) (*unstructured.Unstructured, error) {
+	uobj := &unstructured.Unstructured{}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	uobj.Object = content
+	uobj.SetGroupVersionKind(gvk)
+	return uobj, nil
+}
+
+func (c *objectRecordingClient) Get(goctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if err := c.Client.Get(goctx, key, obj); err != nil {
+		return err
+	}
+
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return err
+	}
+	uobj, err := toUnstructured(gvk, obj)
+	if err != nil {
+		return err
+	}
+	c.ctx.objectRecorder.record(uobj)
+	return nil
+}
+
+func (c *objectRecordingClient) List(goctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := c.Client.List(goctx, list, opts...); err != nil {
+		return err
+	}
+
+	gvk, err := gvkForObject(c.ctx.scheme, list)
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	objs := make([]*unstructured.Unstructured, 0, len(items))
+	for _, item := range items {
+		uobj, err := toUnstructured(gvk, item)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, uobj)
+	}
+	c.ctx.objectRecorder.record(objs...)
+	return nil
+}
+
+func (c *objectRecordingClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(goctx, obj, opts...); err != nil {
+		return err
+	}
+
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	c.ctx.objectRecorder.markCreated(gvk, accessor)
+	return nil
+}
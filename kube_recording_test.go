@@ -0,0 +1,99 @@
+package kubernetes_ctx_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+	"github.com/xunleii/godog-kubernetes/helpers"
+)
+
+func TestWithClientRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fixture := filepath.Join(dir, "recording.yaml")
+	serviceGVK := namespaceGVK.GroupVersion().WithKind("Service")
+
+	// the "real" cluster, with some existing resources
+	cluster := fake.NewFakeClientWithScheme(featureScheme(),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", UID: "ns-uid", ResourceVersion: "42"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubernetes", UID: "svc-uid"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unread"}},
+	)
+
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioContextMock,
+		kubernetes_ctx.WithClient(featureScheme(), cluster),
+		kubernetes_ctx.WithClientRecording(fixture),
+	)
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	require.NoError(t, ctx.Create(serviceGVK, types.NamespacedName{Namespace: "default", Name: "created"}, &unstructured.Unstructured{}))
+	_, err = ctx.Get(serviceGVK, types.NamespacedName{Namespace: "default", Name: "kubernetes"})
+	require.NoError(t, err)
+	_, err = ctx.Get(serviceGVK, types.NamespacedName{Namespace: "default", Name: "created"})
+	require.NoError(t, err)
+	_, err = ctx.List(namespaceGVK)
+	require.NoError(t, err)
+
+	// the recorded objects are written once the scenario ends
+	_, err = os.Stat(fixture)
+	assert.True(t, os.IsNotExist(err))
+	scenarioContextMock.EndScenarioWith(nil, nil)
+
+	// only the existing resources read by the scenario are recorded
+	objs, err := readRecording(fixture)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1/Namespace '/default'", "v1/Service 'default/kubernetes'"}, objs)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary file must be left")
+
+	// the recording seeds the fake client of the following scenarios
+	scenarioContextMock = MockScenarioContext()
+	ctx, err = kubernetes_ctx.NewEmptyFeatureContext(scenarioContextMock, kubernetes_ctx.WithFakeClientFromRecording(fixture))
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	svc, err := ctx.Get(serviceGVK, types.NamespacedName{Namespace: "default", Name: "kubernetes"})
+	require.NoError(t, err)
+	assert.Equal(t, types.UID("svc-uid"), svc.GetUID())
+	_, err = ctx.Get(serviceGVK, types.NamespacedName{Namespace: "default", Name: "unread"})
+	assert.Error(t, err)
+	_, err = ctx.Get(namespaceGVK, namespaceDefault)
+	assert.NoError(t, err)
+}
+
+func TestWithFakeClientFromRecording_NotFound(t *testing.T) {
+	_, err := kubernetes_ctx.NewEmptyFeatureContext(MockScenarioContext(), kubernetes_ctx.WithFakeClientFromRecording("unknown.yaml"))
+	assert.Error(t, err)
+}
+
+// readRecording returns the description of all objects recorded in the
+// given file.
+func readRecording(path string) ([]string, error) {
+	recorded, err := helpers.ReadManifests(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []string
+	for _, obj := range recorded {
+		objs = append(objs, fmt.Sprintf("%s/%s '%s'", obj.GetAPIVersion(), obj.GetKind(), types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}))
+	}
+	return objs, nil
+}
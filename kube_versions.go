@@ -0,0 +1,68 @@
+package kubernetes_ctx
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// CaptureVersion keeps a copy of the current version of the given resource,
+// in order to compare it later or to write it while it is stale.
+func (ctx *FeatureContext) CaptureVersion(
+	groupVersionKind schema.GroupVersionKind,
+	namespacedName types.NamespacedName,
+) error {
+	obj, err := ctx.get(groupVersionKind, namespacedName)
+	if err != nil {
+		return err
+	}
+
+	if ctx.capturedVersions == nil {
+		ctx.capturedVersions = map[string]*unstructured.Unstructured{}
+	}
+	ctx.capturedVersions[capturedVersionKey(groupVersionKind, namespacedName)] = obj
+	return nil
+}
+
+// CapturedVersion returns a copy of the version of the given resource kept
+// by CaptureVersion.
+func (ctx *FeatureContext) CapturedVersion(
+	groupVersionKind schema.GroupVersionKind,
+	namespacedName types.NamespacedName,
+) (*unstructured.Unstructured, error) {
+	obj, exists := ctx.capturedVersions[capturedVersionKey(groupVersionKind, namespacedName)]
+	if !exists {
+		return nil, fmt.Errorf("no version of %s/%s '%s' has been captured", groupVersionKind.GroupVersion(), groupVersionKind.Kind, namespacedName)
+	}
+	return obj.DeepCopy(), nil
+}
+
+// UpdateWithRetry fetches the given resource, mutates it with the given
+// function and updates it, again and again while the update is rejected
+// due to a conflict, like controllers do with retry.RetryOnConflict.
+func (ctx *FeatureContext) UpdateWithRetry(
+	groupVersionKind schema.GroupVersionKind,
+	namespacedName types.NamespacedName,
+	mutate func(obj *unstructured.Unstructured) error,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := ctx.get(groupVersionKind, namespacedName)
+		if err != nil {
+			return err
+		}
+
+		if err := mutate(obj); err != nil {
+			return err
+		}
+		return ctx.Update(groupVersionKind, namespacedName, obj)
+	})
+}
+
+// capturedVersionKey returns the key of the captured version of the given
+// resource.
+func capturedVersionKey(groupVersionKind schema.GroupVersionKind, namespacedName types.NamespacedName) string {
+	return groupVersionKind.String() + "/" + namespacedName.String()
+}
@@ -0,0 +1,53 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestFeatureContext_CaptureVersion(t *testing.T) {
+	ctx := initFakeScenarioWithNamespaces(t)
+
+	_, err := ctx.CapturedVersion(namespaceGVK, namespaceDefault)
+	assert.EqualError(t, err, "no version of v1/Namespace '/default' has been captured")
+
+	require.NoError(t, ctx.CaptureVersion(namespaceGVK, namespaceDefault))
+	require.NoError(t, ctx.Patch(namespaceGVK, namespaceDefault, types.MergePatchType, []byte(`{"metadata":{"labels":{"key":"value"}}}`)))
+
+	captured, err := ctx.CapturedVersion(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	current, err := ctx.Get(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	assert.Empty(t, captured.GetLabels())
+	assert.NotEqual(t, captured.GetResourceVersion(), current.GetResourceVersion())
+
+	// the captured version is stale
+	err = ctx.Update(namespaceGVK, namespaceDefault, captured)
+	assert.EqualError(t, err, "Operation cannot be fulfilled on namespaces \"default\": object was modified")
+}
+
+func TestFeatureContext_UpdateWithRetry(t *testing.T) {
+	ctx := initFakeScenarioWithNamespaces(t)
+
+	attempts := 0
+	err := ctx.UpdateWithRetry(namespaceGVK, namespaceDefault, func(obj *unstructured.Unstructured) error {
+		attempts++
+		if attempts == 1 {
+			// NOTE: the resource is modified concurrently, making the fetched object stale
+			require.NoError(t, ctx.Patch(namespaceGVK, namespaceDefault, types.MergePatchType, []byte(`{"metadata":{"labels":{"concurrent":"true"}}}`)))
+		}
+		obj.SetAnnotations(map[string]string{"attempts": "done"})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	obj, err := ctx.Get(namespaceGVK, namespaceDefault)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"concurrent": "true"}, obj.GetLabels())
+	assert.Equal(t, map[string]string{"attempts": "done"}, obj.GetAnnotations())
+}
@@ -0,0 +1,346 @@
+package kubernetes_ctx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type (
+	// watchHub dispatches the changes written through the fake client to
+	// the emulated watches.
+	// NOTE: reconcilers can send requests concurrently
+	watchHub struct {
+		sync.Mutex
+		watches []*emulatedWatch
+	}
+
+	// emulatedWatch implements watch.Interface for the changes written
+	// through the fake client. Events are queued without limit, in order
+	// to never block the writers.
+	emulatedWatch struct {
+		sync.Mutex
+		hub     *watchHub
+		gvk     schema.GroupVersionKind
+		opts    client.ListOptions
+		pending []watch.Event
+		wakeup  *sync.Cond
+		stopped bool
+		done    chan struct{}
+		result  chan watch.Event
+	}
+
+	// watchRecorder keeps all events received by a watch opened by the
+	// steps, until the end of the scenario.
+	watchRecorder struct {
+		sync.Mutex
+		gvk       schema.GroupVersionKind
+		namespace string
+		watch     watch.Interface
+		events    []watch.Event
+	}
+
+	// watchClient wraps the fake client in order to dispatch all changes
+	// to the emulated watches.
+	watchClient struct {
+		client.Client
+		ctx *FeatureContext
+	}
+
+	// watchStatusWriter wraps a client.StatusWriter in order to dispatch
+	// all changes on the status subresource to the emulated watches.
+	watchStatusWriter struct {
+		client.StatusWriter
+		client *watchClient
+	}
+)
+
+// Watch opens a watch on the resources of the given kind, filtered by the
+// given options (only the namespace and the label selector are supported
+// by the fake client). Only the changes made after its opening are sent.
+// Watches are emulated on the fake client and sent to the API server
+// configured with WithWatchConfig otherwise. The watch must be stopped by
+// the caller.
+func (ctx *FeatureContext) Watch(groupVersionKind schema.GroupVersionKind, opts ...client.ListOption) (watch.Interface, error) {
+	if ctx.watches == nil && ctx.watchConfig == nil {
+		return nil, fmt.Errorf("watches are only supported by the fake client or with a watch configuration (see WithWatchConfig)")
+	}
+
+	mapping, err := ctx.restMapping(groupVersionKind)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	if ctx.watches != nil {
+		return ctx.watches.watch(groupVersionKind, listOpts), nil
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(ctx.watchConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceable := dynamicClient.Resource(mapping.Resource)
+	var resource dynamic.ResourceInterface = namespaceable
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && listOpts.Namespace != "" {
+		resource = namespaceable.Namespace(listOpts.Namespace)
+	}
+
+	watchOpts := listOpts.AsListOptions()
+	if watchOpts.ResourceVersion == "" {
+		// NOTE: without resourceVersion, the API server sends the
+		//       existing resources as ADDED events
+		list, err := resource.List(ctx.ctx, *watchOpts)
+		if err != nil {
+			return nil, err
+		}
+		watchOpts.ResourceVersion = list.GetResourceVersion()
+	}
+	return resource.Watch(ctx.ctx, *watchOpts)
+}
+
+// openWatch opens a watch recording all its events until the end of the
+// scenario.
+func (ctx *FeatureContext) openWatch(groupVersionKind schema.GroupVersionKind, namespace string) error {
+	w, err := ctx.Watch(groupVersionKind, client.InNamespace(namespace))
+	if err != nil {
+		return err
+	}
+
+	recorder := &watchRecorder{gvk: groupVersionKind, namespace: namespace, watch: w}
+	go func() {
+		for event := range w.ResultChan() {
+			recorder.Lock()
+			recorder.events = append(recorder.events, event)
+			recorder.Unlock()
+		}
+	}()
+	ctx.openedWatches = append(ctx.openedWatches, recorder)
+	return nil
+}
+
+// openedWatch returns the last watch opened by the steps on the given kind
+// and namespace, or the last one if the kind is empty.
+func (ctx *FeatureContext) openedWatch(groupVersionKind schema.GroupVersionKind, namespace string) (*watchRecorder, error) {
+	for i := len(ctx.openedWatches) - 1; i >= 0; i-- {
+		recorder := ctx.openedWatches[i]
+		if groupVersionKind.Empty() || (recorder.gvk == groupVersionKind && recorder.namespace == namespace) {
+			return recorder, nil
+		}
+	}
+	if groupVersionKind.Empty() {
+		return nil, fmt.Errorf("no watch has been opened")
+	}
+	return nil, fmt.Errorf("no watch has been opened on %s/%s", groupVersionKind.GroupVersion(), groupVersionKind.Kind)
+}
+
+// stopWatches stops all watches opened by the steps.
+func (ctx *FeatureContext) stopWatches() {
+	for _, recorder := range ctx.openedWatches {
+		recorder.watch.Stop()
+	}
+	ctx.openedWatches = nil
+}
+
+// recorded returns all events received until now.
+func (recorder *watchRecorder) recorded() []watch.Event {
+	recorder.Lock()
+	defer recorder.Unlock()
+	return append([]watch.Event(nil), recorder.events...)
+}
+
+// watch opens a new emulated watch.
+func (hub *watchHub) watch(gvk schema.GroupVersionKind, opts client.ListOptions) *emulatedWatch {
+	w := &emulatedWatch{
+		hub:    hub,
+		gvk:    gvk,
+		opts:   opts,
+		done:   make(chan struct{}),
+		result: make(chan watch.Event),
+	}
+	w.wakeup = sync.NewCond(w)
+	go w.run()
+
+	hub.Lock()
+	defer hub.Unlock()
+	hub.watches = append(hub.watches, w)
+	return w
+}
+
+// dispatch sends the given change to all matching watches.
+func (hub *watchHub) dispatch(eventType watch.EventType, obj *unstructured.Unstructured) {
+	hub.Lock()
+	defer hub.Unlock()
+
+	for _, w := range hub.watches {
+		if w.matches(obj) {
+			w.send(watch.Event{Type: eventType, Object: obj.DeepCopy()})
+		}
+	}
+}
+
+// matches returns true if the given object is watched.
+func (w *emulatedWatch) matches(obj *unstructured.Unstructured) bool {
+	return obj.GroupVersionKind() == w.gvk &&
+		(w.opts.Namespace == "" || w.opts.Namespace == obj.GetNamespace()) &&
+		(w.opts.LabelSelector == nil || w.opts.LabelSelector.Matches(labels.Set(obj.GetLabels())))
+}
+
+// send queues the given event.
+func (w *emulatedWatch) send(event watch.Event) {
+	w.Lock()
+	defer w.Unlock()
+
+	if !w.stopped {
+		w.pending = append(w.pending, event)
+		w.wakeup.Signal()
+	}
+}
+
+// run sends the queued events until the watch is stopped.
+func (w *emulatedWatch) run() {
+	defer close(w.result)
+
+	for {
+		w.Lock()
+		for len(w.pending) == 0 && !w.stopped {
+			w.wakeup.Wait()
+		}
+		if w.stopped {
+			w.Unlock()
+			return
+		}
+		event := w.pending[0]
+		w.pending = w.pending[1:]
+		w.Unlock()
+
+		select {
+		case w.result <- event:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *emulatedWatch) Stop() {
+	w.hub.Lock()
+	for i, other := range w.hub.watches {
+		if other == w {
+			w.hub.watches = append(w.hub.watches[:i:i], w.hub.watches[i+1:]...)
+			break
+		}
+	}
+	w.hub.Unlock()
+
+	w.Lock()
+	defer w.Unlock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.done)
+		w.wakeup.Broadcast()
+	}
+}
+
+func (w *emulatedWatch) ResultChan() <-chan watch.Event { return w.result }
+
+// dispatch sends the given change to the emulated watches.
+func (c *watchClient) dispatch(eventType watch.EventType, obj runtime.Object) error {
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return err
+	}
+
+	uobj := &unstructured.Unstructured{}
+	uobj.Object, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	uobj.SetGroupVersionKind(gvk)
+
+	c.ctx.watches.dispatch(eventType, uobj)
+	return nil
+}
+
+func (c *watchClient) Create(goctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.dispatch(watch.Added, obj)
+}
+
+func (c *watchClient) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.Client.Update(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.dispatch(watch.Modified, obj)
+}
+
+func (c *watchClient) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(goctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	return c.dispatch(watch.Modified, obj)
+}
+
+func (c *watchClient) Delete(goctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.dispatch(watch.Deleted, obj)
+}
+
+func (c *watchClient) DeleteAllOf(goctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	gvk, err := gvkForObject(c.ctx.scheme, obj)
+	if err != nil {
+		return err
+	}
+	deleteOpts := client.DeleteAllOfOptions{}
+	deleteOpts.ApplyOptions(opts)
+
+	// NOTE: removed resources are listed before, in order to send them
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := c.Client.List(goctx, list, &deleteOpts.ListOptions); err != nil {
+		return err
+	}
+
+	if err := c.Client.DeleteAllOf(goctx, obj, opts...); err != nil {
+		return err
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		item.SetGroupVersionKind(gvk)
+		c.ctx.watches.dispatch(watch.Deleted, item)
+	}
+	return nil
+}
+
+func (c *watchClient) Status() client.StatusWriter {
+	return &watchStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+func (w *watchStatusWriter) Update(goctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.StatusWriter.Update(goctx, obj, opts...); err != nil {
+		return err
+	}
+	return w.client.dispatch(watch.Modified, obj)
+}
+
+func (w *watchStatusWriter) Patch(goctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.StatusWriter.Patch(goctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	return w.client.dispatch(watch.Modified, obj)
+}
@@ -0,0 +1,79 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+// nextEvent returns the next event sent by the given watch.
+func nextEvent(t *testing.T, w watch.Interface) watch.Event {
+	select {
+	case event, open := <-w.ResultChan():
+		require.True(t, open, "watch closed")
+		return event
+	case <-time.After(time.Second):
+		require.FailNow(t, "no event received")
+	}
+	return watch.Event{}
+}
+
+func TestFeatureContext_Watch(t *testing.T) {
+	ctx := initFakeScenarioWithNamespaces(t)
+	serviceGVK := namespaceGVK.GroupVersion().WithKind("Service")
+
+	w, err := ctx.Watch(serviceGVK, ctrlclient.InNamespace("default"), ctrlclient.MatchingLabels{"app": "nginx"})
+	require.NoError(t, err)
+	defer w.Stop()
+
+	// ignored by the namespace or the label selector
+	require.NoError(t, ctx.Create(serviceGVK, types.NamespacedName{Namespace: "kube-system", Name: "nginx"}, yamlToUnstructured(t, `metadata: {labels: {app: nginx}}`)))
+	require.NoError(t, ctx.Create(serviceGVK, types.NamespacedName{Namespace: "default", Name: "other"}, &unstructured.Unstructured{}))
+
+	nginx := types.NamespacedName{Namespace: "default", Name: "nginx"}
+	require.NoError(t, ctx.Create(serviceGVK, nginx, yamlToUnstructured(t, `metadata: {labels: {app: nginx}}`)))
+	require.NoError(t, ctx.Patch(serviceGVK, nginx, types.MergePatchType, []byte(`{"spec":{"type":"NodePort"}}`)))
+	_, err = ctx.Delete(serviceGVK, nginx)
+	require.NoError(t, err)
+
+	for _, eventType := range []watch.EventType{watch.Added, watch.Modified, watch.Deleted} {
+		event := nextEvent(t, w)
+		assert.Equal(t, eventType, event.Type)
+
+		obj, isUnstructured := event.Object.(*unstructured.Unstructured)
+		require.True(t, isUnstructured)
+		assert.Equal(t, serviceGVK, obj.GroupVersionKind())
+		assert.Equal(t, "nginx", obj.GetName())
+	}
+}
+
+func TestFeatureContext_Watch_Stop(t *testing.T) {
+	ctx := initFakeScenarioWithNamespaces(t)
+
+	w, err := ctx.Watch(namespaceGVK)
+	require.NoError(t, err)
+	w.Stop()
+	w.Stop()
+
+	require.NoError(t, ctx.Create(namespaceGVK, types.NamespacedName{Name: "stopped"}, &unstructured.Unstructured{}))
+	_, open := <-w.ResultChan()
+	assert.False(t, open)
+}
+
+func TestFeatureContext_Watch_Unsupported(t *testing.T) {
+	scenarioContextMock := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(scenarioContextMock, kubernetes_ctx.WithClient(scheme, client))
+	require.NoError(t, err)
+	scenarioContextMock.RunScenario()
+
+	_, err = ctx.Watch(namespaceGVK)
+	assert.EqualError(t, err, "watches are only supported by the fake client or with a watch configuration (see WithWatchConfig)")
+}
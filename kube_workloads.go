@@ -0,0 +1,641 @@
+package kubernetes_ctx
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	deploymentGroupVersionKind  = appsv1.SchemeGroupVersion.WithKind("Deployment")
+	replicaSetGroupVersionKind  = appsv1.SchemeGroupVersion.WithKind("ReplicaSet")
+	statefulSetGroupVersionKind = appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+	jobGroupVersionKind         = batchv1.SchemeGroupVersion.WithKind("Job")
+)
+
+type (
+	// deploymentController emulates the Kubernetes Deployment controller:
+	// each pod template is managed by a ReplicaSet, old ReplicaSets are
+	// scaled down immediately.
+	deploymentController struct{}
+	// replicaSetController emulates the Kubernetes ReplicaSet controller.
+	replicaSetController struct{}
+	// statefulSetController emulates the Kubernetes StatefulSet controller;
+	// pods are created and removed in order, without waiting for them.
+	statefulSetController struct{}
+	// jobController emulates the Kubernetes Job controller.
+	jobController struct{}
+)
+
+// workloadControllers returns all emulated workload controllers.
+func workloadControllers() []emulator {
+	return []emulator{&deploymentController{}, &replicaSetController{}, &statefulSetController{}, &jobController{}}
+}
+
+// Handles returns true for Deployments and ReplicaSets.
+func (deploymentController) Handles(gvk schema.GroupVersionKind) bool {
+	return gvk == deploymentGroupVersionKind || gvk == replicaSetGroupVersionKind
+}
+
+// Emulate reconciles the Deployment written or owning the written
+// ReplicaSet.
+func (c deploymentController) Emulate(ctx *FeatureContext, event emulationEvent) error {
+	if event.gvk == deploymentGroupVersionKind {
+		if event.operation == emulationDelete {
+			return nil
+		}
+		return c.reconcile(ctx, event.key)
+	}
+
+	return reconcileOwners(ctx, event, deploymentGroupVersionKind, &appsv1.ReplicaSet{}, &appsv1.DeploymentList{}, c.reconcile)
+}
+
+func (deploymentController) reconcile(ctx *FeatureContext, key types.NamespacedName) error {
+	deployment := &appsv1.Deployment{}
+	if err := getIfExists(ctx, key, deployment); err != nil || deployment.Name == "" {
+		return err
+	}
+
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := ctx.serverClient().List(ctx.ctx, replicaSets, client.InNamespace(key.Namespace)); err != nil {
+		return err
+	}
+
+	hash := podTemplateHash(deployment.Spec.Template, deployment.Status.CollisionCount)
+	replicas := int32OrDefault(deployment.Spec.Replicas, 1)
+
+	var (
+		newRS    *appsv1.ReplicaSet
+		owned    []*appsv1.ReplicaSet
+		revision int64
+	)
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		owned = append(owned, rs)
+
+		if rsRevision, _ := strconv.ParseInt(rs.Annotations[deploymentRevisionAnnotation], 10, 64); rsRevision > revision {
+			revision = rsRevision
+		}
+		if rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == hash {
+			newRS = rs
+		}
+	}
+
+	if newRS == nil {
+		newRS = &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              deployment.Name + "-" + hash,
+				Namespace:         deployment.Namespace,
+				UID:               types.UID(uuid.New().String()),
+				CreationTimestamp: ctx.now(),
+				Labels:            withLabel(deployment.Spec.Template.Labels, appsv1.DefaultDeploymentUniqueLabelKey, hash),
+				Annotations:       map[string]string{deploymentRevisionAnnotation: strconv.FormatInt(revision+1, 10)},
+				OwnerReferences:   []metav1.OwnerReference{*metav1.NewControllerRef(deployment, deploymentGroupVersionKind)},
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Replicas:        &replicas,
+				MinReadySeconds: deployment.Spec.MinReadySeconds,
+				Selector:        withSelectorLabel(deployment.Spec.Selector, appsv1.DefaultDeploymentUniqueLabelKey, hash),
+				Template:        *deployment.Spec.Template.DeepCopy(),
+			},
+		}
+		newRS.Spec.Template.Labels = withLabel(newRS.Spec.Template.Labels, appsv1.DefaultDeploymentUniqueLabelKey, hash)
+
+		if err := ctx.serverClient().Create(ctx.ctx, newRS); err != nil {
+			return err
+		}
+		owned = append(owned, newRS)
+	}
+
+	for _, rs := range owned {
+		expected := int32(0)
+		if rs == newRS {
+			expected = replicas
+		}
+		if int32OrDefault(rs.Spec.Replicas, 1) == expected {
+			continue
+		}
+
+		rs.Spec.Replicas = &expected
+		if err := ctx.serverClient().Update(ctx.ctx, rs); err != nil {
+			return err
+		}
+	}
+
+	status := appsv1.DeploymentStatus{
+		ObservedGeneration: deployment.Generation,
+		CollisionCount:     deployment.Status.CollisionCount,
+		UpdatedReplicas:    newRS.Status.Replicas,
+	}
+	for _, rs := range owned {
+		status.Replicas += rs.Status.Replicas
+		status.ReadyReplicas += rs.Status.ReadyReplicas
+		status.AvailableReplicas += rs.Status.AvailableReplicas
+	}
+	if status.Replicas > status.AvailableReplicas {
+		status.UnavailableReplicas = status.Replicas - status.AvailableReplicas
+	}
+
+	available := status.AvailableReplicas >= replicas
+	progressed := available && status.UpdatedReplicas == replicas && status.Replicas == replicas
+	status.Conditions = deployment.Status.Conditions
+	status.Conditions = setDeploymentCondition(status.Conditions, ctx.now(), appsv1.DeploymentAvailable, available, "MinimumReplicasAvailable", "MinimumReplicasUnavailable")
+	status.Conditions = setDeploymentCondition(status.Conditions, ctx.now(), appsv1.DeploymentProgressing, true, map[bool]string{true: "NewReplicaSetAvailable", false: "ReplicaSetUpdated"}[progressed], "")
+
+	if equality.Semantic.DeepEqual(deployment.Status, status) {
+		return nil
+	}
+	deployment.Status = status
+	return ctx.serverClient().Status().Update(ctx.ctx, deployment)
+}
+
+// Handles returns true for ReplicaSets and Pods.
+func (replicaSetController) Handles(gvk schema.GroupVersionKind) bool {
+	return gvk == replicaSetGroupVersionKind || gvk == podGroupVersionKind
+}
+
+// Emulate reconciles the ReplicaSet written or owning the written Pod.
+func (c replicaSetController) Emulate(ctx *FeatureContext, event emulationEvent) error {
+	if event.gvk == replicaSetGroupVersionKind {
+		if event.operation == emulationDelete {
+			return nil
+		}
+		return c.reconcile(ctx, event.key)
+	}
+
+	return reconcileOwners(ctx, event, replicaSetGroupVersionKind, &corev1.Pod{}, &appsv1.ReplicaSetList{}, c.reconcile)
+}
+
+func (replicaSetController) reconcile(ctx *FeatureContext, key types.NamespacedName) error {
+	rs := &appsv1.ReplicaSet{}
+	if err := getIfExists(ctx, key, rs); err != nil || rs.Name == "" {
+		return err
+	}
+
+	pods, err := ownedPods(ctx, rs)
+	if err != nil {
+		return err
+	}
+
+	var active []*corev1.Pod
+	for _, pod := range pods {
+		if isPodActive(pod) {
+			active = append(active, pod)
+		}
+	}
+
+	replicas := int(int32OrDefault(rs.Spec.Replicas, 1))
+	for len(active) < replicas {
+		pod := podFromTemplate(ctx, rs.Spec.Template, rs, replicaSetGroupVersionKind, rs.Name+"-"+utilrand.String(5))
+		if err := ctx.serverClient().Create(ctx.ctx, pod); err != nil {
+			return err
+		}
+		active = append(active, pod)
+	}
+
+	// NOTE: pods not ready are removed first
+	sort.SliceStable(active, func(i, j int) bool { return isPodReady(active[i]) && !isPodReady(active[j]) })
+	for len(active) > replicas {
+		if err := ctx.serverClient().Delete(ctx.ctx, active[len(active)-1]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		active = active[:len(active)-1]
+	}
+
+	// NOTE: created pods can be updated by the kubelet
+	if pods, err = ownedPods(ctx, rs); err != nil {
+		return err
+	}
+
+	status := appsv1.ReplicaSetStatus{ObservedGeneration: rs.Generation, Conditions: rs.Status.Conditions}
+	for _, pod := range pods {
+		if !isPodActive(pod) {
+			continue
+		}
+		status.Replicas++
+		status.FullyLabeledReplicas++
+		if isPodReady(pod) {
+			status.ReadyReplicas++
+			status.AvailableReplicas++
+		}
+	}
+
+	if equality.Semantic.DeepEqual(rs.Status, status) {
+		return nil
+	}
+	rs.Status = status
+	return ctx.serverClient().Status().Update(ctx.ctx, rs)
+}
+
+// Handles returns true for StatefulSets and Pods.
+func (statefulSetController) Handles(gvk schema.GroupVersionKind) bool {
+	return gvk == statefulSetGroupVersionKind || gvk == podGroupVersionKind
+}
+
+// Emulate reconciles the StatefulSet written or owning the written Pod.
+func (c statefulSetController) Emulate(ctx *FeatureContext, event emulationEvent) error {
+	if event.gvk == statefulSetGroupVersionKind {
+		if event.operation == emulationDelete {
+			return nil
+		}
+		return c.reconcile(ctx, event.key)
+	}
+
+	return reconcileOwners(ctx, event, statefulSetGroupVersionKind, &corev1.Pod{}, &appsv1.StatefulSetList{}, c.reconcile)
+}
+
+func (statefulSetController) reconcile(ctx *FeatureContext, key types.NamespacedName) error {
+	sts := &appsv1.StatefulSet{}
+	if err := getIfExists(ctx, key, sts); err != nil || sts.Name == "" {
+		return err
+	}
+
+	pods, err := ownedPods(ctx, sts)
+	if err != nil {
+		return err
+	}
+	byName := map[string]*corev1.Pod{}
+	for _, pod := range pods {
+		byName[pod.Name] = pod
+	}
+
+	replicas := int(int32OrDefault(sts.Spec.Replicas, 1))
+	for ordinal := 0; ordinal < replicas; ordinal++ {
+		name := fmt.Sprintf("%s-%d", sts.Name, ordinal)
+		if _, exists := byName[name]; exists {
+			continue
+		}
+
+		pod := podFromTemplate(ctx, sts.Spec.Template, sts, statefulSetGroupVersionKind, name)
+		pod.Labels = withLabel(pod.Labels, appsv1.StatefulSetPodNameLabel, name)
+		pod.Spec.Hostname = name
+		pod.Spec.Subdomain = sts.Spec.ServiceName
+		if err := ctx.serverClient().Create(ctx.ctx, pod); err != nil {
+			return err
+		}
+		byName[name] = pod
+	}
+
+	// NOTE: pods are removed from the highest ordinal
+	for ordinal := len(byName) - 1; ordinal >= replicas; ordinal-- {
+		pod, exists := byName[fmt.Sprintf("%s-%d", sts.Name, ordinal)]
+		if !exists {
+			continue
+		}
+		if err := ctx.serverClient().Delete(ctx.ctx, pod); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if pods, err = ownedPods(ctx, sts); err != nil {
+		return err
+	}
+
+	status := appsv1.StatefulSetStatus{
+		ObservedGeneration: sts.Generation,
+		CollisionCount:     sts.Status.CollisionCount,
+		Conditions:         sts.Status.Conditions,
+	}
+	for _, pod := range pods {
+		if !isPodActive(pod) {
+			continue
+		}
+		status.Replicas++
+		status.CurrentReplicas++
+		status.UpdatedReplicas++
+		if isPodReady(pod) {
+			status.ReadyReplicas++
+		}
+	}
+
+	if equality.Semantic.DeepEqual(sts.Status, status) {
+		return nil
+	}
+	sts.Status = status
+	return ctx.serverClient().Status().Update(ctx.ctx, sts)
+}
+
+// Handles returns true for Jobs and Pods.
+func (jobController) Handles(gvk schema.GroupVersionKind) bool {
+	return gvk == jobGroupVersionKind || gvk == podGroupVersionKind
+}
+
+// Emulate reconciles the Job written or owning the written Pod.
+func (c jobController) Emulate(ctx *FeatureContext, event emulationEvent) error {
+	if event.gvk == jobGroupVersionKind {
+		if event.operation == emulationDelete {
+			return nil
+		}
+		return c.reconcile(ctx, event.key)
+	}
+
+	return reconcileOwners(ctx, event, jobGroupVersionKind, &corev1.Pod{}, &batchv1.JobList{}, c.reconcile)
+}
+
+func (jobController) reconcile(ctx *FeatureContext, key types.NamespacedName) error {
+	job := &batchv1.Job{}
+	if err := getIfExists(ctx, key, job); err != nil || job.Name == "" {
+		return err
+	}
+	if isJobFinished(job) {
+		return nil
+	}
+
+	pods, err := ownedPods(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	status := *job.Status.DeepCopy()
+	status.Active, status.Succeeded, status.Failed = 0, 0, 0
+	for _, pod := range pods {
+		switch {
+		case pod.Status.Phase == corev1.PodSucceeded:
+			status.Succeeded++
+		case pod.Status.Phase == corev1.PodFailed:
+			status.Failed++
+		case pod.DeletionTimestamp == nil:
+			status.Active++
+		}
+	}
+
+	now := ctx.now()
+	if status.StartTime == nil {
+		status.StartTime = &now
+	}
+
+	parallelism := int32OrDefault(job.Spec.Parallelism, 1)
+	backoffLimit := int32OrDefault(job.Spec.BackoffLimit, 6)
+
+	wantActive := parallelism
+	switch {
+	case job.Spec.Completions != nil && *job.Spec.Completions-status.Succeeded < wantActive:
+		wantActive = *job.Spec.Completions - status.Succeeded
+	case job.Spec.Completions == nil && status.Succeeded > 0:
+		wantActive = 0
+	}
+
+	switch {
+	case status.Failed > backoffLimit:
+		for _, pod := range pods {
+			if isPodActive(pod) {
+				if err := ctx.serverClient().Delete(ctx.ctx, pod); err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+			}
+		}
+		status.Active = 0
+		status.Conditions = append(status.Conditions, batchv1.JobCondition{
+			Type:               batchv1.JobFailed,
+			Status:             corev1.ConditionTrue,
+			LastProbeTime:      now,
+			LastTransitionTime: now,
+			Reason:             "BackoffLimitExceeded",
+			Message:            "Job has reached the specified backoff limit",
+		})
+
+	case wantActive <= 0 && status.Active == 0:
+		status.CompletionTime = &now
+		status.Conditions = append(status.Conditions, batchv1.JobCondition{
+			Type:               batchv1.JobComplete,
+			Status:             corev1.ConditionTrue,
+			LastProbeTime:      now,
+			LastTransitionTime: now,
+		})
+
+	default:
+		for ; status.Active < wantActive; status.Active++ {
+			pod := podFromTemplate(ctx, job.Spec.Template, job, jobGroupVersionKind, job.Name+"-"+utilrand.String(5))
+			pod.Labels = withLabel(pod.Labels, "controller-uid", string(job.UID))
+			pod.Labels = withLabel(pod.Labels, "job-name", job.Name)
+			if err := ctx.serverClient().Create(ctx.ctx, pod); err != nil {
+				return err
+			}
+		}
+	}
+
+	if equality.Semantic.DeepEqual(job.Status, status) {
+		return nil
+	}
+	job.Status = status
+	return ctx.serverClient().Status().Update(ctx.ctx, job)
+}
+
+// deploymentRevisionAnnotation is the annotation containing the revision of
+// a ReplicaSet managed by a Deployment.
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// reconcileOwners reconciles the owner of the written object (of the given
+// kind). If the object has been removed, all owners of its namespace are
+// reconciled.
+func reconcileOwners(
+	ctx *FeatureContext,
+	event emulationEvent,
+	ownerKind schema.GroupVersionKind,
+	obj runtime.Object,
+	owners runtime.Object,
+	reconcile func(*FeatureContext, types.NamespacedName) error,
+) error {
+	if event.operation != emulationDelete {
+		if err := getIfExists(ctx, event.key, obj); err != nil {
+			return err
+		}
+
+		accessor := obj.(metav1.Object)
+		if accessor.GetName() == "" {
+			return nil
+		}
+		ref := metav1.GetControllerOf(accessor)
+		if ref == nil || ref.APIVersion != ownerKind.GroupVersion().String() || ref.Kind != ownerKind.Kind {
+			return nil
+		}
+		return reconcile(ctx, types.NamespacedName{Namespace: event.key.Namespace, Name: ref.Name})
+	}
+
+	if err := ctx.serverClient().List(ctx.ctx, owners, client.InNamespace(event.key.Namespace)); err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(owners)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		owner, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if err := reconcile(ctx, types.NamespacedName{Namespace: owner.GetNamespace(), Name: owner.GetName()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getIfExists fetches the given object; the object is left empty if it
+// doesn't exist.
+func getIfExists(ctx *FeatureContext, key types.NamespacedName, obj runtime.Object) error {
+	err := ctx.serverClient().Get(ctx.ctx, key, obj)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// ownedPods returns all pods controlled by the given owner.
+func ownedPods(ctx *FeatureContext, owner metav1.Object) ([]*corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	if err := ctx.serverClient().List(ctx.ctx, pods, client.InNamespace(owner.GetNamespace())); err != nil {
+		return nil, err
+	}
+
+	var owned []*corev1.Pod
+	for i := range pods.Items {
+		if metav1.IsControlledBy(&pods.Items[i], owner) {
+			owned = append(owned, &pods.Items[i])
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool { return owned[i].Name < owned[j].Name })
+	return owned, nil
+}
+
+// podFromTemplate returns a new pod based on the given template, controlled
+// by the given owner.
+func podFromTemplate(ctx *FeatureContext, template corev1.PodTemplateSpec, owner metav1.Object, ownerKind schema.GroupVersionKind, name string) *corev1.Pod {
+	template = *template.DeepCopy()
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         owner.GetNamespace(),
+			UID:               types.UID(uuid.New().String()),
+			CreationTimestamp: ctx.now(),
+			Labels:            template.Labels,
+			Annotations:       template.Annotations,
+			OwnerReferences:   []metav1.OwnerReference{*metav1.NewControllerRef(owner, ownerKind)},
+		},
+		Spec: template.Spec,
+	}
+}
+
+// podTemplateHash returns the hash of a pod template, used to name the
+// ReplicaSets managed by a Deployment.
+func podTemplateHash(template corev1.PodTemplateSpec, collisionCount *int32) string {
+	hasher := fnv.New32a()
+	data, _ := json.Marshal(template)
+	_, _ = hasher.Write(data)
+	if collisionCount != nil {
+		_, _ = hasher.Write([]byte(strconv.FormatInt(int64(*collisionCount), 10)))
+	}
+	return utilrand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
+
+// setDeploymentCondition sets the given condition, updating the transition
+// time only when its status changes.
+func setDeploymentCondition(
+	conditions []appsv1.DeploymentCondition,
+	now metav1.Time,
+	conditionType appsv1.DeploymentConditionType,
+	value bool,
+	trueReason, falseReason string,
+) []appsv1.DeploymentCondition {
+	status, reason := corev1.ConditionFalse, falseReason
+	if value {
+		status, reason = corev1.ConditionTrue, trueReason
+	}
+
+	for i, condition := range conditions {
+		if condition.Type != conditionType {
+			continue
+		}
+		if condition.Status != status || condition.Reason != reason {
+			conditions = append([]appsv1.DeploymentCondition{}, conditions...)
+			conditions[i].Status, conditions[i].Reason = status, reason
+			conditions[i].LastUpdateTime, conditions[i].LastTransitionTime = now, now
+		}
+		return conditions
+	}
+
+	return append(conditions, appsv1.DeploymentCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+	})
+}
+
+// withLabel returns a copy of the given labels with the given label.
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	copied := map[string]string{key: value}
+	for k, v := range labels {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// withSelectorLabel returns a copy of the given selector matching also the
+// given label.
+func withSelectorLabel(selector *metav1.LabelSelector, key, value string) *metav1.LabelSelector {
+	if selector == nil {
+		selector = &metav1.LabelSelector{}
+	}
+	selector = selector.DeepCopy()
+	selector.MatchLabels = withLabel(selector.MatchLabels, key, value)
+	return selector
+}
+
+// isPodActive returns true if the pod is neither terminated nor removed.
+func isPodActive(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil &&
+		pod.Status.Phase != corev1.PodSucceeded &&
+		pod.Status.Phase != corev1.PodFailed
+}
+
+// isPodReady returns true if the pod has the condition Ready.
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isJobFinished returns true if the job is complete or failed.
+func isJobFinished(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// int32OrDefault returns the value of the given pointer, or the default value
+// if it is nil.
+func int32OrDefault(value *int32, defaultValue int32) int32 {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
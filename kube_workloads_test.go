@@ -0,0 +1,202 @@
+package kubernetes_ctx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+var (
+	deploymentGVK  = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	statefulSetGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}
+	jobGVK         = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+)
+
+// initFakeScenarioWithControllers generates a godoc ScenarioContext and a
+// FeatureContext with a fake client, the workload controllers and a
+// simulated kubelet.
+func initFakeScenarioWithControllers(t *testing.T, opts kubernetes_ctx.KubeletOptions) *kubernetes_ctx.FeatureContext {
+	return initFakeScenario(t, kubernetes_ctx.WithWorkloadControllers(), kubernetes_ctx.WithKubeletSimulator(opts))
+}
+
+// listPods returns all pods of the default namespace.
+func listPods(t *testing.T, ctx *kubernetes_ctx.FeatureContext) []corev1.Pod {
+	pods := &corev1.PodList{}
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), pods, ctrlclient.InNamespace("default")))
+	return pods.Items
+}
+
+func TestWorkloadControllers_Deployment(t *testing.T) {
+	ctx := initFakeScenarioWithControllers(t, kubernetes_ctx.KubeletOptions{})
+	key := types.NamespacedName{Namespace: "default", Name: "nginx"}
+
+	require.NoError(t, ctx.Create(deploymentGVK, key, yamlToUnstructured(t, `
+spec:
+  replicas: 3
+  selector: {matchLabels: {app: nginx}}
+  template:
+    metadata: {labels: {app: nginx}}
+    spec: {containers: [{name: app, image: nginx}]}
+`)))
+
+	replicaSets := &appsv1.ReplicaSetList{}
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), replicaSets, ctrlclient.InNamespace("default")))
+	require.Len(t, replicaSets.Items, 1)
+	rs := replicaSets.Items[0]
+	hash := rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+	assert.NotEmpty(t, hash)
+	assert.Equal(t, "nginx-"+hash, rs.Name)
+	assert.Equal(t, int32(3), rs.Status.ReadyReplicas)
+
+	pods := listPods(t, ctx)
+	require.Len(t, pods, 3)
+	for _, pod := range pods {
+		assert.Equal(t, hash, pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey])
+		assert.Equal(t, rs.UID, pod.OwnerReferences[0].UID)
+		assert.Equal(t, corev1.PodRunning, pod.Status.Phase)
+	}
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), key, deployment))
+	assert.Equal(t, int32(3), deployment.Status.Replicas)
+	assert.Equal(t, int32(3), deployment.Status.ReadyReplicas)
+	assert.Equal(t, int32(3), deployment.Status.AvailableReplicas)
+	assert.Equal(t, int32(3), deployment.Status.UpdatedReplicas)
+
+	// a new pod template creates a new ReplicaSet
+	deployment.Spec.Template.Spec.Containers[0].Image = "nginx:1.19"
+	require.NoError(t, ctx.Client().Update(ctx.GoContext(), deployment))
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), replicaSets, ctrlclient.InNamespace("default")))
+	require.Len(t, replicaSets.Items, 2)
+	for _, rs := range replicaSets.Items {
+		if rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == hash {
+			assert.Equal(t, int32(0), *rs.Spec.Replicas)
+		} else {
+			assert.Equal(t, int32(3), rs.Status.ReadyReplicas)
+		}
+	}
+	assert.Len(t, listPods(t, ctx), 3)
+
+	// all owned resources are removed with the deployment
+	_, err := ctx.Delete(deploymentGVK, key)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Client().List(ctx.GoContext(), replicaSets, ctrlclient.InNamespace("default")))
+	assert.Empty(t, replicaSets.Items)
+	assert.Empty(t, listPods(t, ctx))
+}
+
+func TestWorkloadControllers_DeploymentNotReady(t *testing.T) {
+	ctx := initFakeScenarioWithControllers(t, kubernetes_ctx.KubeletOptions{Manual: true})
+	key := types.NamespacedName{Namespace: "default", Name: "nginx"}
+
+	require.NoError(t, ctx.Create(deploymentGVK, key, yamlToUnstructured(t, `
+spec:
+  replicas: 2
+  selector: {matchLabels: {app: nginx}}
+  template:
+    metadata: {labels: {app: nginx}}
+    spec: {containers: [{name: app, image: nginx}]}
+`)))
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), key, deployment))
+	assert.Equal(t, int32(2), deployment.Status.Replicas)
+	assert.Equal(t, int32(0), deployment.Status.ReadyReplicas)
+	assert.Equal(t, int32(2), deployment.Status.UnavailableReplicas)
+
+	pods := listPods(t, ctx)
+	require.Len(t, pods, 2)
+	require.NoError(t, ctx.MakePodReady(types.NamespacedName{Namespace: "default", Name: pods[0].Name}))
+
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), key, deployment))
+	assert.Equal(t, int32(1), deployment.Status.ReadyReplicas)
+	assert.Equal(t, int32(1), deployment.Status.AvailableReplicas)
+}
+
+func TestWorkloadControllers_StatefulSet(t *testing.T) {
+	ctx := initFakeScenarioWithControllers(t, kubernetes_ctx.KubeletOptions{})
+	key := types.NamespacedName{Namespace: "default", Name: "db"}
+
+	require.NoError(t, ctx.Create(statefulSetGVK, key, yamlToUnstructured(t, `
+spec:
+  replicas: 3
+  serviceName: db
+  selector: {matchLabels: {app: db}}
+  template:
+    metadata: {labels: {app: db}}
+    spec: {containers: [{name: app, image: postgres}]}
+`)))
+
+	pods := listPods(t, ctx)
+	require.Len(t, pods, 3)
+	for i, pod := range pods {
+		assert.Equal(t, []string{"db-0", "db-1", "db-2"}[i], pod.Name)
+	}
+
+	sts := &appsv1.StatefulSet{}
+	require.NoError(t, ctx.Client().Get(ctx.GoContext(), key, sts))
+	assert.Equal(t, int32(3), sts.Status.ReadyReplicas)
+
+	replicas := int32(1)
+	sts.Spec.Replicas = &replicas
+	require.NoError(t, ctx.Client().Update(ctx.GoContext(), sts))
+	pods = listPods(t, ctx)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "db-0", pods[0].Name)
+}
+
+func TestWorkloadControllers_Job(t *testing.T) {
+	tests := map[string]struct {
+		exitCode  int32
+		condition batchv1.JobConditionType
+		succeeded int32
+		failed    int32
+	}{
+		"Complete": {exitCode: 0, condition: batchv1.JobComplete, succeeded: 1},
+		"Failed":   {exitCode: 1, condition: batchv1.JobFailed, failed: 2},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := initFakeScenarioWithControllers(t, kubernetes_ctx.KubeletOptions{})
+			key := types.NamespacedName{Namespace: "default", Name: "migrate"}
+
+			require.NoError(t, ctx.Create(jobGVK, key, yamlToUnstructured(t, `
+spec:
+  backoffLimit: 1
+  template:
+    spec:
+      restartPolicy: Never
+      containers: [{name: app, image: migrate}]
+`)))
+
+			for i := 0; i < 2; i++ {
+				for _, pod := range listPods(t, ctx) {
+					if pod.Status.Phase == corev1.PodRunning {
+						assert.Equal(t, key.Name, pod.Labels["job-name"])
+						require.NoError(t, ctx.TerminatePodContainer(types.NamespacedName{Namespace: "default", Name: pod.Name}, "app", test.exitCode))
+					}
+				}
+			}
+
+			job := &batchv1.Job{}
+			require.NoError(t, ctx.Client().Get(ctx.GoContext(), key, job))
+			require.Len(t, job.Status.Conditions, 1)
+			assert.Equal(t, test.condition, job.Status.Conditions[0].Type)
+			assert.Equal(t, test.succeeded, job.Status.Succeeded)
+			assert.Equal(t, test.failed, job.Status.Failed)
+			assert.Equal(t, int32(0), job.Status.Active)
+			assert.NotNil(t, job.Status.StartTime)
+			assert.Equal(t, test.condition == batchv1.JobComplete, job.Status.CompletionTime != nil)
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package kubernetes_ctx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultStepPrefix is the word starting most of the provided steps, which
+// can be replaced through WithStepPrefix.
+const DefaultStepPrefix = "Kubernetes"
+
+// StepGroup is a set of steps sharing the same capability.
+type StepGroup string
+
+const (
+	StepsCreate      StepGroup = "create"
+	StepsAssert      StepGroup = "assert"
+	StepsLabels      StepGroup = "labels"
+	StepsPatch       StepGroup = "patch"
+	StepsDelete      StepGroup = "delete"
+	StepsValidation  StepGroup = "validation"
+	StepsFailures    StepGroup = "failures"
+	StepsVersions    StepGroup = "versions"
+	StepsFaults      StepGroup = "faults"
+	StepsCalls       StepGroup = "calls"
+	StepsWatch       StepGroup = "watch"
+	StepsCheckpoints StepGroup = "checkpoints"
+	StepsPods        StepGroup = "pods"
+	StepsServices    StepGroup = "services"
+	StepsTime        StepGroup = "time"
+	StepsRBAC        StepGroup = "rbac"
+	StepsCustom      StepGroup = "custom"
+)
+
+// stepGroups lists the steps provided by each group, in the order of
+// their registration.
+var stepGroups = []struct {
+	group StepGroup
+	steps []func(*FeatureContext, ScenarioContext)
+}{
+	{StepsCreate, []func(*FeatureContext, ScenarioContext){
+		CreateSingleResource, CreateSingleResourceWith, CreateSingleResourceFrom, CreateMultiResources,
+	}},
+	{StepsAssert, []func(*FeatureContext, ScenarioContext){
+		ResourceExists, ResourceNotExists,
+		ResourceIsSimilarTo, ResourceIsNotSimilarTo, ResourceIsEqualTo, ResourceIsNotEqualTo,
+		ResourceHasField, ResourceDoesntHaveField, ResourceHasFieldEqual, ResourceHasFieldNotEqual,
+		CountResources, CountNamespacedResources, OnlyNamespacedResources,
+	}},
+	{StepsLabels, []func(*FeatureContext, ScenarioContext){
+		ResourceHasLabel, ResourceDoesntHaveLabel, ResourceHasLabelEqual, ResourceHasLabelNotEqual,
+		ResourceHasAnnotation, ResourceDoesntHaveAnnotation, ResourceHasAnnotationEqual, ResourceHasAnnotationNotEqual,
+		LabelizeResource, RemoveResourceLabel, UpdateResourceLabel,
+		AnnotateResource, RemoveResourceAnnotation, UpdateResourceAnnotation,
+	}},
+	{StepsPatch, []func(*FeatureContext, ScenarioContext){PatchResourceWith}},
+	{StepsValidation, []func(*FeatureContext, ScenarioContext){CreateResourceViolatingRule, PatchResourceViolatingRule}},
+	{StepsFailures, []func(*FeatureContext, ScenarioContext){
+		FailToCreateResource, FailToCreateResourceWith, FailToPatchResource, FailToRemoveResource,
+	}},
+	{StepsVersions, []func(*FeatureContext, ScenarioContext){
+		CaptureResourceVersion, UpdateResourceFromStaleVersion, ResourceVersionChanged,
+	}},
+	{StepsFaults, []func(*FeatureContext, ScenarioContext){InjectRequestFault, ClearRequestFaults}},
+	{StepsCalls, []func(*FeatureContext, ScenarioContext){CountClientCalls, ClientIssuedCalls, ResetClientCalls}},
+	{StepsWatch, []func(*FeatureContext, ScenarioContext){OpenWatch, WatchSawEvents}},
+	{StepsCheckpoints, []func(*FeatureContext, ScenarioContext){SaveCheckpoint, RestoreCheckpoint, ChangedSinceCheckpoint}},
+	{StepsDelete, []func(*FeatureContext, ScenarioContext){RemoveResource, RemoveMultiResource}},
+	{StepsPods, []func(*FeatureContext, ScenarioContext){PodBecomesReady, PodContainerTerminates}},
+	{StepsServices, []func(*FeatureContext, ScenarioContext){ServiceHasEndpoints}},
+	{StepsTime, []func(*FeatureContext, ScenarioContext){TimeAdvances, TimeIs}},
+	{StepsRBAC, []func(*FeatureContext, ScenarioContext){
+		ActAsUser, ActAsServiceAccount, ActAsClusterAdministrator, UserIsAllowed,
+	}},
+}
+
+// AllStepGroups returns all groups of provided steps.
+func AllStepGroups() []StepGroup {
+	groups := make([]StepGroup, 0, len(stepGroups))
+	for _, group := range stepGroups {
+		groups = append(groups, group.group)
+	}
+	return groups
+}
+
+// stepGroupIndex returns the index of the given group of provided steps.
+func stepGroupIndex(group StepGroup) (int, bool) {
+	for i := range stepGroups {
+		if stepGroups[i].group == group {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// StepDefinition describes a step registered on the FeatureContext, in
+// order to generate its documentation.
+type StepDefinition struct {
+	// Group is the capability group of the step.
+	Group StepGroup `json:"group"`
+	// Expr is the regular expression matching the step.
+	Expr string `json:"expr"`
+	// Templates are the human readable forms of the step.
+	Templates []string `json:"templates,omitempty"`
+	// Description explains what the step does.
+	Description string `json:"description,omitempty"`
+	// Examples are some valid uses of the step.
+	Examples []string `json:"examples,omitempty"`
+}
+
+// RegisterStep registers the given step on the scenario and keeps its
+// definition in the step catalog of the FeatureContext. Custom steps
+// registered through it are documented like the provided ones.
+//
+// It returns an error, without registering the step, if the step conflicts
+// with an already registered one: both have the same expression or the
+// examples of one are matched by the other. Steps registered on the godog
+// scenario are checked the same way when registered through the scenario
+// returned by CheckedScenarioContext.
+//
+// NOTE: steps starting with `Kubernetes` use the prefix given to WithStepPrefix.
+func (ctx *FeatureContext) RegisterStep(s ScenarioContext, definition StepDefinition, stepFunc interface{}) error {
+	if definition.Group == "" {
+		definition.Group = StepsCustom
+	}
+	definition = definition.withPrefix(ctx.stepPrefix)
+
+	if err := ctx.checkStepConflicts(definition); err != nil {
+		return err
+	}
+
+	ctx.steps = append(ctx.steps, definition)
+	s.Step(definition.Expr, stepFunc)
+	return nil
+}
+
+// CheckedScenarioContext returns a ScenarioContext wrapping the given one,
+// which registers all steps through RegisterStep: steps are added to the
+// step catalog, and a step conflicting with an already registered one makes
+// Step panic, like godog does with the invalid step expressions.
+func (ctx *FeatureContext) CheckedScenarioContext(s ScenarioContext) ScenarioContext {
+	return &checkedScenarioContext{ScenarioContext: s, ctx: ctx}
+}
+
+// checkedScenarioContext wraps a ScenarioContext in order to check the
+// conflicts of all steps registered on it.
+type checkedScenarioContext struct {
+	ScenarioContext
+	ctx *FeatureContext
+}
+
+func (s *checkedScenarioContext) Step(expr, stepFunc interface{}) {
+	var definition StepDefinition
+	switch expr := expr.(type) {
+	case string:
+		definition.Expr = expr
+	case *regexp.Regexp:
+		definition.Expr = expr.String()
+	default:
+		// NOTE: invalid expressions are reported by godog
+		s.ScenarioContext.Step(expr, stepFunc)
+		return
+	}
+
+	if err := s.ctx.RegisterStep(s.ScenarioContext, definition, stepFunc); err != nil {
+		panic(err)
+	}
+}
+
+// CheckStepConflicts returns an error if one of the given step expressions,
+// registered directly on the godog scenario, conflicts with a step registered
+// on the FeatureContext: both have the same expression or the examples of
+// the registered step are matched by the given expression.
+func (ctx *FeatureContext) CheckStepConflicts(exprs ...string) error {
+	for _, expr := range exprs {
+		if err := ctx.checkStepConflicts(StepDefinition{Expr: expr}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStepConflicts returns an error if the given step conflicts with an
+// already registered one.
+func (ctx *FeatureContext) checkStepConflicts(definition StepDefinition) error {
+	for _, step := range ctx.steps {
+		if step.conflictsWith(definition) {
+			return fmt.Errorf("step '%s' conflicts with the already registered step '%s'", definition.Expr, step.Expr)
+		}
+	}
+	return nil
+}
+
+// registerStep registers a provided step, keeping the first error in order
+// to be returned by NewEmptyFeatureContext.
+func (ctx *FeatureContext) registerStep(s ScenarioContext, definition StepDefinition, stepFunc interface{}) {
+	if err := ctx.RegisterStep(s, definition, stepFunc); err != nil {
+		ctx.setError(err)
+	}
+}
+
+// hasStepGroup returns true if the given group has been selected with
+// WithSteps.
+func (ctx *FeatureContext) hasStepGroup(group StepGroup) bool {
+	for _, g := range ctx.stepGroups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// registerStepGroups registers the steps of all groups selected with
+// WithSteps, and returns the first conflict found.
+func (ctx *FeatureContext) registerStepGroups(s ScenarioContext) error {
+	for _, group := range stepGroups {
+		if !ctx.hasStepGroup(group.group) {
+			continue
+		}
+		for _, step := range group.steps {
+			step(ctx, s)
+		}
+	}
+	return ctx.err
+}
+
+// withPrefix returns the definition with the given prefix instead of
+// DefaultStepPrefix.
+func (definition StepDefinition) withPrefix(prefix string) StepDefinition {
+	if prefix == "" || prefix == DefaultStepPrefix || !strings.HasPrefix(definition.Expr, "^"+DefaultStepPrefix+" ") {
+		return definition
+	}
+
+	replace := func(str string) string {
+		if !strings.HasPrefix(str, DefaultStepPrefix+" ") {
+			return str
+		}
+		return prefix + strings.TrimPrefix(str, DefaultStepPrefix)
+	}
+
+	definition.Expr = "^" + regexp.QuoteMeta(prefix) + strings.TrimPrefix(definition.Expr, "^"+DefaultStepPrefix)
+	definition.Templates = mapStrings(definition.Templates, replace)
+	definition.Examples = mapStrings(definition.Examples, replace)
+	return definition
+}
+
+// conflictsWith returns true if both steps can match the same sentence,
+// based on their expressions and their examples.
+func (definition StepDefinition) conflictsWith(other StepDefinition) bool {
+	return definition.Expr == other.Expr ||
+		examplesMatch(definition.Expr, other.Examples) ||
+		examplesMatch(other.Expr, definition.Examples)
+}
+
+// stepExpressions caches the compiled step expressions, because steps are
+// registered again for each scenario.
+var stepExpressions sync.Map
+
+// examplesMatch returns true if one of the given sentences matches the
+// expression.
+func examplesMatch(expr string, sentences []string) bool {
+	if len(sentences) == 0 {
+		return false
+	}
+
+	cached, exists := stepExpressions.Load(expr)
+	if !exists {
+		rx, err := regexp.Compile(expr)
+		if err != nil {
+			// NOTE: invalid expressions are reported by godog
+			return false
+		}
+		cached, _ = stepExpressions.LoadOrStore(expr, rx)
+	}
+	rx := cached.(*regexp.Regexp)
+
+	for _, sentence := range sentences {
+		if rx.MatchString(sentence) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapStrings applies the given function on all strings.
+func mapStrings(strs []string, fnc func(string) string) []string {
+	if strs == nil {
+		return nil
+	}
+
+	mapped := make([]string, len(strs))
+	for i, str := range strs {
+		mapped[i] = fnc(str)
+	}
+	return mapped
+}
+
+// Steps returns the definitions of all steps registered on the
+// FeatureContext, in the registration order.
+func (ctx FeatureContext) Steps() []StepDefinition {
+	return append([]StepDefinition(nil), ctx.steps...)
+}
+
+// WriteStepsMarkdown writes the given step definitions as a Markdown
+// document, one section per group. Steps without group are written in the
+// StepsCustom section.
+func WriteStepsMarkdown(w io.Writer, steps []StepDefinition) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString("# Kubernetes steps\n")
+
+	var group StepGroup
+	for _, step := range steps {
+		stepGroup := step.Group
+		if stepGroup == "" {
+			stepGroup = StepsCustom
+		}
+		if stepGroup != group {
+			group = stepGroup
+			fmt.Fprintf(buf, "\n## %s%s\n", strings.ToUpper(string(group[:1])), group[1:])
+		}
+
+		buf.WriteString("\n")
+		templates := step.Templates
+		if len(templates) == 0 {
+			templates = []string{step.Expr}
+		}
+		for _, template := range templates {
+			fmt.Fprintf(buf, "- `%s`\n", template)
+		}
+		if step.Description != "" {
+			fmt.Fprintf(buf, "\n%s\n", step.Description)
+		}
+		if len(step.Examples) > 0 {
+			buf.WriteString("\n```gherkin\n")
+			for _, example := range step.Examples {
+				fmt.Fprintf(buf, "%s\n", example)
+			}
+			buf.WriteString("```\n")
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// WriteStepsJSON writes the given step definitions as a JSON array.
+func WriteStepsJSON(w io.Writer, steps []StepDefinition) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(steps)
+}
+
+// WriteStepsCucumber writes the given step definitions as a Cucumber
+// step-definition file (JavaScript syntax), understood by the IDE plugins
+// in order to provide the step autocompletion.
+func WriteStepsCucumber(w io.Writer, steps []StepDefinition) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Code generated by godog-kubernetes-steps. DO NOT EDIT.\n")
+	buf.WriteString("// Step definitions of godog-kubernetes, for the IDE autocompletion only.\n")
+	buf.WriteString("const { Given } = require('@cucumber/cucumber');\n")
+
+	for _, step := range steps {
+		buf.WriteString("\n")
+		for _, template := range step.Templates {
+			fmt.Fprintf(buf, "// %s\n", template)
+		}
+		fmt.Fprintf(buf, "Given(/%s/, function () {});\n", strings.ReplaceAll(step.Expr, "/", `\/`))
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,219 @@
+package kubernetes_ctx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubernetes_ctx "github.com/xunleii/godog-kubernetes"
+)
+
+func TestFeatureContext_Steps(t *testing.T) {
+	scenarioCtx := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewFeatureContext(scenarioCtx, kubernetes_ctx.WithFakeRuntimeClient())
+	require.NoError(t, err)
+
+	steps := ctx.Steps()
+	require.Len(t, steps, len(scenarioCtx.stepList))
+	for i, step := range steps {
+		assert.Equal(t, scenarioCtx.stepList[i].expr, step.Expr)
+		assert.NotEmpty(t, step.Group, step.Expr)
+		assert.NotEmpty(t, step.Templates, step.Expr)
+		assert.NotEmpty(t, step.Description, step.Expr)
+		require.NotEmpty(t, step.Examples, step.Expr)
+
+		rx := regexp.MustCompile(step.Expr)
+		for _, example := range step.Examples {
+			assert.Regexp(t, rx, example)
+		}
+	}
+
+	// the catalog is kept between scenarios
+	scenarioCtx.RunScenario()
+	assert.Len(t, ctx.Steps(), len(steps))
+}
+
+func TestFeatureContext_RegisterStep(t *testing.T) {
+	scenarioCtx := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(scenarioCtx, kubernetes_ctx.WithFakeRuntimeClient())
+	require.NoError(t, err)
+
+	require.NoError(t, ctx.RegisterStep(scenarioCtx, kubernetes_ctx.StepDefinition{Expr: `^my operator is ready$`}, func() error { return nil }))
+
+	require.Len(t, scenarioCtx.stepList, 1)
+	assert.Equal(t, `^my operator is ready$`, scenarioCtx.stepList[0].expr)
+	assert.Equal(t,
+		[]kubernetes_ctx.StepDefinition{{Group: kubernetes_ctx.StepsCustom, Expr: `^my operator is ready$`}},
+		ctx.Steps(),
+	)
+}
+
+func TestWriteSteps(t *testing.T) {
+	steps := []kubernetes_ctx.StepDefinition{
+		{
+			Group:       kubernetes_ctx.StepsTime,
+			Expr:        `^Time is '([^']+)'$`,
+			Templates:   []string{"Time is '<Time>'"},
+			Description: "It sets the time.",
+			Examples:    []string{"Time is '2024-01-01T00:00:00Z'"},
+		},
+		{Group: kubernetes_ctx.StepsCustom, Expr: `^my operator/controller is ready$`},
+	}
+
+	t.Run("Markdown", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, kubernetes_ctx.WriteStepsMarkdown(buf, steps))
+		assert.Equal(t, "# Kubernetes steps\n"+
+			"\n## Time\n"+
+			"\n- `Time is '<Time>'`\n"+
+			"\nIt sets the time.\n"+
+			"\n```gherkin\nTime is '2024-01-01T00:00:00Z'\n```\n"+
+			"\n## Custom\n"+
+			"\n- `^my operator/controller is ready$`\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("MarkdownWithoutGroup", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, kubernetes_ctx.WriteStepsMarkdown(buf, []kubernetes_ctx.StepDefinition{
+			{Expr: `^my operator is ready$`},
+			{Group: kubernetes_ctx.StepsCustom, Expr: `^my operator is stopped$`},
+		}))
+		assert.Equal(t, "# Kubernetes steps\n"+
+			"\n## Custom\n"+
+			"\n- `^my operator is ready$`\n"+
+			"\n- `^my operator is stopped$`\n",
+			buf.String(),
+		)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, kubernetes_ctx.WriteStepsJSON(buf, steps))
+
+		var decoded []kubernetes_ctx.StepDefinition
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, steps, decoded)
+	})
+
+	t.Run("Cucumber", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, kubernetes_ctx.WriteStepsCucumber(buf, steps))
+		assert.Contains(t, buf.String(), "// Time is '<Time>'\nGiven(/^Time is '([^']+)'$/, function () {});\n")
+		assert.Contains(t, buf.String(), `Given(/^my operator\/controller is ready$/, function () {});`)
+	})
+}
+
+func TestWithSteps(t *testing.T) {
+	scenarioCtx := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioCtx,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithSteps(kubernetes_ctx.StepsCreate, kubernetes_ctx.StepsTime, kubernetes_ctx.StepsCreate),
+	)
+	require.NoError(t, err)
+
+	assert.Len(t, scenarioCtx.stepList, 6)
+	for _, step := range ctx.Steps() {
+		assert.Contains(t, []kubernetes_ctx.StepGroup{kubernetes_ctx.StepsCreate, kubernetes_ctx.StepsTime}, step.Group)
+	}
+
+	_, err = kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithSteps("unknown"),
+	)
+	assert.EqualError(t, err, "unknown step group 'unknown'")
+}
+
+func TestWithStepPrefix(t *testing.T) {
+	scenarioCtx := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewEmptyFeatureContext(
+		scenarioCtx,
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithSteps(kubernetes_ctx.StepsDelete, kubernetes_ctx.StepsTime),
+		kubernetes_ctx.WithStepPrefix("the cluster"),
+	)
+	require.NoError(t, err)
+
+	steps := ctx.Steps()
+	require.Len(t, steps, 4)
+	assert.Equal(t, `^the cluster removes the following resources$`, steps[1].Expr)
+	assert.Equal(t, []string{"the cluster removes the following resources <RESOURCES_TABLE>"}, steps[1].Templates)
+	assert.Equal(t, []string{"the cluster removes the following resources"}, steps[1].Examples)
+	assert.Equal(t, `^Time is '([^']+)'$`, steps[3].Expr)
+
+	for _, step := range steps {
+		for _, example := range step.Examples {
+			assert.Regexp(t, step.Expr, example)
+		}
+	}
+
+	_, err = kubernetes_ctx.NewEmptyFeatureContext(
+		MockScenarioContext(),
+		kubernetes_ctx.WithFakeRuntimeClient(),
+		kubernetes_ctx.WithStepPrefix(" "),
+	)
+	assert.EqualError(t, err, "step prefix must not be empty")
+}
+
+func TestFeatureContext_RegisterStep_Conflicts(t *testing.T) {
+	tests := map[string]kubernetes_ctx.StepDefinition{
+		"SameExpression": {Expr: `^Time is '([^']+)'$`},
+		"MatchesExample": {Expr: `^Kubernetes has (.+)$`},
+		"ExampleMatched": {Expr: `^Kubernetes removes v1/Pod '(.+)'$`, Examples: []string{"Kubernetes removes v1/Pod 'default/pod'"}},
+	}
+
+	for name, definition := range tests {
+		t.Run(name, func(t *testing.T) {
+			scenarioCtx := MockScenarioContext()
+			ctx, err := kubernetes_ctx.NewFeatureContext(scenarioCtx, kubernetes_ctx.WithFakeRuntimeClient())
+			require.NoError(t, err)
+
+			err = ctx.RegisterStep(scenarioCtx, definition, func() error { return nil })
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), fmt.Sprintf("step '%s' conflicts with the already registered step", definition.Expr))
+			assert.Len(t, ctx.Steps(), len(scenarioCtx.stepList))
+		})
+	}
+}
+
+func TestFeatureContext_CheckStepConflicts(t *testing.T) {
+	scenarioCtx := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewFeatureContext(scenarioCtx, kubernetes_ctx.WithFakeRuntimeClient())
+	require.NoError(t, err)
+
+	assert.NoError(t, ctx.CheckStepConflicts(`^my operator is ready$`, `^my operator has (\d+) replicas$`))
+	assert.EqualError(t,
+		ctx.CheckStepConflicts(`^my operator is ready$`, `^Time is '([^']+)'$`),
+		`step '^Time is '([^']+)'$' conflicts with the already registered step '^Time is '([^']+)'$'`,
+	)
+	assert.Error(t, ctx.CheckStepConflicts(`^Kubernetes has (.+)$`))
+}
+
+func TestFeatureContext_CheckedScenarioContext(t *testing.T) {
+	scenarioCtx := MockScenarioContext()
+	ctx, err := kubernetes_ctx.NewFeatureContext(scenarioCtx, kubernetes_ctx.WithFakeRuntimeClient())
+	require.NoError(t, err)
+	steps := len(ctx.Steps())
+
+	checked := ctx.CheckedScenarioContext(scenarioCtx)
+	checked.Step(`^my operator is ready$`, func() error { return nil })
+	checked.Step(regexp.MustCompile(`^my operator has (\d+) replicas$`), func(int) error { return nil })
+	require.Len(t, ctx.Steps(), steps+2)
+	assert.Equal(t, kubernetes_ctx.StepDefinition{Group: kubernetes_ctx.StepsCustom, Expr: `^my operator is ready$`}, ctx.Steps()[steps])
+	assert.Len(t, scenarioCtx.stepList, steps+2)
+
+	assert.PanicsWithError(t,
+		`step '^Kubernetes has (.+)$' conflicts with the already registered step '^Kubernetes has (`+kubernetes_ctx.RxGroupVersionKind+`) '(`+kubernetes_ctx.RxNamespacedName+`)'$'`,
+		func() { checked.Step(`^Kubernetes has (.+)$`, func(string) error { return nil }) },
+	)
+	assert.Len(t, ctx.Steps(), steps+2)
+	assert.Len(t, scenarioCtx.stepList, steps+2)
+}